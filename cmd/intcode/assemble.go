@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/asm"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/format"
+)
+
+func assemble(args []string) error {
+	flags := flag.NewFlagSet("assemble", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected an assembly file, got %d arguments", flags.NArg())
+	}
+
+	source, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("could not read file %s: %w", flags.Arg(0), err)
+	}
+
+	memory, err := asm.Assemble(string(source))
+	if err != nil {
+		return err
+	}
+
+	return format.WritePlain(os.Stdout, memory)
+}
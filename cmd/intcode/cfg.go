@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/analysis"
+)
+
+func cfg(args []string) error {
+	flags := flag.NewFlagSet("cfg", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a program file, got %d arguments", flags.NArg())
+	}
+
+	memory, err := readProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	graph, err := analysis.Build(memory)
+	if err != nil {
+		return err
+	}
+
+	return graph.WriteDOT(os.Stdout)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/format"
+)
+
+func convert(args []string) error {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	to := flags.String("to", "text", "format to write the program in: plain, text or binary")
+	outputFile := flags.String("o", "", "file to write the program to instead of stdout")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a program file, got %d arguments", flags.NArg())
+	}
+
+	p, err := format.Load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	return writeProgram(p, *to, *outputFile)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/coverage"
+)
+
+// runInputs is a flag that can be repeated, holding the inputs of each run
+type runInputs [][]int
+
+func (r *runInputs) String() string {
+	runs := make([]string, len(*r))
+	for i, inputs := range *r {
+		runs[i] = fmt.Sprint(inputs)
+	}
+	return strings.Join(runs, " ")
+}
+
+func (r *runInputs) Set(s string) error {
+	inputs, err := parseInputs(s)
+	if err != nil {
+		return err
+	}
+	*r = append(*r, inputs)
+	return nil
+}
+
+func coverageReport(args []string) error {
+	flags := flag.NewFlagSet("coverage", flag.ExitOnError)
+	var runs runInputs
+	flags.Var(&runs, "input", "comma separated list of inputs of a run, repeat it to aggregate several runs")
+	htmlFlag := flags.String("html", "", "file where an HTML report is written")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a program file, got %d arguments", flags.NArg())
+	}
+
+	memory, err := readProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if len(runs) == 0 {
+		runs = runInputs{nil}
+	}
+
+	c := coverage.New()
+	for i, inputs := range runs {
+		intcodeProgram := intcode.NewIntcodeFromState(
+			memory, 0, 0, inputQueue(inputs), func(int) {}, intcode.WithObserver(c),
+		)
+
+		err = run(context.Background(), intcodeProgram)
+		if err != nil {
+			return fmt.Errorf("run %d: %w", i+1, err)
+		}
+	}
+
+	if *htmlFlag == "" {
+		return c.Report(os.Stdout, memory)
+	}
+
+	f, err := os.Create(*htmlFlag)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.WriteHTML(f, memory)
+}
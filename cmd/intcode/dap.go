@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/dap"
+)
+
+// serveDAP serves a Debug Adapter Protocol session over stdio. Editors launch it as the
+// debug adapter of Intcode programs, passing the program file in the launch request:
+//
+//	{"type": "intcode", "request": "launch", "program": "day05/day05.txt", "stopOnEntry": true}
+func serveDAP(args []string) error {
+	flags := flag.NewFlagSet("dap", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 0 {
+		return fmt.Errorf("expected no arguments, got %d", flags.NArg())
+	}
+
+	return dap.NewServer(os.Stdin, os.Stdout).Serve()
+}
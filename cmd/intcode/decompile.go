@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/decompiler"
+)
+
+func decompile(args []string) error {
+	flags := flag.NewFlagSet("decompile", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a program file, got %d arguments", flags.NArg())
+	}
+
+	memory, err := readProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	pseudocode, err := decompiler.Decompile(memory)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(pseudocode)
+	return nil
+}
@@ -0,0 +1,166 @@
+// Command intcode provides tools to work with Intcode programs
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/format"
+)
+
+// errNoMoreInputs is raised by the onInput function of inputQueue once all the inputs have been read
+var errNoMoreInputs = errors.New("intcode program expects more inputs than provided")
+
+const usage = `Usage: intcode COMMAND [OPTIONS] [SESSION] PROGRAM
+
+Commands:
+  assemble  assembles a program written in the syntax of disassembly listings
+  cfg       writes the control flow graph of a program in Graphviz DOT
+  coverage  runs a program and reports which instructions and branches were executed
+  convert   converts a program between the plain, text and binary formats
+  dap       serves a Debug Adapter Protocol session over stdio
+  decompile writes a program as structured Go-like pseudocode
+  memory    runs a program taking snapshots of its memory and shows the cells which change
+  metrics   runs a program and exports its metrics in the Prometheus text format
+  optimize  rewrites the code of a program proven not to be modified into faster equivalent code
+  patch     applies the patches of a patch file to a program
+  profile   runs a program and reports where it spends its time
+  record    runs a program and records its inputs and outputs in a session
+  replay    replays a session against a program and reports the first divergence
+  serve     serves an HTTP/JSON API to run programs in sessions
+  spec      runs the spec files of directories and reports the specs which fail
+  transpile translates a program into a Go package
+`
+
+var commands = map[string]func(args []string) error{
+	"assemble":  assemble,
+	"cfg":       cfg,
+	"convert":   convert,
+	"coverage":  coverageReport,
+	"dap":       serveDAP,
+	"decompile": decompile,
+	"memory":    memorySnapshots,
+	"metrics":   exportMetrics,
+	"optimize":  optimize,
+	"patch":     applyPatches,
+	"profile":   profile,
+	"record":    record,
+	"replay":    replaySession,
+	"serve":     serve,
+	"spec":      runSpecs,
+	"transpile": transpile,
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("intcode: ")
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		log.Fatalf("unknown command %s", os.Args[1])
+	}
+
+	err := command(os.Args[2:])
+	if err != nil {
+		log.Fatalf("%s: %v", os.Args[1], err)
+	}
+}
+
+// readProgram reads the memory of the program stored in filename, in the text or binary format
+func readProgram(filename string) ([]int, error) {
+	p, err := format.Load(filename)
+	if err != nil {
+		return nil, err
+	}
+	return p.Memory, nil
+}
+
+// writeProgram writes p in the format given by to, which is plain, text or binary, to
+// outputFile or to stdout if it is empty
+func writeProgram(p *format.Program, to, outputFile string) error {
+	if to != "plain" && to != "text" && to != "binary" {
+		return fmt.Errorf("unknown format %s", to)
+	}
+
+	out := os.Stdout
+	if outputFile != "" {
+		var err error
+		out, err = os.Create(outputFile)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	w := bufio.NewWriter(out)
+	var err error
+	switch to {
+	case "plain":
+		err = format.WritePlain(w, p.Memory)
+	case "text":
+		err = format.WriteText(w, p)
+	case "binary":
+		err = format.WriteBinary(w, p)
+	}
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// parseInputs parses a comma separated list of input values
+func parseInputs(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var inputs []int
+	for _, token := range strings.Split(s, ",") {
+		input, err := strconv.Atoi(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input %s: %w", token, err)
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
+
+// inputQueue returns an onInput function that reads inputs in order and panics with
+// errNoMoreInputs once all of them have been read, which run turns into an error
+func inputQueue(inputs []int) func() int {
+	return func() int {
+		if len(inputs) == 0 {
+			panic(errNoMoreInputs)
+		}
+		input := inputs[0]
+		inputs = inputs[1:]
+		return input
+	}
+}
+
+// run runs program until it halts, fails or ctx is done, and returns errNoMoreInputs if it
+// expects more inputs than its inputQueue provides. The input instruction is not executed then.
+func run(ctx context.Context, program *intcode.Intcode) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r != errNoMoreInputs {
+				panic(r)
+			}
+			err = errNoMoreInputs
+		}
+	}()
+	return program.RunContext(ctx)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/profiler"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/snapshot"
+)
+
+// correlations is the number of cells listed when correlating memory with outputs
+const correlations = 10
+
+// memorySnapshots runs a program taking snapshots of its memory and shows how it changes. For
+// instance, the cell holding the position of the ball of day13 is found with:
+//
+//	intcode memory -set 0=2 -every 3 -fill 0 -view none -correlate 0 -where 2=4 day13/day13.txt
+func memorySnapshots(args []string) error {
+	flags := flag.NewFlagSet("memory", flag.ExitOnError)
+	inputFlag := flags.String("input", "", "comma separated list of inputs")
+	setFlag := flags.String("set", "", "comma separated list of POSITION=VALUE stored in memory before running")
+	fillFlag := flags.String("fill", "", "input read once all the inputs have been read, by default the program stops")
+	everyFlag := flags.Int("every", 0, "take a snapshot each time the program writes this number of outputs")
+	atFlag := flags.String("at", "", "comma separated list of addresses whose instructions take a snapshot once executed")
+	maxSteps := flags.Int("max-steps", 1<<26, "maximum number of instructions executed")
+	viewFlag := flags.String("view", "table", "how snapshots are shown: table, heatmap, diff or none")
+	positionsFlag := flags.String("positions", "", "comma separated list of cells shown in the table, by default the ones which change")
+	correlateFlag := flags.Int("correlate", -1, "index of the output, among the ones of each snapshot, searched in memory")
+	whereFlag := flags.String("where", "", "only correlate the snapshots whose outputs hold INDEX=VALUE")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a program file, got %d arguments", flags.NArg())
+	}
+
+	memory, err := readProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	inputs, err := parseInputs(*inputFlag)
+	if err != nil {
+		return err
+	}
+
+	memory, err = setCells(memory, *setFlag)
+	if err != nil {
+		return err
+	}
+
+	trigger, err := snapshotTrigger(*everyFlag, *atFlag)
+	if err != nil {
+		return err
+	}
+
+	fill, filled := 0, *fillFlag != ""
+	if filled {
+		fill, err = strconv.Atoi(*fillFlag)
+		if err != nil {
+			return fmt.Errorf("invalid fill input %s: %w", *fillFlag, err)
+		}
+	}
+	read := 0
+	onInput := func() int {
+		read++
+		if read <= len(inputs) {
+			return inputs[read-1]
+		}
+		return fill
+	}
+
+	recorder := snapshot.NewRecorder(memory, trigger)
+	intcodeProgram := intcode.NewIntcodeFromState(
+		memory, 0, 0, onInput, func(int) {}, intcode.WithObserver(recorder),
+	)
+	for steps := 0; !intcodeProgram.Halted() && steps < *maxSteps; steps++ {
+		if !filled && read == len(inputs) && nextIsInput(intcodeProgram) {
+			fmt.Fprintln(os.Stderr, "program expects more inputs than provided")
+			break
+		}
+		if err := intcodeProgram.Step(); err != nil {
+			return err
+		}
+	}
+	recorder.Take()
+	snapshots := recorder.Snapshots
+
+	switch *viewFlag {
+	case "table":
+		positions := snapshot.Changing(snapshots)
+		if *positionsFlag != "" {
+			positions, err = parseInputs(*positionsFlag)
+			if err != nil {
+				return err
+			}
+		}
+		fmt.Print(snapshot.Table(snapshots, positions))
+	case "heatmap":
+		fmt.Printf("Changes per cell over %d snapshots:\n", len(snapshots))
+		fmt.Print(profiler.HeatMap(snapshot.Heat(snapshots)))
+	case "diff":
+		fmt.Print(snapshot.DiffString(snapshot.Diff(snapshots[0], snapshots[len(snapshots)-1])))
+	case "none":
+	default:
+		return fmt.Errorf("unknown view %s", *viewFlag)
+	}
+
+	if *correlateFlag < 0 {
+		return nil
+	}
+	observe, err := outputObservation(*correlateFlag, *whereFlag)
+	if err != nil {
+		return err
+	}
+
+	found := snapshot.Correlate(snapshots, observe)
+	if len(found) > correlations {
+		found = found[:correlations]
+	}
+	fmt.Printf("Cells holding output %d:\n", *correlateFlag)
+	for _, correlation := range found {
+		fmt.Printf("%6d: %d of %d snapshots\n", correlation.Position, correlation.Matches, correlation.Observed)
+	}
+	return nil
+}
+
+// snapshotTrigger returns the trigger taking a snapshot every outputs and at the addresses
+// listed in at, or after each output if none is given
+func snapshotTrigger(every int, at string) (snapshot.Trigger, error) {
+	addresses, err := parseInputs(at)
+	if err != nil {
+		return nil, err
+	}
+
+	var triggers []snapshot.Trigger
+	if every > 0 {
+		triggers = append(triggers, snapshot.EveryOutputs(every))
+	}
+	if len(addresses) > 0 {
+		triggers = append(triggers, snapshot.AtAddresses(addresses...))
+	}
+	if len(triggers) == 0 {
+		triggers = append(triggers, snapshot.EveryOutputs(1))
+	}
+	return snapshot.Any(triggers...), nil
+}
+
+// outputObservation returns the observation of the output at index of the snapshots, whose
+// outputs hold the value given by where as INDEX=VALUE if it is not empty
+func outputObservation(index int, where string) (snapshot.Observation, error) {
+	if where == "" {
+		return snapshot.Output(index, -1, 0), nil
+	}
+
+	whereIndex, whereValue, ok := strings.Cut(where, "=")
+	if !ok {
+		return nil, fmt.Errorf("invalid where %s: expected INDEX=VALUE", where)
+	}
+	i, err := strconv.Atoi(whereIndex)
+	if err != nil || i < 0 {
+		return nil, fmt.Errorf("invalid where index %s", whereIndex)
+	}
+	value, err := strconv.Atoi(whereValue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid where value %s: %w", whereValue, err)
+	}
+	return snapshot.Output(index, i, value), nil
+}
+
+// setCells stores in memory the values given by set as a comma separated list of POSITION=VALUE
+func setCells(memory []int, set string) ([]int, error) {
+	if set == "" {
+		return memory, nil
+	}
+
+	for _, token := range strings.Split(set, ",") {
+		p, v, ok := strings.Cut(token, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid cell %s: expected POSITION=VALUE", token)
+		}
+		position, err := strconv.Atoi(p)
+		if err != nil || position < 0 {
+			return nil, fmt.Errorf("invalid position %s", p)
+		}
+		value, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s: %w", v, err)
+		}
+
+		for position >= len(memory) {
+			memory = append(memory, 0)
+		}
+		memory[position] = value
+	}
+	return memory, nil
+}
+
+// nextIsInput returns true if the next instruction of program reads an input
+func nextIsInput(program *intcode.Intcode) bool {
+	n, err := program.Peek(program.InstructionPointer())
+	return err == nil && n%100 == instruction.InputOpcode
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/metrics"
+)
+
+// exportMetrics runs a program and exports its metrics in the Prometheus text format, written
+// to a file that a node exporter textfile collector can read or served to be scraped. The
+// outputs of the program are written to stderr, so that stdout only holds the metrics:
+//
+//	intcode metrics -input 5 -o day05.prom -interval 1s day05/day05.txt
+//	intcode metrics -input 2 -addr localhost:9019 day09/day09.txt
+func exportMetrics(args []string) error {
+	flags := flag.NewFlagSet("metrics", flag.ExitOnError)
+	inputFlag := flags.String("input", "", "comma separated list of inputs")
+	outputFile := flags.String("o", "", "file where the metrics are written once the program halts, stdout if empty")
+	interval := flags.Duration("interval", 0, "interval at which the metrics are also written to the file while the program runs")
+	addr := flags.String("addr", "", "address where the metrics are served at /metrics until the command is interrupted")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a program file, got %d arguments", flags.NArg())
+	}
+	if *interval < 0 || (*interval > 0 && *outputFile == "") {
+		return fmt.Errorf("the interval must be positive and requires an output file")
+	}
+
+	memory, err := readProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	inputs, err := parseInputs(*inputFlag)
+	if err != nil {
+		return err
+	}
+
+	onOutput := func(output int) { fmt.Fprintln(os.Stderr, output) }
+	intcodeProgram := intcode.NewIntcodeFromState(
+		memory, 0, 0, inputQueue(inputs), onOutput, intcode.WithMetrics(),
+	)
+
+	exporter := metrics.NewExporter()
+	err = exporter.Add(filepath.Base(flags.Arg(0)), intcodeProgram)
+	if err != nil {
+		return err
+	}
+
+	// an interrupt cancels the run, so the metrics of a program that runs for too long are
+	// still exported, and then stops serving them
+	ctx, stopNotify := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stopNotify()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	served := make(chan error, 1)
+	if *addr != "" {
+		log.Printf("serving metrics on http://%s/metrics", *addr)
+		go func() { served <- httpServer.ListenAndServe() }()
+	}
+
+	stop := make(chan struct{})
+	written := make(chan error, 1)
+	if *interval > 0 {
+		go func() { written <- exporter.WriteEvery(*outputFile, *interval, stop) }()
+	}
+
+	// the metrics are exported even if the program fails, which is when they are most useful
+	runErr := run(ctx, intcodeProgram)
+
+	switch {
+	case *interval > 0:
+		close(stop)
+		err = <-written
+	case *outputFile != "":
+		err = exporter.WriteFile(*outputFile)
+	default:
+		err = exporter.Write(os.Stdout)
+	}
+	if errors.Is(runErr, context.Canceled) {
+		return errors.New("interrupted before the program halted")
+	}
+	if runErr != nil {
+		return runErr
+	}
+	if err != nil || *addr == "" {
+		return err
+	}
+
+	select {
+	case err = <-served:
+		return err
+	case <-ctx.Done():
+		return httpServer.Shutdown(context.Background())
+	}
+}
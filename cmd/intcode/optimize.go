@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/format"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/optimizer"
+)
+
+func optimize(args []string) error {
+	flags := flag.NewFlagSet("optimize", flag.ExitOnError)
+	to := flags.String("to", "text", "format to write the program in: plain, text or binary")
+	outputFile := flags.String("o", "", "file to write the program to instead of stdout")
+	var runs runInputs
+	flags.Var(&runs, "input", "comma separated list of inputs of a validation run, repeat it to validate several runs")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a program file, got %d arguments", flags.NArg())
+	}
+
+	p, err := format.Load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	result, err := optimizer.Optimize(p.Memory)
+	if err != nil {
+		return err
+	}
+
+	if len(runs) == 0 {
+		runs = runInputs{nil}
+	}
+	comparison, err := result.Validate(p.Memory, runs)
+	if err != nil {
+		return fmt.Errorf("optimized program does not behave as the original: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "rewritten %d instructions: %d folded, %d removed, %d jumps threaded\n",
+		len(result.Rewritten), result.Folded, result.Removed, result.Threaded)
+	for _, reason := range result.Unproven {
+		fmt.Fprintf(os.Stderr, "left instructions unchanged: %s\n", reason)
+	}
+	fmt.Fprintf(os.Stderr, "validated %d runs: %d instructions executed, %d before optimizing\n",
+		len(runs), comparison.OptimizedSteps, comparison.OriginalSteps)
+
+	// the optimizer keeps the layout of the program, so its symbols still hold
+	return writeProgram(&format.Program{Memory: result.Memory, Symbols: p.Symbols}, *to, *outputFile)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/format"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/patch"
+)
+
+func applyPatches(args []string) error {
+	flags := flag.NewFlagSet("patch", flag.ExitOnError)
+	patchFile := flags.String("patches", "", "file holding the patches")
+	names := flags.String("apply", "", "comma separated list of the patches to apply, by default all of them in order")
+	to := flags.String("to", "text", "format to write the program in: plain, text or binary")
+	outputFile := flags.String("o", "", "file to write the program to instead of stdout")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a program file, got %d arguments", flags.NArg())
+	}
+	if *patchFile == "" {
+		return fmt.Errorf("expected a patch file")
+	}
+
+	p, err := format.Load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	patches, err := patch.ParseFile(*patchFile)
+	if err != nil {
+		return err
+	}
+	if *names != "" {
+		patches, err = patch.Select(patches, strings.Split(*names, ",")...)
+		if err != nil {
+			return err
+		}
+	}
+
+	memory, err := patch.Apply(p.Memory, patches...)
+	if err != nil {
+		return err
+	}
+
+	// patches never extend the program, so its symbols still hold
+	return writeProgram(&format.Program{Memory: memory, Symbols: p.Symbols}, *to, *outputFile)
+}
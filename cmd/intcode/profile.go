@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/profiler"
+)
+
+func profile(args []string) error {
+	flags := flag.NewFlagSet("profile", flag.ExitOnError)
+	inputFlag := flags.String("input", "", "comma separated list of inputs")
+	pprofFlag := flags.String("pprof", "", "file where a pprof profile is written")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a program file, got %d arguments", flags.NArg())
+	}
+
+	memory, err := readProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	inputs, err := parseInputs(*inputFlag)
+	if err != nil {
+		return err
+	}
+
+	p := profiler.New()
+	onOutput := func(output int) { fmt.Println(output) }
+
+	intcodeProgram := intcode.NewIntcodeFromState(
+		memory, 0, 0, inputQueue(inputs), onOutput, intcode.WithObserver(p),
+	)
+
+	err = run(context.Background(), intcodeProgram)
+	if err != nil {
+		return err
+	}
+
+	err = p.Report(os.Stdout, memory)
+	if err != nil {
+		return err
+	}
+
+	if *pprofFlag == "" {
+		return nil
+	}
+
+	f, err := os.Create(*pprofFlag)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return p.WritePprof(f, memory)
+}
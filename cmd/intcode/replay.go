@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/replay"
+)
+
+func record(args []string) error {
+	flags := flag.NewFlagSet("record", flag.ExitOnError)
+	inputFlag := flags.String("input", "", "comma separated list of inputs")
+	output := flags.String("o", "session.txt", "file where the session is written")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a program file, got %d arguments", flags.NArg())
+	}
+
+	memory, err := readProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	inputs, err := parseInputs(*inputFlag)
+	if err != nil {
+		return err
+	}
+
+	recorder := replay.NewRecorder()
+	onOutput := func(output int) { fmt.Println(output) }
+
+	intcodeProgram := intcode.NewIntcodeFromState(
+		memory, 0, 0, inputQueue(inputs), onOutput, intcode.WithObserver(recorder),
+	)
+
+	// the session is written even if the program fails, so that the failure can be replayed
+	runErr := run(context.Background(), intcodeProgram)
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = recorder.Session().Write(f)
+	if runErr != nil {
+		return runErr
+	}
+	return err
+}
+
+func replaySession(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		return fmt.Errorf("expected a session file and a program file, got %d arguments", flags.NArg())
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	session, err := replay.ReadSession(f)
+	if err != nil {
+		return err
+	}
+
+	memory, err := readProgram(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	err = replay.Replay(memory, session)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("replayed %d events\n", len(session))
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/server"
+)
+
+// serve serves the HTTP/JSON API of the server package, which lets tools that are not written
+// in Go run Intcode programs:
+//
+//	curl -X POST localhost:8019/programs -d '{"program": "3,9,102,2,9,9,4,9,99,0"}'
+//	curl -X POST localhost:8019/sessions -d '{"program": "1", "input": [21]}'
+//	curl -X POST localhost:8019/sessions/2/run -d '{}'
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8019", "address to listen on")
+	limits := server.DefaultLimits
+	flags.IntVar(&limits.MaxPrograms, "max-programs", limits.MaxPrograms, "maximum number of programs held at the same time")
+	flags.IntVar(&limits.MaxSessions, "max-sessions", limits.MaxSessions, "maximum number of sessions held at the same time")
+	flags.IntVar(&limits.MaxSteps, "max-steps", limits.MaxSteps, "maximum number of instructions executed by a request")
+	flags.IntVar(&limits.Budget, "budget", limits.Budget, "maximum number of instructions executed by a session")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 0 {
+		return fmt.Errorf("expected no arguments, got %d", flags.NArg())
+	}
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server.NewServer(limits),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("serving on http://%s", *addr)
+	return httpServer.ListenAndServe()
+}
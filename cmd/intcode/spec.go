@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/spec"
+)
+
+func runSpecs(args []string) error {
+	flags := flag.NewFlagSet("spec", flag.ExitOnError)
+	verbose := flags.Bool("v", false, "print the specs which pass")
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("expected at least one spec directory")
+	}
+
+	var specs []spec.Spec
+	for _, dir := range flags.Args() {
+		dirSpecs, err := spec.ParseDir(dir)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, dirSpecs...)
+	}
+
+	failed := 0
+	for _, s := range specs {
+		diffs := s.Run()
+		if len(diffs) == 0 {
+			if *verbose {
+				fmt.Printf("PASS %s\n", s)
+			}
+			continue
+		}
+
+		failed++
+		fmt.Printf("FAIL %s\n", s)
+		for _, diff := range diffs {
+			fmt.Printf("    %s:%d: %s\n", s.File, s.Line, diff)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d specs failed", failed, len(specs))
+	}
+	fmt.Printf("%d specs passed\n", len(specs))
+	return nil
+}
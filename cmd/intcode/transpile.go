@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/transpiler"
+)
+
+func transpile(args []string) error {
+	flags := flag.NewFlagSet("transpile", flag.ExitOnError)
+	packageName := flags.String("package", "main", "name of the generated package")
+	output := flags.String("o", "", "file where the generated source is written, instead of stdout")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a program file, got %d arguments", flags.NArg())
+	}
+
+	memory, err := readProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	source, err := transpiler.Transpile(memory, *packageName)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(source)
+		return err
+	}
+	return os.WriteFile(*output, source, 0644)
+}
@@ -5,11 +5,11 @@ import (
 	"fmt"
 
 	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/symbolic"
 )
 
-const (
-	desiredIntcodeOutput = 19690720
-)
+const desiredIntcodeOutput = 19690720
 
 // Day holds the data needed to solve part one and part two
 type Day struct {
@@ -42,26 +42,22 @@ func (d Day) SolvePartOne() (string, error) {
 
 // SolvePartTwo solves part two
 func (d Day) SolvePartTwo() (string, error) {
-	for noun := 0; noun < 100; noun++ {
-		for verb := 0; verb < 100; verb++ {
-
-			intcodeProgram, err := intcode.NewIntcodeProgram(
-				d.program, intcode.MustNotInput, intcode.MustNotOutput,
-			)
-			if err != nil {
-				return "", err
-			}
-
-			intcodeOutput, err := intcodeProgram.RunWithNounAndVerb(noun, verb)
-			if err != nil {
-				return "", err
-			}
+	memory, err := program.Parse(d.program)
+	if err != nil {
+		return "", err
+	}
 
-			if intcodeOutput == desiredIntcodeOutput {
-				return fmt.Sprintf("%d", 100*noun+verb), nil
-			}
-		}
+	variables := []symbolic.Variable{
+		symbolic.Cell("noun", intcode.NounPosition, 0, 99),
+		symbolic.Cell("verb", intcode.VerbPosition, 0, 99),
+	}
+	values, err := symbolic.Solve(memory, variables, symbolic.MemoryResult(intcode.OutputPosition), desiredIntcodeOutput)
+	if errors.Is(err, symbolic.ErrNoSolution) {
+		return "", errors.New("could not find combination to produce desired output")
+	}
+	if err != nil {
+		return "", err
 	}
 
-	return "", errors.New("could not find combination to produce desired output")
+	return fmt.Sprintf("%d", 100*values["noun"]+values["verb"]), nil
 }
@@ -1,21 +1,23 @@
 package day07
 
 import (
+	"errors"
 	"fmt"
 
-	"golang.org/x/sync/errgroup"
-
 	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/scheduler"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/search"
 )
 
 type amplifier struct {
-	id     rune
-	phase  int
-	input  chan int
-	output chan int
+	id    rune
+	phase int
 }
 
-type signalFn func(amplifiers []amplifier, program string) (int, error)
+type signalFn func(amplifiers []amplifier, program *intcode.Intcode) (int, error)
+
+// amplifierIDs are the identifiers of the amplifiers, in the order they are wired
+var amplifierIDs = []rune{'A', 'B', 'C', 'D', 'E'}
 
 // Day holds the data needed to solve part one and part two
 type Day struct {
@@ -31,163 +33,115 @@ func NewDay(input string) (*Day, error) {
 
 // SolvePartOne solves part one
 func (d Day) SolvePartOne() (string, error) {
-	phaseSettings := []int{0, 1, 2, 3, 4}
-	signalFn := thrusterSignalInSeries
-	maxThrusterSignal := getMaxThrusterSignal(d.program, phaseSettings, signalFn)
+	maxThrusterSignal, err := getMaxThrusterSignal(d.program, 0, 4, thrusterSignalInSeries)
+	if err != nil {
+		return "", err
+	}
 	return fmt.Sprintf("%d", maxThrusterSignal), nil
 }
 
 // SolvePartTwo solves part two
 func (d Day) SolvePartTwo() (string, error) {
-	phaseSettings := []int{5, 6, 7, 8, 9}
-	signalFn := thrusterSignalWithFeedbackLoop
-	maxThrusterSignal := getMaxThrusterSignal(d.program, phaseSettings, signalFn)
+	maxThrusterSignal, err := getMaxThrusterSignal(d.program, 5, 9, thrusterSignalWithFeedbackLoop)
+	if err != nil {
+		return "", err
+	}
 	return fmt.Sprintf("%d", maxThrusterSignal), nil
 }
 
-func getMaxThrusterSignal(program string, phaseSettings []int, signalFn signalFn) int {
-	amplifiers := []amplifier{
-		{id: 'A'},
-		{id: 'B'},
-		{id: 'C'},
-		{id: 'D'},
-		{id: 'E'},
+// getMaxThrusterSignal searches the phase settings from minPhase to maxPhase, each amplifier
+// having a different one, which produce the highest thruster signal
+func getMaxThrusterSignal(program string, minPhase, maxPhase int, signalFn signalFn) (int, error) {
+	intcodeProgram, err := intcode.NewIntcodeProgram(program, intcode.MustNotInput, intcode.MustNotOutput)
+	if err != nil {
+		return 0, err
 	}
-	allCombinations := generateAllPhaseSettingsCombinations(phaseSettings)
-
-	maxThrusterSignal := 0
-	for _, combination := range allCombinations {
-
-		amplifiers[0].phase = combination[0]
-		amplifiers[1].phase = combination[1]
-		amplifiers[2].phase = combination[2]
-		amplifiers[3].phase = combination[3]
-		amplifiers[4].phase = combination[4]
 
-		ts, _ := signalFn(amplifiers, program)
-		if ts > maxThrusterSignal {
-			maxThrusterSignal = ts
-		}
+	space := search.Space{Distinct: true}
+	for _, id := range amplifierIDs {
+		space.Parameters = append(space.Parameters, search.Input(string(id), minPhase, maxPhase))
 	}
-	return maxThrusterSignal
-}
 
-func generateAllPhaseSettingsCombinations(phaseSettings []int) [][]int {
-	var phaseSettingsCombinations [][]int
-	permute(phaseSettings, &phaseSettingsCombinations, 0)
-	return phaseSettingsCombinations
-}
-
-func permute(phaseSettings []int, phaseSettingsCombinations *[][]int, i int) {
-	if i > len(phaseSettings) {
-		combination := make([]int, len(phaseSettings))
-		copy(combination, phaseSettings)
-		*phaseSettingsCombinations = append(*phaseSettingsCombinations, combination)
-		return
-	}
-
-	permute(phaseSettings, phaseSettingsCombinations, i+1)
+	best, err := search.Best(space, func(phases search.Assignment) (int, bool, error) {
+		amplifiers := make([]amplifier, len(amplifierIDs))
+		for i, id := range amplifierIDs {
+			amplifiers[i] = amplifier{id: id, phase: phases[string(id)]}
+		}
 
-	for j := i + 1; j < len(phaseSettings); j++ {
-		phaseSettings[i], phaseSettings[j] = phaseSettings[j], phaseSettings[i]
-		permute(phaseSettings, phaseSettingsCombinations, i+1)
-		phaseSettings[i], phaseSettings[j] = phaseSettings[j], phaseSettings[i]
+		// the phase settings for which the amplifiers fail do not produce any signal
+		thrusterSignal, err := signalFn(amplifiers, intcodeProgram)
+		return thrusterSignal, err == nil, nil
+	})
+	if err != nil {
+		return 0, err
 	}
+	return best.Score, nil
 }
 
-func thrusterSignalInSeries(amplifiers []amplifier, program string) (int, error) {
-	wireInSeries(amplifiers)
+// thrusterSignalInSeries runs the amplifiers one after the other, each one sending its output
+// to the next one, and returns the output of the last one
+func thrusterSignalInSeries(amplifiers []amplifier, program *intcode.Intcode) (int, error) {
+	s, machines := spawnAmplifiers(amplifiers, program)
+	for i := 0; i < len(machines)-1; i++ {
+		machines[i].OnOutput(machines[i+1].Send)
+	}
 
 	firstSignal := 0
-	amplifiers[0].input <- firstSignal
-
-	for _, amplifier := range amplifiers {
-		onInput := func() int {
-			return <-amplifier.input
-		}
+	machines[0].Send(firstSignal)
 
-		onOutput := func(output int) {
-			amplifier.output <- output
-		}
-
-		intcodeProgram, err := intcode.NewIntcodeProgram(program, onInput, onOutput)
-		if err != nil {
-			return 0, err
-		}
-
-		err = intcodeProgram.Run()
-		if err != nil {
-			return 0, err
-		}
+	err := s.Run()
+	if err != nil {
+		return 0, err
+	}
+	if !s.Halted() {
+		return 0, errors.New("amplifiers are waiting for an input signal")
 	}
 
-	outputSignal := <-amplifiers[len(amplifiers)-1].output
+	outputSignal, ok := machines[len(machines)-1].Receive()
+	if !ok {
+		return 0, errors.New("last amplifier did not produce an output signal")
+	}
 	return outputSignal, nil
 }
 
-func wireInSeries(amplifiers []amplifier) {
-	for i := range amplifiers {
-		amplifiers[i].input = make(chan int, 2)
-		amplifiers[i].input <- amplifiers[i].phase
+// thrusterSignalWithFeedbackLoop runs the amplifiers until they halt, each one sending its
+// outputs to the next one and the last one to the first one, and returns the last output
+// of the last one
+func thrusterSignalWithFeedbackLoop(amplifiers []amplifier, program *intcode.Intcode) (int, error) {
+	s, machines := spawnAmplifiers(amplifiers, program)
+	for i := 0; i < len(machines)-1; i++ {
+		machines[i].OnOutput(machines[i+1].Send)
 	}
 
-	for i := range amplifiers {
-		if i < len(amplifiers)-1 {
-			amplifiers[i].output = amplifiers[i+1].input
-		} else {
-			amplifiers[i].output = make(chan int, 1)
-		}
-	}
-}
-
-func thrusterSignalWithFeedbackLoop(amplifiers []amplifier, program string) (int, error) {
-	wireWithFeedbackLoop(amplifiers)
+	outputSignal, ok := 0, false
+	machines[len(machines)-1].OnOutput(func(output int) {
+		outputSignal, ok = output, true
+		machines[0].Send(output)
+	})
 
 	firstSignal := 0
-	amplifiers[0].input <- firstSignal
-
-	var group errgroup.Group
-	for _, amplifier := range amplifiers {
-		// create local variable for closures
-		amplifier := amplifier
-
-		onInput := func() int {
-			return <-amplifier.input
-		}
-
-		onOutput := func(output int) {
-			amplifier.output <- output
-		}
+	machines[0].Send(firstSignal)
 
-		intcodeProgram, err := intcode.NewIntcodeProgram(program, onInput, onOutput)
-		if err != nil {
-			return 0, err
-		}
-
-		group.Go(func() error {
-			return intcodeProgram.Run()
-		})
-	}
-	err := group.Wait()
+	err := s.Run()
 	if err != nil {
 		return 0, err
 	}
-
-	outputSignal := <-amplifiers[len(amplifiers)-1].output
+	if !s.Halted() {
+		return 0, errors.New("amplifiers are waiting for an input signal")
+	}
+	if !ok {
+		return 0, errors.New("last amplifier did not produce an output signal")
+	}
 	return outputSignal, nil
 }
 
-func wireWithFeedbackLoop(amplifiers []amplifier) {
-	for i := range amplifiers {
-		amplifiers[i].input = make(chan int, 2)
-		amplifiers[i].input <- amplifiers[i].phase
-	}
-
-	for i := range amplifiers {
-		if i < len(amplifiers)-1 {
-			amplifiers[i].output = amplifiers[i+1].input
-		} else {
-			amplifiers[i].output = amplifiers[0].input
-		}
+// spawnAmplifiers returns a scheduler which runs a fork of program for each amplifier,
+// whose first input is its phase
+func spawnAmplifiers(amplifiers []amplifier, program *intcode.Intcode) (*scheduler.Scheduler, []*scheduler.Machine) {
+	s := scheduler.New()
+	machines := make([]*scheduler.Machine, len(amplifiers))
+	for i, amplifier := range amplifiers {
+		machines[i] = s.Spawn(string(amplifier.id), program, amplifier.phase)
 	}
+	return s, machines
 }
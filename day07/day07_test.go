@@ -37,6 +37,11 @@ func TestSolvePartOne(t *testing.T) {
 				"1002,33,7,33,1,33,31,31,1,32,31,31,4,31,99,0,0,0",
 			expected: "65210",
 		},
+		"failing phase settings": {
+			// outputs phase+signal, failing with an invalid instruction if both are 0
+			input:    "3,16,3,17,1,16,17,18,1005,18,13,0,0,4,18,99,0,0,0",
+			expected: "10",
+		},
 	}
 	for name, testCase := range testCases {
 		t.Run(name, func(t *testing.T) {
@@ -78,19 +83,3 @@ func TestSolvePartTwo(t *testing.T) {
 		})
 	}
 }
-
-func TestGenerateAllPhaseSettingsCombinations(t *testing.T) {
-	phaseSettings := []int{0, 1, 2}
-	phaseSettingsCombinations := generateAllPhaseSettingsCombinations(phaseSettings)
-
-	assert.Len(t, phaseSettingsCombinations, 6)
-
-	assert.Contains(t, phaseSettingsCombinations, []int{0, 1, 2})
-	assert.Contains(t, phaseSettingsCombinations, []int{0, 2, 1})
-
-	assert.Contains(t, phaseSettingsCombinations, []int{1, 2, 0})
-	assert.Contains(t, phaseSettingsCombinations, []int{1, 0, 2})
-
-	assert.Contains(t, phaseSettingsCombinations, []int{2, 0, 1})
-	assert.Contains(t, phaseSettingsCombinations, []int{2, 1, 0})
-}
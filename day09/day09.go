@@ -36,7 +36,7 @@ func runWithInput(program string, input int) (string, error) {
 	}
 
 	intcodeProgram, err := intcode.NewIntcodeProgram(
-		program, func() int { return input }, onOutput,
+		program, func() int { return input }, onOutput, intcode.WithOverflowDetection(),
 	)
 	if err != nil {
 		return "", err
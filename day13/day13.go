@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/patch"
 )
 
 // Day holds the data needed to solve part one and part two
@@ -34,6 +35,9 @@ const (
 	readTile action = "read_tile"
 )
 
+// freePlay sets the number of quarters, stored at address 0, to 2 so that the game can be played for free
+var freePlay = patch.Values("free-play", 0, 2)
+
 type arcadeCabinet struct {
 	screen      map[pixel]tile
 	nextAction  action
@@ -76,13 +80,17 @@ func (d Day) SolvePartTwo() (string, error) {
 		nextAction: readX,
 	}
 
-	intcodeProgram, err := intcode.NewIntcodeProgram(
-		"2"+d.program[1:], arcadeCabinet.onInputWithQuarters(), arcadeCabinet.onOutputWithQuarters(),
-	)
+	image, err := intcode.NewImage(d.program)
+	if err != nil {
+		return "", err
+	}
+
+	image, err = patch.ApplyImage(image, freePlay)
 	if err != nil {
 		return "", err
 	}
 
+	intcodeProgram := image.New(arcadeCabinet.onInputWithQuarters(), arcadeCabinet.onOutputWithQuarters())
 	err = intcodeProgram.Run()
 	if err != nil {
 		return "", err
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/scheduler"
 	"github.com/OctaviPascual/AdventOfCode2019/util"
 )
 
@@ -44,11 +45,11 @@ type position struct {
 }
 
 type repairDroid struct {
-	position      position
-	space         map[position]cell
-	inputChannel  chan<- int
-	outputChannel <-chan int
-	foundOxygen   bool
+	position    position
+	space       map[position]cell
+	scheduler   *scheduler.Scheduler
+	program     *scheduler.Machine
+	foundOxygen bool
 }
 
 // NewDay returns a new Day that solves part one and two for the given input
@@ -91,38 +92,27 @@ func newRepairDroid(program string) (*repairDroid, error) {
 	space := make(map[position]cell)
 	space[initialPosition] = empty
 
-	inputChannel := make(chan int)
-	outputChannel := make(chan int)
-
-	rd := &repairDroid{
-		position:      initialPosition,
-		space:         space,
-		inputChannel:  inputChannel,
-		outputChannel: outputChannel,
-	}
-
-	onInput := func() int {
-		return <-inputChannel
+	intcodeProgram, err := intcode.NewIntcodeProgram(program, intcode.MustNotInput, intcode.MustNotOutput)
+	if err != nil {
+		return nil, err
 	}
 
-	onOutput := func(output int) {
-		outputChannel <- output
+	s := scheduler.New()
+	rd := &repairDroid{
+		position:  initialPosition,
+		space:     space,
+		scheduler: s,
+		program:   s.Spawn("droid", intcodeProgram),
 	}
 
-	intcodeProgram, err := intcode.NewIntcodeProgram(program, onInput, onOutput)
+	// The program provided doesn't finish as it's always waiting for a new input instruction,
+	// so the droid explores the space one command at a time.
+	err = rd.exploreAllSpace()
 	if err != nil {
 		return nil, err
 	}
 
-	// The program provided doesn't finish as it's always waiting for a new input instruction.
-	// We will only return an error if it returns an error while exploring all the space.
-	go func() {
-		err = intcodeProgram.Run()
-	}()
-	rd.exploreAllSpace()
-	intcodeProgram.Stop()
-
-	return rd, err
+	return rd, nil
 }
 
 func (rd *repairDroid) fewestNumberOfCommandsToOxygen(source position) (int, error) {
@@ -156,16 +146,19 @@ func (rd *repairDroid) minutesToFillWithOxygen() (int, error) {
 	return maxMinutesToFill, nil
 }
 
-func (rd *repairDroid) exploreAllSpace() {
+func (rd *repairDroid) exploreAllSpace() error {
 	for {
 		isTarget := func(position position) bool { return rd.space[position] == unknown }
 		commands, err := commandsToTarget(rd.position, isTarget, rd.space)
 		if err != nil {
-			return
+			return nil
 		}
 
 		for _, command := range commands {
-			rd.move(command)
+			err = rd.move(command)
+			if err != nil {
+				return err
+			}
 		}
 	}
 }
@@ -179,10 +172,20 @@ func oxygenPosition(space map[position]cell) position {
 	panic("could not find oxygen in space")
 }
 
-func (rd *repairDroid) move(command command) {
-	rd.inputChannel <- command.toInt()
+// move sends command to the program and runs it until it replies with the status of the droid
+func (rd *repairDroid) move(command command) error {
+	rd.program.Send(command.toInt())
+	err := rd.scheduler.Run()
+	if err != nil {
+		return err
+	}
 
-	status := status(<-rd.outputChannel)
+	output, ok := rd.program.Receive()
+	if !ok {
+		return fmt.Errorf("program did not reply to command %d", command)
+	}
+
+	status := status(output)
 	switch status {
 	case foundWall:
 		p := rd.position.nextPosition(command)
@@ -194,7 +197,10 @@ func (rd *repairDroid) move(command command) {
 		rd.position = rd.position.nextPosition(command)
 		rd.foundOxygen = true
 		rd.space[rd.position] = oxygen
+	default:
+		return fmt.Errorf("unknown status %d", status)
 	}
+	return nil
 }
 
 // nextPosition returns the position that results from applying command to p
@@ -0,0 +1,407 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/asm"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// Instruction is an instruction decoded from the memory of a program
+type Instruction struct {
+	instruction.Decoded
+	// Address is the position of the instruction
+	Address int
+	// Parameters are the values of the parameters as stored in memory
+	Parameters []int
+}
+
+// Next returns the position of the instruction that follows this one in memory
+func (i Instruction) Next() int {
+	return i.Address + i.Size()
+}
+
+func (i Instruction) String() string {
+	return fmt.Sprintf("%d: %s", i.Address, asm.Format(i.Decoded, i.Parameters))
+}
+
+// EdgeKind describes how control flows through an edge
+type EdgeKind int
+
+const (
+	// Fallthrough edges go to the next instruction in memory
+	Fallthrough EdgeKind = iota
+	// Jump edges go to the target of an unconditional jump
+	Jump
+	// Taken edges go to the target of a conditional jump when its condition holds
+	Taken
+	// NotTaken edges go to the next instruction of a conditional jump when its condition fails
+	NotTaken
+	// Call edges go to the entry of a called function
+	Call
+	// CallReturn edges go from a call site to the return address of the call
+	CallReturn
+)
+
+func (k EdgeKind) String() string {
+	switch k {
+	case Fallthrough:
+		return "fallthrough"
+	case Jump:
+		return "jump"
+	case Taken:
+		return "taken"
+	case NotTaken:
+		return "not taken"
+	case Call:
+		return "call"
+	case CallReturn:
+		return "return"
+	default:
+		return fmt.Sprintf("edge(%d)", int(k))
+	}
+}
+
+// Edge is an edge of the control flow graph
+type Edge struct {
+	// To is the start of the block control flows to
+	To   int
+	Kind EdgeKind
+}
+
+// Block is a basic block: a sequence of instructions that are always executed in order
+type Block struct {
+	// Start is the position of the first instruction of the block
+	Start int
+	// Instructions are the instructions of the block
+	Instructions []Instruction
+	// Successors are the blocks control can flow to after this block
+	Successors []Edge
+
+	// Halts indicates that the block ends with a halt instruction
+	Halts bool
+	// Returns indicates that the block ends returning from a function
+	Returns bool
+	// Indirect indicates that the block ends with a jump whose target is not known statically,
+	// or with a custom instruction which may jump
+	Indirect bool
+	// Invalid indicates that control flows after the block to a value which is not an instruction
+	Invalid bool
+}
+
+// Last returns the last instruction of the block, which must not be empty
+func (b *Block) Last() Instruction {
+	return b.Instructions[len(b.Instructions)-1]
+}
+
+// Function is a set of blocks reachable from an entry without following calls
+type Function struct {
+	// Entry is the start of the first block of the function
+	Entry int
+	// Blocks are the starts of the blocks of the function, in increasing order
+	Blocks []int
+}
+
+// Graph is the control flow graph of a program
+type Graph struct {
+	// Entry is the start of the block where the program starts
+	Entry int
+	// Blocks are the basic blocks of the program by their start
+	Blocks map[int]*Block
+	// Functions are the functions of the program by their entry, the program entry being one of them
+	Functions map[int]*Function
+}
+
+// SortedBlocks returns the blocks of the graph ordered by their start
+func (g *Graph) SortedBlocks() []*Block {
+	blocks := make([]*Block, 0, len(g.Blocks))
+	for _, block := range g.Blocks {
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Start < blocks[j].Start })
+	return blocks
+}
+
+// SortedFunctions returns the functions of the graph ordered by their entry
+func (g *Graph) SortedFunctions() []*Function {
+	functions := make([]*Function, 0, len(g.Functions))
+	for _, function := range g.Functions {
+		functions = append(functions, function)
+	}
+	sort.Slice(functions, func(i, j int) bool { return functions[i].Entry < functions[j].Entry })
+	return functions
+}
+
+// flow describes where control goes after an instruction
+type flow struct {
+	// ends indicates that the instruction ends a basic block
+	ends bool
+	// edges are the known destinations of the instruction when it ends a block
+	edges    []Edge
+	halts    bool
+	returns  bool
+	indirect bool
+}
+
+// Build builds the control flow graph of a program by recursive descent from position 0.
+// Only the code reachable through statically known jumps is analysed: the targets of jumps
+// that are not in immediate mode are unknown, except for the return idiom of functions.
+func Build(memory []int) (*Graph, error) {
+	return BuildWith(instruction.Standard(), memory)
+}
+
+// BuildWith builds the control flow graph of a program as Build, decoding the instructions of set.
+// Custom instructions may jump anywhere, so they end their block with an unknown destination
+// besides the fallthrough to the next instruction.
+func BuildWith(set *instruction.Set, memory []int) (*Graph, error) {
+	if len(memory) == 0 {
+		return nil, fmt.Errorf("empty program")
+	}
+
+	d := &descent{
+		set:          set,
+		memory:       memory,
+		instructions: make(map[int]Instruction),
+		flows:        make(map[int]flow),
+		invalid:      make(map[int]bool),
+		leaders:      map[int]bool{0: true},
+	}
+	d.explore(0)
+
+	g := &Graph{
+		Entry:     0,
+		Blocks:    make(map[int]*Block),
+		Functions: make(map[int]*Function),
+	}
+	for leader := range d.leaders {
+		g.Blocks[leader] = d.block(leader)
+	}
+
+	entries := map[int]bool{g.Entry: true}
+	for _, block := range g.Blocks {
+		for _, edge := range block.Successors {
+			if edge.Kind == Call {
+				entries[edge.To] = true
+			}
+		}
+	}
+	for entry := range entries {
+		g.Functions[entry] = g.function(entry)
+	}
+
+	return g, nil
+}
+
+// descent holds the state of a recursive descent over the memory of a program
+type descent struct {
+	set          *instruction.Set
+	memory       []int
+	instructions map[int]Instruction
+	flows        map[int]flow
+	invalid      map[int]bool
+	leaders      map[int]bool
+}
+
+// explore decodes all the instructions reachable from address
+func (d *descent) explore(address int) {
+	worklist := []int{address}
+	for len(worklist) > 0 {
+		address := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+
+		// run holds the instructions decoded in straight line from the worklist item
+		var run []Instruction
+		for {
+			if _, ok := d.instructions[address]; ok || d.invalid[address] {
+				break
+			}
+
+			ins, ok := d.decode(address)
+			if !ok {
+				d.invalid[address] = true
+				break
+			}
+			d.instructions[address] = ins
+			run = append(run, ins)
+
+			f := flowOf(ins, run)
+			d.flows[address] = f
+			if !f.ends {
+				address = ins.Next()
+				continue
+			}
+
+			for _, edge := range f.edges {
+				d.leaders[edge.To] = true
+				worklist = append(worklist, edge.To)
+			}
+			break
+		}
+	}
+}
+
+// decode decodes the instruction at address, returning false if it is not a valid instruction
+func (d *descent) decode(address int) (Instruction, bool) {
+	if address < 0 || address >= len(d.memory) {
+		return Instruction{}, false
+	}
+
+	decoded, err := d.set.Decode(d.memory[address])
+	if err != nil || address+decoded.Size() > len(d.memory) {
+		return Instruction{}, false
+	}
+
+	return Instruction{
+		Decoded:    decoded,
+		Address:    address,
+		Parameters: d.memory[address+1 : address+decoded.Size()],
+	}, true
+}
+
+// block builds the basic block that starts at leader
+func (d *descent) block(leader int) *Block {
+	block := &Block{Start: leader}
+
+	address := leader
+	for {
+		ins, ok := d.instructions[address]
+		if !ok {
+			block.Invalid = true
+			return block
+		}
+		block.Instructions = append(block.Instructions, ins)
+
+		f := d.flows[address]
+		if f.ends {
+			block.Successors = f.edges
+			block.Halts = f.halts
+			block.Returns = f.returns
+			block.Indirect = f.indirect
+			return block
+		}
+
+		address = ins.Next()
+		if d.leaders[address] {
+			block.Successors = []Edge{{To: address, Kind: Fallthrough}}
+			return block
+		}
+	}
+}
+
+// flowOf returns where control goes after ins, run being the instructions
+// executed in straight line up to ins
+func flowOf(ins Instruction, run []Instruction) flow {
+	switch ins.Opcode {
+	case instruction.HaltOpcode:
+		return flow{ends: true, halts: true}
+	case instruction.JumpIfTrueOpcode, instruction.JumpIfFalseOpcode:
+		return jumpFlow(ins, run)
+	}
+	if _, ok := instruction.Lookup(ins.Opcode); !ok {
+		return flow{ends: true, indirect: true, edges: []Edge{{To: ins.Next(), Kind: Fallthrough}}}
+	}
+	return flow{}
+}
+
+func jumpFlow(ins Instruction, run []Instruction) flow {
+	conditionMode, condition := ins.Modes[0], ins.Parameters[0]
+	targetMode, target := ins.Modes[1], ins.Parameters[1]
+
+	conditional := conditionMode != instruction.Immediate
+	if !conditional {
+		jumps := condition != 0
+		if ins.Opcode == instruction.JumpIfFalseOpcode {
+			jumps = condition == 0
+		}
+		if !jumps {
+			// the jump is never taken, so it behaves like any other instruction
+			return flow{}
+		}
+	}
+
+	if targetMode != instruction.Immediate {
+		if !conditional && targetMode == instruction.Relative {
+			return flow{ends: true, returns: true}
+		}
+		f := flow{ends: true, indirect: true}
+		if conditional {
+			f.edges = []Edge{{To: ins.Next(), Kind: NotTaken}}
+		}
+		return f
+	}
+
+	if conditional {
+		return flow{ends: true, edges: []Edge{
+			{To: target, Kind: Taken},
+			{To: ins.Next(), Kind: NotTaken},
+		}}
+	}
+
+	if isCall(ins, run) {
+		return flow{ends: true, edges: []Edge{
+			{To: target, Kind: Call},
+			{To: ins.Next(), Kind: CallReturn},
+		}}
+	}
+
+	return flow{ends: true, edges: []Edge{{To: target, Kind: Jump}}}
+}
+
+// isCall returns true if the unconditional jump ins is preceded in run by an instruction that
+// stores the position after the jump in a relative cell, which is how functions are called
+func isCall(ins Instruction, run []Instruction) bool {
+	for _, previous := range run[:len(run)-1] {
+		value, ok := storedConstant(previous)
+		if ok && value == ins.Next() && previous.Modes[2] == instruction.Relative {
+			return true
+		}
+	}
+	return false
+}
+
+// storedConstant returns the value stored by ins if it is an arithmetic
+// instruction whose operands are both in immediate mode
+func storedConstant(ins Instruction) (int, bool) {
+	if ins.Opcode != instruction.AddOpcode && ins.Opcode != instruction.MultiplyOpcode {
+		return 0, false
+	}
+	if ins.Modes[0] != instruction.Immediate || ins.Modes[1] != instruction.Immediate {
+		return 0, false
+	}
+
+	a, b := ins.Parameters[0], ins.Parameters[1]
+	if ins.Opcode == instruction.AddOpcode {
+		return a + b, true
+	}
+	return a * b, true
+}
+
+// function returns the function that starts at entry
+func (g *Graph) function(entry int) *Function {
+	visited := map[int]bool{entry: true}
+	queue := []int{entry}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range g.Blocks[current].Successors {
+			if edge.Kind == Call || visited[edge.To] {
+				continue
+			}
+			visited[edge.To] = true
+			queue = append(queue, edge.To)
+		}
+	}
+
+	blocks := make([]int, 0, len(visited))
+	for start := range visited {
+		blocks = append(blocks, start)
+	}
+	sort.Ints(blocks)
+
+	return &Function{
+		Entry:  entry,
+		Blocks: blocks,
+	}
+}
@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+// callProgram calls a function that stores 5 in the caller frame and then outputs it
+const callProgram = "109,100,21101,9,0,0,1105,1,13,204,1,99,0," +
+	"109,2,21101,5,0,-1,109,-2,2106,0,0"
+
+func buildGraph(t *testing.T, programString string) *Graph {
+	memory, err := program.Parse(programString)
+	require.NoError(t, err)
+
+	graph, err := Build(memory)
+	require.NoError(t, err)
+	return graph
+}
+
+func blockStarts(graph *Graph) []int {
+	var starts []int
+	for _, block := range graph.SortedBlocks() {
+		starts = append(starts, block.Start)
+	}
+	return starts
+}
+
+func TestBuildLoop(t *testing.T) {
+	graph := buildGraph(t, "1101,0,0,100,1001,100,1,100,1007,100,3,101,1005,101,4,4,100,99")
+
+	assert.Equal(t, []int{0, 4, 15}, blockStarts(graph))
+	assert.Equal(t, []Edge{{To: 4, Kind: Fallthrough}}, graph.Blocks[0].Successors)
+	assert.Equal(t, []Edge{{To: 4, Kind: Taken}, {To: 15, Kind: NotTaken}}, graph.Blocks[4].Successors)
+	assert.Len(t, graph.Blocks[4].Instructions, 3)
+	assert.True(t, graph.Blocks[15].Halts)
+	assert.Len(t, graph.Functions, 1)
+}
+
+func TestBuildCall(t *testing.T) {
+	graph := buildGraph(t, callProgram)
+
+	assert.Equal(t, []int{0, 9, 13}, blockStarts(graph))
+	assert.Equal(t, []Edge{{To: 13, Kind: Call}, {To: 9, Kind: CallReturn}}, graph.Blocks[0].Successors)
+	assert.True(t, graph.Blocks[9].Halts)
+	assert.True(t, graph.Blocks[13].Returns)
+	assert.Equal(t, "21: jz 0, [rb+0]", graph.Blocks[13].Last().String())
+
+	require.Len(t, graph.Functions, 2)
+	assert.Equal(t, []int{0, 9}, graph.Functions[0].Blocks)
+	assert.Equal(t, []int{13}, graph.Functions[13].Blocks)
+}
+
+func TestBuildIndirectJump(t *testing.T) {
+	graph := buildGraph(t, "5,6,7,99,99,99,1,3")
+
+	assert.Equal(t, []int{0, 3}, blockStarts(graph))
+	assert.True(t, graph.Blocks[0].Indirect)
+	assert.Equal(t, []Edge{{To: 3, Kind: NotTaken}}, graph.Blocks[0].Successors)
+}
+
+func TestBuildUnconditionalJumps(t *testing.T) {
+	// jnz 0 is never taken, jz 0 always is
+	graph := buildGraph(t, "1105,0,99,1106,0,7,42,99")
+
+	assert.Equal(t, []int{0, 7}, blockStarts(graph))
+	assert.Len(t, graph.Blocks[0].Instructions, 2)
+	assert.Equal(t, []Edge{{To: 7, Kind: Jump}}, graph.Blocks[0].Successors)
+}
+
+func TestBuildInvalidInstruction(t *testing.T) {
+	graph := buildGraph(t, "1105,1,5,99,1101,42")
+
+	assert.Equal(t, []int{0, 5}, blockStarts(graph))
+	assert.True(t, graph.Blocks[5].Invalid)
+	assert.Empty(t, graph.Blocks[5].Instructions)
+
+	graph = buildGraph(t, "1101,1,2,3")
+	assert.True(t, graph.Blocks[0].Invalid)
+	assert.Len(t, graph.Blocks[0].Instructions, 1)
+}
+
+func TestBuildSplitsBlocks(t *testing.T) {
+	// the jump at 8 targets the middle of the first run of instructions
+	graph := buildGraph(t, "1101,1,1,20,1101,2,2,21,1105,1,4")
+
+	assert.Equal(t, []int{0, 4}, blockStarts(graph))
+	assert.Equal(t, []Edge{{To: 4, Kind: Fallthrough}}, graph.Blocks[0].Successors)
+	assert.Equal(t, []Edge{{To: 4, Kind: Jump}}, graph.Blocks[4].Successors)
+}
+
+func TestBuildWith(t *testing.T) {
+	set := instruction.NewSet()
+	err := set.Register(
+		instruction.Definition{Opcode: 50, Mnemonic: "print", Parameters: []instruction.ParameterKind{instruction.Read}},
+		func(operands *instruction.Operands) error { return nil },
+	)
+	require.NoError(t, err)
+
+	memory, err := program.Parse("1101,2,3,7,50,7,99,0")
+	require.NoError(t, err)
+
+	graph, err := Build(memory)
+	require.NoError(t, err)
+	assert.True(t, graph.Blocks[0].Invalid)
+
+	graph, err = BuildWith(set, memory)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 6}, blockStarts(graph))
+	assert.Equal(t, "4: print [7]", graph.Blocks[0].Last().String())
+	assert.True(t, graph.Blocks[0].Indirect)
+	assert.Equal(t, []Edge{{To: 6, Kind: Fallthrough}}, graph.Blocks[0].Successors)
+	assert.True(t, graph.Blocks[6].Halts)
+}
+
+func TestBuildEmptyProgram(t *testing.T) {
+	_, err := Build(nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// edgeAttributes are the Graphviz attributes used to draw each kind of edge
+var edgeAttributes = map[EdgeKind]string{
+	Fallthrough: `color="black"`,
+	Jump:        `color="black", style="bold"`,
+	Taken:       `color="darkgreen", label="taken"`,
+	NotTaken:    `color="red", label="not taken"`,
+	Call:        `color="blue", style="dashed", label="call"`,
+	CallReturn:  `color="blue", style="dotted", label="return"`,
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("digraph intcode {\n")
+	sb.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+
+	for _, block := range g.SortedBlocks() {
+		fmt.Fprintf(&sb, "  %s [label=\"%s\"", nodeName(block.Start), blockLabel(g, block))
+		if _, ok := g.Functions[block.Start]; ok {
+			sb.WriteString(", peripheries=2")
+		}
+		sb.WriteString("];\n")
+	}
+
+	for _, block := range g.SortedBlocks() {
+		for _, edge := range block.Successors {
+			fmt.Fprintf(
+				&sb, "  %s -> %s [%s];\n",
+				nodeName(block.Start), nodeName(edge.To), edgeAttributes[edge.Kind],
+			)
+		}
+	}
+
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func nodeName(start int) string {
+	return fmt.Sprintf("block%d", start)
+}
+
+// blockLabel returns the label of the node of block, with one left aligned line per instruction
+func blockLabel(g *Graph, block *Block) string {
+	var lines []string
+	if _, ok := g.Functions[block.Start]; ok {
+		lines = append(lines, fmt.Sprintf("function %d", block.Start))
+	}
+	for _, ins := range block.Instructions {
+		lines = append(lines, ins.String())
+	}
+
+	switch {
+	case block.Halts:
+		lines = append(lines, "(halt)")
+	case block.Returns:
+		lines = append(lines, "(return)")
+	case block.Indirect:
+		lines = append(lines, "(indirect jump)")
+	case block.Invalid:
+		lines = append(lines, "(invalid instruction)")
+	}
+
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(escape(line))
+		sb.WriteString(`\l`)
+	}
+	return sb.String()
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
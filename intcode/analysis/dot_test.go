@@ -0,0 +1,31 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDOT(t *testing.T) {
+	graph := buildGraph(t, callProgram)
+
+	var sb strings.Builder
+	require.NoError(t, graph.WriteDOT(&sb))
+
+	expected := `digraph intcode {
+  node [shape=box, fontname="monospace"];
+  block0 [label="function 0\l0: arb 100\l2: add 9, 0, [rb+0]\l6: jnz 1, 13\l", peripheries=2];
+  block9 [label="9: out [rb+1]\l11: halt\l(halt)\l"];
+  block13 [label="function 13\l13: arb 2\l15: add 5, 0, [rb-1]\l19: arb -2\l21: jz 0, [rb+0]\l(return)\l", peripheries=2];
+  block0 -> block13 [color="blue", style="dashed", label="call"];
+  block0 -> block9 [color="blue", style="dotted", label="return"];
+}
+`
+	assert.Equal(t, expected, sb.String())
+}
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, `a \"b\" \\c`, escape(`a "b" \c`))
+}
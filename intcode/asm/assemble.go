@@ -0,0 +1,129 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// Assemble assembles source, written in the syntax of the disassembler, into the values of memory.
+// Each line holds either an instruction such as "add [rb+1], 5, [9]" or data values such as
+// "data 1, 2", optionally preceded by their address as in listings, which must then match
+// the position where they are assembled. Text following a # is a comment.
+func Assemble(source string) ([]int, error) {
+	return AssembleWith(instruction.Standard(), source)
+}
+
+// AssembleWith assembles source as Assemble, encoding the instructions of set
+func AssembleWith(set *instruction.Set, source string) ([]int, error) {
+	var memory []int
+	for i, line := range strings.Split(source, "\n") {
+		values, err := assembleLine(set, line, len(memory))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		memory = append(memory, values...)
+	}
+	return memory, nil
+}
+
+// assembleLine assembles a line of source to be stored at address
+func assembleLine(set *instruction.Set, line string, address int) ([]int, error) {
+	if comment := strings.Index(line, "#"); comment >= 0 {
+		line = line[:comment]
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, nil
+	}
+
+	if prefix, rest, ok := strings.Cut(line, ":"); ok {
+		expected, err := strconv.Atoi(strings.TrimSpace(prefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %s", prefix)
+		}
+		if expected != address {
+			return nil, fmt.Errorf("address %d does not match position %d", expected, address)
+		}
+		line = strings.TrimSpace(rest)
+	}
+
+	mnemonic, rest, _ := strings.Cut(line, " ")
+	var operands []string
+	if rest = strings.TrimSpace(rest); rest != "" {
+		operands = strings.Split(rest, ",")
+	}
+
+	if mnemonic == "data" {
+		return assembleData(operands)
+	}
+
+	definition, ok := set.LookupMnemonic(mnemonic)
+	if !ok {
+		return nil, fmt.Errorf("unknown mnemonic %s", mnemonic)
+	}
+	if len(operands) != len(definition.Parameters) {
+		return nil, fmt.Errorf("%s expects %d operands, got %d", mnemonic, len(definition.Parameters), len(operands))
+	}
+
+	values := []int{definition.Opcode}
+	scale := 100
+	for _, operand := range operands {
+		mode, value, err := ParseOperand(strings.TrimSpace(operand))
+		if err != nil {
+			return nil, err
+		}
+		values[0] += int(mode) * scale
+		values = append(values, value)
+		scale *= 10
+	}
+	return values, nil
+}
+
+func assembleData(operands []string) ([]int, error) {
+	if len(operands) == 0 {
+		return nil, fmt.Errorf("data expects at least one value")
+	}
+
+	values := make([]int, len(operands))
+	for i, operand := range operands {
+		value, err := strconv.Atoi(strings.TrimSpace(operand))
+		if err != nil {
+			return nil, fmt.Errorf("invalid data value %s", operand)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// ParseOperand parses an operand formatted by FormatOperand into its mode and value
+func ParseOperand(operand string) (instruction.Mode, int, error) {
+	if !strings.HasPrefix(operand, "[") || !strings.HasSuffix(operand, "]") {
+		value, err := strconv.Atoi(operand)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid operand %s", operand)
+		}
+		return instruction.Immediate, value, nil
+	}
+
+	inner := strings.TrimSpace(operand[1 : len(operand)-1])
+	if strings.HasPrefix(inner, "rb") {
+		offset := strings.ReplaceAll(strings.TrimPrefix(inner, "rb"), " ", "")
+		if offset == "" {
+			return instruction.Relative, 0, nil
+		}
+		value, err := strconv.Atoi(offset)
+		if err != nil || (offset[0] != '+' && offset[0] != '-') {
+			return 0, 0, fmt.Errorf("invalid relative operand %s", operand)
+		}
+		return instruction.Relative, value, nil
+	}
+
+	value, err := strconv.Atoi(inner)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid position operand %s", operand)
+	}
+	return instruction.Position, value, nil
+}
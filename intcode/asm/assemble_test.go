@@ -0,0 +1,127 @@
+package asm
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+func TestAssemble(t *testing.T) {
+	source := `
+# doubles its input
+in [9]
+mul [9], 2, [rb+1]   # stored relative to the relative base
+out [rb + 1]
+jz 0, [rb]
+data 0, -3
+`
+
+	memory, err := Assemble(source)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 9, 21002, 9, 2, 1, 204, 1, 2106, 0, 0, 0, -3}, memory)
+}
+
+func TestAssembleListing(t *testing.T) {
+	for _, filename := range []string{"../../day05/day05.txt", "../../day09/day09.txt", "../../day13/day13.txt"} {
+		t.Run(filename, func(t *testing.T) {
+			bytes, err := os.ReadFile(filename)
+			require.NoError(t, err)
+
+			memory, err := program.Parse(strings.TrimSuffix(string(bytes), "\n"))
+			require.NoError(t, err)
+
+			assembled, err := Assemble(Listing(memory))
+			require.NoError(t, err)
+			assert.Equal(t, memory, assembled)
+		})
+	}
+}
+
+// FuzzAssembleListing checks that the listing of any program assembles back to the program
+func FuzzAssembleListing(f *testing.F) {
+	for _, filename := range []string{"../../day05/day05.txt", "../../day09/day09.txt", "../../day13/day13.txt"} {
+		bytes, err := os.ReadFile(filename)
+		require.NoError(f, err)
+		f.Add(strings.TrimSuffix(string(bytes), "\n"))
+	}
+	f.Add("3,9,21002,9,2,1,204,1,2106,0,0,0,-3")
+
+	f.Fuzz(func(t *testing.T, programString string) {
+		memory, err := program.Parse(programString)
+		if err != nil {
+			return
+		}
+
+		assembled, err := Assemble(Listing(memory))
+		require.NoError(t, err)
+		assert.Equal(t, memory, assembled)
+	})
+}
+
+func TestAssembleInvalid(t *testing.T) {
+	testCases := map[string]struct {
+		source string
+		err    string
+	}{
+		"unknown mnemonic": {
+			source: "nop",
+			err:    "line 1: unknown mnemonic nop",
+		},
+		"wrong number of operands": {
+			source: "halt\nadd 1, 2",
+			err:    "line 2: add expects 3 operands, got 2",
+		},
+		"invalid operand": {
+			source: "out x",
+			err:    "line 1: invalid operand x",
+		},
+		"invalid relative operand": {
+			source: "out [rb*2]",
+			err:    "line 1: invalid relative operand [rb*2]",
+		},
+		"invalid position operand": {
+			source: "out [x]",
+			err:    "line 1: invalid position operand [x]",
+		},
+		"empty data": {
+			source: "data",
+			err:    "line 1: data expects at least one value",
+		},
+		"wrong address": {
+			source: "0: halt\n2: halt",
+			err:    "line 2: address 2 does not match position 1",
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Assemble(test.source)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestCustomInstructionSet(t *testing.T) {
+	set := instruction.NewSet()
+	err := set.Register(
+		instruction.Definition{Opcode: 50, Mnemonic: "print", Parameters: []instruction.ParameterKind{instruction.Read}},
+		func(*instruction.Operands) error { return nil },
+	)
+	require.NoError(t, err)
+
+	memory, err := AssembleWith(set, "print [rb-2]\nhalt")
+	require.NoError(t, err)
+	assert.Equal(t, []int{250, -2, 99}, memory)
+
+	assert.Equal(t, "    0: print [rb-2]\n    2: halt\n", ListingWith(set, memory))
+	assert.Equal(t, "data 250", Disassemble(memory)[0].Text)
+
+	_, err = Assemble("print [rb-2]")
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package asm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// Line is a line of a disassembly listing
+type Line struct {
+	// Address is the position of the first memory cell represented by the line
+	Address int
+	// Cells are the memory values represented by the line
+	Cells []int
+	// Text is the assembly representation of the cells
+	Text string
+}
+
+// String returns the line formatted as in a listing
+func (l Line) String() string {
+	return fmt.Sprintf("%5d: %s", l.Address, l.Text)
+}
+
+// Disassemble disassembles memory with a linear sweep from position 0. Values that cannot
+// be decoded as an instruction, or whose parameters don't fit in memory, are listed as data.
+func Disassemble(memory []int) []Line {
+	return DisassembleWith(instruction.Standard(), memory)
+}
+
+// DisassembleWith disassembles memory as Disassemble, decoding the instructions of set
+func DisassembleWith(set *instruction.Set, memory []int) []Line {
+	var lines []Line
+	for address := 0; address < len(memory); {
+		line := disassembleAt(set, memory, address)
+		lines = append(lines, line)
+		address += len(line.Cells)
+	}
+	return lines
+}
+
+// Listing returns the disassembly listing of memory, one line per instruction
+func Listing(memory []int) string {
+	return ListingWith(instruction.Standard(), memory)
+}
+
+// ListingWith returns the disassembly listing of memory, decoding the instructions of set
+func ListingWith(set *instruction.Set, memory []int) string {
+	var sb strings.Builder
+	for _, line := range DisassembleWith(set, memory) {
+		sb.WriteString(line.String())
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func disassembleAt(set *instruction.Set, memory []int, address int) Line {
+	data := Line{
+		Address: address,
+		Cells:   memory[address : address+1],
+		Text:    fmt.Sprintf("data %d", memory[address]),
+	}
+
+	decoded, err := set.Decode(memory[address])
+	if err != nil || address+decoded.Size() > len(memory) {
+		return data
+	}
+
+	cells := memory[address : address+decoded.Size()]
+	return Line{
+		Address: address,
+		Cells:   cells,
+		Text:    Format(decoded, cells[1:]),
+	}
+}
+
+// Format returns the assembly representation of a decoded instruction with the given parameters
+func Format(decoded instruction.Decoded, parameters []int) string {
+	operands := make([]string, len(decoded.Modes))
+	for i, mode := range decoded.Modes {
+		operands[i] = FormatOperand(mode, parameters[i])
+	}
+
+	text := decoded.Mnemonic
+	if len(operands) > 0 {
+		text += " " + strings.Join(operands, ", ")
+	}
+	return text
+}
+
+// FormatOperand returns the assembly representation of a parameter with the given mode
+func FormatOperand(mode instruction.Mode, value int) string {
+	switch mode {
+	case instruction.Position:
+		return fmt.Sprintf("[%d]", value)
+	case instruction.Relative:
+		return fmt.Sprintf("[rb%+d]", value)
+	default:
+		return fmt.Sprintf("%d", value)
+	}
+}
@@ -0,0 +1,33 @@
+package asm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisassemble(t *testing.T) {
+	memory := []int{1101, 2, 3, 9, 204, -1, 1105, 1, 0, 99, 42, 1}
+
+	expected := []Line{
+		{Address: 0, Cells: []int{1101, 2, 3, 9}, Text: "add 2, 3, [9]"},
+		{Address: 4, Cells: []int{204, -1}, Text: "out [rb-1]"},
+		{Address: 6, Cells: []int{1105, 1, 0}, Text: "jnz 1, 0"},
+		{Address: 9, Cells: []int{99}, Text: "halt"},
+		{Address: 10, Cells: []int{42}, Text: "data 42"},
+		{Address: 11, Cells: []int{1}, Text: "data 1"},
+	}
+
+	assert.Equal(t, expected, Disassemble(memory))
+}
+
+func TestListing(t *testing.T) {
+	memory := []int{3, 0, 4, 0, 99}
+
+	expected := "" +
+		"    0: in [0]\n" +
+		"    2: out [0]\n" +
+		"    4: halt\n"
+
+	assert.Equal(t, expected, Listing(memory))
+}
@@ -0,0 +1,85 @@
+package intcode
+
+import (
+	"context"
+	"fmt"
+)
+
+// Execution is a run of an Intcode program in the background, started with Start. It suits a
+// host that runs a program while doing other work, such as the metrics command serving its
+// metrics. Programs that exchange inputs and outputs with each other, such as the amplifiers of
+// day07 and the repair droid of day15, are run by the scheduler package on a single goroutine
+// instead, which keeps their runs deterministic.
+type Execution struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	// err is the error of the run, which is only read once done is closed
+	err error
+}
+
+// Start runs the Intcode program in a new goroutine until it halts, fails, is stopped or ctx
+// is done, and returns the handle of the run. A panic of onInput or onOutput makes the run fail
+// instead of crashing the process. While the run is active, the program must only be inspected
+// or stopped, which is safe from any goroutine.
+func (i *Intcode) Start(ctx context.Context) *Execution {
+	ctx, cancel := context.WithCancel(ctx)
+	e := &Execution{
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+
+	go func() {
+		defer close(e.done)
+		defer cancel()
+		defer func() {
+			if r := recover(); r != nil {
+				e.err = fmt.Errorf("intcode program panicked: %v", r)
+			}
+		}()
+		e.err = i.RunContext(ctx)
+	}()
+
+	return e
+}
+
+// Done returns a channel that is closed once the run has finished
+func (e *Execution) Done() <-chan struct{} {
+	return e.done
+}
+
+// Wait waits for the run to finish and returns its error, which is the context error if it
+// was cancelled before the program halted
+func (e *Execution) Wait() error {
+	<-e.done
+	return e.err
+}
+
+// Cancel cancels the run, which finishes once the instruction being executed completes. A
+// program waiting in onInput or onOutput is not interrupted, so callbacks that may block
+// should return once the context given to Start is done, and be cancelled through it.
+func (e *Execution) Cancel() {
+	e.cancel()
+}
+
+// RunContext runs the Intcode program like Run, but also returns the error of ctx if it is
+// done before the program halts, once the instruction being executed completes
+func (i *Intcode) RunContext(ctx context.Context) error {
+	done := ctx.Done()
+	for !i.Halted() {
+		select {
+		case <-done:
+			return ctx.Err()
+		default:
+		}
+
+		err := i.Step()
+		if err != nil {
+			return err
+		}
+
+		if i.shouldStop.Load() {
+			break
+		}
+	}
+	return nil
+}
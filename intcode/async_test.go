@@ -0,0 +1,97 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStart(t *testing.T) {
+	outputs := make(chan int, 1)
+	program, err := NewIntcodeProgram(doubleProgram, func() int { return 21 }, func(output int) { outputs <- output })
+	require.NoError(t, err)
+
+	execution := program.Start(context.Background())
+	<-execution.Done()
+	assert.NoError(t, execution.Wait())
+	assert.Equal(t, 42, <-outputs)
+	assert.True(t, program.Halted())
+}
+
+func TestStartFails(t *testing.T) {
+	program, err := NewIntcodeProgram("1,0,0,0,98", MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+
+	execution := program.Start(context.Background())
+	err = execution.Wait()
+	assert.EqualError(t, err, "error parsing instruction: unknown opcode 98")
+	assert.Equal(t, err, execution.Wait(), "the error must be returned by every wait")
+}
+
+func TestStartPanics(t *testing.T) {
+	program, err := NewIntcodeProgram(doubleProgram, MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+
+	err = program.Start(context.Background()).Wait()
+	assert.EqualError(t, err, "intcode program panicked: intcode program expects an input")
+}
+
+func TestStartCancel(t *testing.T) {
+	program, err := NewIntcodeProgram(counter, MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+
+	execution := program.Start(context.Background())
+	for program.Registers() == (Registers{}) {
+		time.Sleep(time.Millisecond)
+	}
+	execution.Cancel()
+
+	assert.True(t, errors.Is(execution.Wait(), context.Canceled))
+	assert.Contains(t, []int{0, 4}, program.InstructionPointer())
+}
+
+func TestStartContext(t *testing.T) {
+	program, err := NewIntcodeProgram(counter, MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = program.Start(ctx).Wait()
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestStartBlockedOnInput(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	onInput := func() int {
+		<-ctx.Done()
+		return 0
+	}
+	program, err := NewIntcodeProgram(doubleProgram, onInput, func(int) {})
+	require.NoError(t, err)
+
+	execution := program.Start(ctx)
+	select {
+	case <-execution.Done():
+		t.Fatal("the run must wait for an input")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	cancel()
+	assert.True(t, errors.Is(execution.Wait(), context.Canceled))
+	assert.Equal(t, 2, program.InstructionPointer())
+}
+
+func TestStartStopped(t *testing.T) {
+	program, err := NewIntcodeProgram(counter, MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+
+	execution := program.Start(context.Background())
+	program.Stop()
+	assert.NoError(t, execution.Wait())
+	assert.False(t, program.Halted())
+}
@@ -0,0 +1,81 @@
+package coverage
+
+import (
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// Branch counts the outcomes of a conditional jump
+type Branch struct {
+	// Taken is the number of times the jump was taken
+	Taken int
+	// NotTaken is the number of times execution continued with the next instruction
+	NotTaken int
+}
+
+// Outcomes returns the number of outcomes of the branch that have been seen, from 0 to 2
+func (b Branch) Outcomes() int {
+	outcomes := 0
+	if b.Taken > 0 {
+		outcomes++
+	}
+	if b.NotTaken > 0 {
+		outcomes++
+	}
+	return outcomes
+}
+
+// Coverage is an intcode.Observer that records the instructions executed by Intcode programs
+// and the outcomes of their branches. It can observe several runs of a program one after
+// the other, aggregating their coverage, but it must not observe programs running concurrently:
+// use a Coverage for each of them and Merge them instead.
+type Coverage struct {
+	intcode.NopObserver
+
+	// Instructions counts the executed instructions per address
+	Instructions map[int]int
+	// Branches counts the outcomes of the executed jumps per address. A jump is considered taken
+	// when it moves the instruction pointer anywhere but to the next instruction, so a jump to the
+	// next instruction is never taken.
+	Branches map[int]Branch
+}
+
+var _ intcode.Observer = &Coverage{}
+
+// New returns a new Coverage
+func New() *Coverage {
+	return &Coverage{
+		Instructions: make(map[int]int),
+		Branches:     make(map[int]Branch),
+	}
+}
+
+// OnInstruction records an executed instruction and the outcome of jumps
+func (c *Coverage) OnInstruction(event intcode.InstructionEvent) {
+	c.Instructions[event.Address]++
+
+	if event.Opcode != instruction.JumpIfTrueOpcode && event.Opcode != instruction.JumpIfFalseOpcode {
+		return
+	}
+
+	branch := c.Branches[event.Address]
+	if event.Next == event.Address+3 {
+		branch.NotTaken++
+	} else {
+		branch.Taken++
+	}
+	c.Branches[event.Address] = branch
+}
+
+// Merge adds the coverage recorded by other to c
+func (c *Coverage) Merge(other *Coverage) {
+	for address, count := range other.Instructions {
+		c.Instructions[address] += count
+	}
+	for address, outcomes := range other.Branches {
+		branch := c.Branches[address]
+		branch.Taken += outcomes.Taken
+		branch.NotTaken += outcomes.NotTaken
+		c.Branches[address] = branch
+	}
+}
@@ -0,0 +1,63 @@
+package coverage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+// countingProgram counts from 0 to 3 and outputs the result
+const countingProgram = "1101,0,0,100,1001,100,1,100,1007,100,3,101,1005,101,4,4,100,99"
+
+// branchingProgram outputs 0 if its input is 0 and 1 otherwise
+const branchingProgram = "3,100,1005,100,8,104,0,99,104,1,99"
+
+func runCovered(t *testing.T, program string, input int) *Coverage {
+	coverage := New()
+	intcodeProgram, err := intcode.NewIntcodeProgram(
+		program, func() int { return input }, func(int) {}, intcode.WithObserver(coverage),
+	)
+	require.NoError(t, err)
+	require.NoError(t, intcodeProgram.Run())
+	return coverage
+}
+
+func TestCoverage(t *testing.T) {
+	coverage := runCovered(t, countingProgram, 0)
+
+	assert.Equal(t, map[int]int{0: 1, 4: 3, 8: 3, 12: 3, 15: 1, 17: 1}, coverage.Instructions)
+	assert.Equal(t, map[int]Branch{12: {Taken: 2, NotTaken: 1}}, coverage.Branches)
+}
+
+func TestCoverageAggregatesRuns(t *testing.T) {
+	coverage := New()
+	for _, input := range []int{0, 0, 1} {
+		intcodeProgram, err := intcode.NewIntcodeProgram(
+			branchingProgram, func() int { return input }, func(int) {}, intcode.WithObserver(coverage),
+		)
+		require.NoError(t, err)
+		require.NoError(t, intcodeProgram.Run())
+	}
+
+	assert.Equal(t, map[int]int{0: 3, 2: 3, 5: 2, 7: 2, 8: 1, 10: 1}, coverage.Instructions)
+	assert.Equal(t, map[int]Branch{2: {Taken: 1, NotTaken: 2}}, coverage.Branches)
+}
+
+func TestMerge(t *testing.T) {
+	coverage := runCovered(t, branchingProgram, 0)
+	coverage.Merge(runCovered(t, branchingProgram, 1))
+	coverage.Merge(runCovered(t, branchingProgram, 1))
+
+	assert.Equal(t, map[int]int{0: 3, 2: 3, 5: 1, 7: 1, 8: 2, 10: 2}, coverage.Instructions)
+	assert.Equal(t, map[int]Branch{2: {Taken: 2, NotTaken: 1}}, coverage.Branches)
+}
+
+func TestBranchOutcomes(t *testing.T) {
+	assert.Equal(t, 0, Branch{}.Outcomes())
+	assert.Equal(t, 1, Branch{Taken: 3}.Outcomes())
+	assert.Equal(t, 1, Branch{NotTaken: 1}.Outcomes())
+	assert.Equal(t, 2, Branch{Taken: 1, NotTaken: 5}.Outcomes())
+}
@@ -0,0 +1,252 @@
+package coverage
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/analysis"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/asm"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// maxInstructionSize is the number of memory positions taken by the largest instruction
+const maxInstructionSize = 4
+
+// Status is the coverage status of a line of the disassembly listing
+type Status int
+
+const (
+	// Data lines are not known to be instructions
+	Data Status = iota
+	// Uncovered lines are instructions that were never executed
+	Uncovered
+	// Partial lines are branches that were executed with only one of their outcomes
+	Partial
+	// Covered lines are instructions that were executed, with both outcomes for branches
+	Covered
+)
+
+func (s Status) String() string {
+	switch s {
+	case Data:
+		return "data"
+	case Uncovered:
+		return "uncovered"
+	case Partial:
+		return "partial"
+	case Covered:
+		return "covered"
+	default:
+		return fmt.Sprintf("status(%d)", int(s))
+	}
+}
+
+// Line is a line of the disassembly listing annotated with its coverage
+type Line struct {
+	asm.Line
+	Status Status
+	// Count is the number of times the instruction was executed
+	Count int
+	// Branch holds the outcomes of the line if it is a conditional jump
+	Branch *Branch
+}
+
+// Summary summarises the coverage of a program
+type Summary struct {
+	// Instructions is the number of known instructions and CoveredInstructions the executed ones
+	Instructions        int
+	CoveredInstructions int
+	// Branches is the number of outcomes of the known conditional jumps and CoveredBranches
+	// the ones that have been seen
+	Branches        int
+	CoveredBranches int
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf(
+		"Instructions covered: %d/%d (%.2f%%)\nBranches covered: %d/%d (%.2f%%)\n",
+		s.CoveredInstructions, s.Instructions, percentage(s.CoveredInstructions, s.Instructions),
+		s.CoveredBranches, s.Branches, percentage(s.CoveredBranches, s.Branches),
+	)
+}
+
+// Summarize returns the coverage summary of the program whose initial memory is memory. The known
+// instructions are the ones reachable through the control flow graph of the program and the ones
+// that have been executed.
+func (c *Coverage) Summarize(memory []int) Summary {
+	var s Summary
+	for address := range c.known(memory) {
+		s.Instructions++
+		if c.Instructions[address] > 0 {
+			s.CoveredInstructions++
+		}
+		if c.conditional(memory, address) {
+			s.Branches += 2
+			s.CoveredBranches += c.Branches[address].Outcomes()
+		}
+	}
+	return s
+}
+
+// Annotate returns the disassembly listing of memory, the initial memory of the program,
+// annotated with its coverage. The listing is aligned with the known instructions.
+func (c *Coverage) Annotate(memory []int) []Line {
+	known := c.known(memory)
+
+	var lines []Line
+	for address := 0; address < len(memory); {
+		end := address + maxInstructionSize
+		if end > len(memory) {
+			end = len(memory)
+		}
+		line := asm.Disassemble(memory[address:end])[0]
+		line.Address = address
+
+		if _, err := instruction.Decode(line.Cells[0]); err != nil && known[address] {
+			// the instruction was not in the initial memory, the code must have been modified
+			line.Text += " (modified code)"
+		}
+		if !known[address] {
+			for i := 1; i < len(line.Cells); i++ {
+				// the line overlaps a known instruction, so its first cell is data
+				if known[address+i] {
+					line = asm.Line{Address: address, Cells: line.Cells[:1], Text: fmt.Sprintf("data %d", line.Cells[0])}
+					break
+				}
+			}
+		}
+
+		lines = append(lines, c.annotate(memory, line, known[address]))
+		address += len(line.Cells)
+	}
+	return lines
+}
+
+// annotate annotates line, which is a known instruction if instruction is true
+func (c *Coverage) annotate(memory []int, line asm.Line, instruction bool) Line {
+	annotated := Line{Line: line, Count: c.Instructions[line.Address]}
+	if !instruction {
+		return annotated
+	}
+
+	annotated.Status = Uncovered
+	if annotated.Count > 0 {
+		annotated.Status = Covered
+	}
+	if c.conditional(memory, line.Address) {
+		branch := c.Branches[line.Address]
+		annotated.Branch = &branch
+		if branch.Outcomes() == 1 {
+			annotated.Status = Partial
+		}
+	}
+	return annotated
+}
+
+// known returns the addresses of the known instructions of the program
+func (c *Coverage) known(memory []int) map[int]bool {
+	known := make(map[int]bool, len(c.Instructions))
+	for address := range c.Instructions {
+		known[address] = true
+	}
+
+	graph, err := analysis.Build(memory)
+	if err != nil {
+		return known
+	}
+	for _, block := range graph.Blocks {
+		for _, ins := range block.Instructions {
+			known[ins.Address] = true
+		}
+	}
+	return known
+}
+
+// conditional returns true if the instruction at address is a conditional jump. Jumps whose
+// condition is an immediate value are unconditional, unless the code has been modified and
+// outcomes were recorded for an instruction that is not a jump in memory.
+func (c *Coverage) conditional(memory []int, address int) bool {
+	if address >= 0 && address < len(memory) {
+		decoded, err := instruction.Decode(memory[address])
+		jump := err == nil &&
+			(decoded.Opcode == instruction.JumpIfTrueOpcode || decoded.Opcode == instruction.JumpIfFalseOpcode)
+		if jump {
+			return decoded.Modes[0] != instruction.Immediate
+		}
+	}
+	_, ok := c.Branches[address]
+	return ok
+}
+
+// Report writes the coverage summary followed by the annotated disassembly listing of memory.
+// Each line starts with the times it was executed, ##### marking the instructions that were
+// never executed, and conditional jumps are followed by their outcomes.
+func (c *Coverage) Report(w io.Writer, memory []int) error {
+	var sb strings.Builder
+	sb.WriteString(c.Summarize(memory).String())
+
+	sb.WriteString("\nAnnotated disassembly:\n")
+	for _, line := range c.Annotate(memory) {
+		switch line.Status {
+		case Data:
+			fmt.Fprintf(&sb, "%12s  %s", "", line.Line)
+		case Uncovered:
+			fmt.Fprintf(&sb, "%12s  %s", "#####", line.Line)
+		default:
+			fmt.Fprintf(&sb, "%12d  %s", line.Count, line.Line)
+		}
+		if line.Branch != nil {
+			fmt.Fprintf(&sb, "  # taken %d, not taken %d", line.Branch.Taken, line.Branch.NotTaken)
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// htmlReport is the template of the HTML coverage report
+var htmlReport = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Intcode coverage</title>
+<style>
+body { font-family: monospace; }
+td { padding: 0 1em; white-space: pre; }
+td.count { text-align: right; }
+tr.uncovered { background: #f8c8c8; }
+tr.partial { background: #f8ecb0; }
+tr.covered { background: #c8f0c8; }
+</style>
+</head>
+<body>
+<p>Instructions covered: {{.Summary.CoveredInstructions}}/{{.Summary.Instructions}}<br>
+Branches covered: {{.Summary.CoveredBranches}}/{{.Summary.Branches}}</p>
+<table>
+{{range .Lines}}<tr class="{{.Status}}"><td class="count">{{if .Status}}{{.Count}}{{end}}</td><td>{{.Address}}</td><td>{{.Text}}</td><td>{{with .Branch}}taken {{.Taken}}, not taken {{.NotTaken}}{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes the coverage summary and the annotated disassembly listing of memory as an
+// HTML page, where the lines are coloured by their status
+func (c *Coverage) WriteHTML(w io.Writer, memory []int) error {
+	return htmlReport.Execute(w, struct {
+		Summary Summary
+		Lines   []Line
+	}{
+		Summary: c.Summarize(memory),
+		Lines:   c.Annotate(memory),
+	})
+}
+
+func percentage(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(count) / float64(total)
+}
@@ -0,0 +1,104 @@
+package coverage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+func parse(t *testing.T, programString string) []int {
+	memory, err := program.Parse(programString)
+	require.NoError(t, err)
+	return memory
+}
+
+func TestSummarize(t *testing.T) {
+	memory := parse(t, branchingProgram)
+
+	coverage := runCovered(t, branchingProgram, 0)
+	assert.Equal(t, Summary{Instructions: 6, CoveredInstructions: 4, Branches: 2, CoveredBranches: 1},
+		coverage.Summarize(memory))
+
+	coverage.Merge(runCovered(t, branchingProgram, 1))
+	assert.Equal(t, Summary{Instructions: 6, CoveredInstructions: 6, Branches: 2, CoveredBranches: 2},
+		coverage.Summarize(memory))
+}
+
+func TestSummarizeUnconditionalJump(t *testing.T) {
+	// the jump at 0 is always taken, so it has no outcomes to cover and the halt at 3 is unreachable
+	programString := "1105,1,4,99,104,1,99"
+	coverage := runCovered(t, programString, 0)
+
+	assert.Equal(t, Summary{Instructions: 3, CoveredInstructions: 3}, coverage.Summarize(parse(t, programString)))
+}
+
+func TestAnnotate(t *testing.T) {
+	memory := parse(t, branchingProgram)
+	lines := runCovered(t, branchingProgram, 0).Annotate(memory)
+
+	require.Len(t, lines, 6)
+	assert.Equal(t, Covered, lines[0].Status)
+	assert.Equal(t, Partial, lines[1].Status)
+	assert.Equal(t, &Branch{NotTaken: 1}, lines[1].Branch)
+	assert.Equal(t, Uncovered, lines[4].Status)
+	assert.Equal(t, 8, lines[4].Address)
+	assert.Equal(t, 0, lines[4].Count)
+}
+
+func TestAnnotateAlignsWithInstructions(t *testing.T) {
+	// a linear sweep would decode 1 at 3 as an add covering the instruction at 4
+	programString := "1105,1,4,1,104,1,99"
+	lines := runCovered(t, programString, 0).Annotate(parse(t, programString))
+
+	require.Len(t, lines, 4)
+	assert.Equal(t, "    3: data 1", lines[1].Line.String())
+	assert.Equal(t, Data, lines[1].Status)
+	assert.Equal(t, "    4: out 1", lines[2].Line.String())
+	assert.Equal(t, Covered, lines[2].Status)
+}
+
+func TestReport(t *testing.T) {
+	coverage := runCovered(t, branchingProgram, 0)
+
+	var sb strings.Builder
+	require.NoError(t, coverage.Report(&sb, parse(t, branchingProgram)))
+
+	expected := "" +
+		"Instructions covered: 4/6 (66.67%)\n" +
+		"Branches covered: 1/2 (50.00%)\n" +
+		"\n" +
+		"Annotated disassembly:\n" +
+		"           1      0: in [100]\n" +
+		"           1      2: jnz [100], 8  # taken 0, not taken 1\n" +
+		"           1      5: out 0\n" +
+		"           1      7: halt\n" +
+		"       #####      8: out 1\n" +
+		"       #####     10: halt\n"
+	assert.Equal(t, expected, sb.String())
+}
+
+func TestWriteHTML(t *testing.T) {
+	coverage := runCovered(t, branchingProgram, 0)
+
+	var sb strings.Builder
+	require.NoError(t, coverage.WriteHTML(&sb, parse(t, branchingProgram)))
+	html := sb.String()
+
+	assert.Contains(t, html, "Instructions covered: 4/6<br>")
+	assert.Contains(t, html, `<tr class="partial"><td class="count">1</td><td>2</td><td>jnz [100], 8</td><td>taken 0, not taken 1</td></tr>`)
+	assert.Contains(t, html, `<tr class="uncovered"><td class="count">0</td><td>8</td><td>out 1</td><td></td></tr>`)
+}
+
+func TestAnnotateModifiedCode(t *testing.T) {
+	// the instruction at 0 stores 104 at 7, which initially holds data, and the jump at 4 executes it
+	programString := "1101,100,4,7,1105,1,7,0,5,99"
+	lines := runCovered(t, programString, 0).Annotate(parse(t, programString))
+
+	require.Len(t, lines, 5)
+	assert.Equal(t, "    7: data 0 (modified code)", lines[2].Line.String())
+	assert.Equal(t, Covered, lines[2].Status)
+}
@@ -0,0 +1,393 @@
+package dap
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/asm"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/format"
+)
+
+const (
+	threadID        = 1
+	sourceReference = 1
+
+	registersReference = 1
+	memoryReference    = 2
+	// chunksReference is the reference of the first chunk of memory, the next ones following it
+	chunksReference = 3
+	// chunkSize is the number of memory positions shown per chunk
+	chunkSize = 100
+	// maxDisassembled is the maximum number of instructions of a disassemble request
+	maxDisassembled = 1 << 16
+)
+
+// handler handles the arguments of a request and returns the body of its response. The
+// function it returns, if any, is called once the response has been sent.
+type handler func(s *Server, arguments json.RawMessage) (interface{}, func(), error)
+
+var handlers map[string]handler
+
+func init() {
+	handlers = map[string]handler{
+		"initialize":                initialize,
+		"launch":                    launch,
+		"setBreakpoints":            setBreakpoints,
+		"setInstructionBreakpoints": setInstructionBreakpoints,
+		"setExceptionBreakpoints":   setExceptionBreakpoints,
+		"configurationDone":         configurationDone,
+		"threads":                   threads,
+		"stackTrace":                stackTrace,
+		"scopes":                    scopes,
+		"variables":                 variables,
+		"source":                    source,
+		"disassemble":               disassemble,
+		"evaluate":                  evaluate,
+		"continue":                  resumeHandler(forward, untilStop),
+		"next":                      resumeHandler(forward, stepOver),
+		"stepIn":                    resumeHandler(forward, stepInstruction),
+		"stepOut":                   resumeHandler(forward, stepOut),
+		"stepBack":                  resumeHandler(backward, stepInstruction),
+		"reverseContinue":           resumeHandler(backward, untilStop),
+		"pause":                     pause,
+		"disconnect":                disconnect,
+		"terminate":                 disconnect,
+	}
+}
+
+func initialize(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	capabilities := capabilities{
+		SupportsConfigurationDoneRequest: true,
+		SupportsStepBack:                 true,
+		SupportsInstructionBreakpoints:   true,
+		SupportsDisassembleRequest:       true,
+		SupportsTerminateRequest:         true,
+	}
+	return capabilities, func() { s.sendEvent("initialized", nil) }, nil
+}
+
+func launch(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	var args launchArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, nil, err
+	}
+	if s.session.intcode != nil {
+		return nil, nil, fmt.Errorf("a program has already been launched")
+	}
+
+	p, err := format.Load(args.Program)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read program: %w", err)
+	}
+	memory := p.Memory
+
+	intcodeProgram := s.newIntcode(memory)
+	s.session.analyse(memory)
+
+	s.session.name = filepath.Base(args.Program)
+	s.session.intcode = intcodeProgram
+	s.session.lines = asm.Disassemble(memory)
+	s.session.listing = asm.Listing(memory)
+	s.session.inputs = args.Input
+	s.session.stopOnEntry = args.StopOnEntry
+	return nil, s.launched, nil
+}
+
+func setBreakpoints(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	var args setBreakpointsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, nil, err
+	}
+
+	s.session.lineBreakpoints = make(map[int]bool)
+	result := make([]breakpoint, len(args.Breakpoints))
+	for i, b := range args.Breakpoints {
+		result[i] = breakpoint{Line: b.Line}
+		if b.Line < 1 || b.Line > len(s.session.lines) {
+			result[i].Message = fmt.Sprintf("line %d is not in the listing", b.Line)
+			continue
+		}
+
+		address := s.session.lines[b.Line-1].Address
+		s.session.lineBreakpoints[address] = true
+		result[i].Verified = true
+		result[i].InstructionReference = strconv.Itoa(address)
+	}
+
+	return breakpointsBody{Breakpoints: result}, nil, nil
+}
+
+func setInstructionBreakpoints(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	var args setInstructionBreakpointsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, nil, err
+	}
+
+	s.session.instructionBreakpoints = make(map[int]bool)
+	result := make([]breakpoint, len(args.Breakpoints))
+	for i, b := range args.Breakpoints {
+		result[i] = breakpoint{InstructionReference: b.InstructionReference}
+
+		address, err := strconv.Atoi(b.InstructionReference)
+		if err != nil {
+			result[i].Message = fmt.Sprintf("invalid address %s", b.InstructionReference)
+			continue
+		}
+
+		address += b.Offset
+		s.session.instructionBreakpoints[address] = true
+		result[i].Verified = true
+		result[i].InstructionReference = strconv.Itoa(address)
+		if line := s.session.lineOf(address); line >= 0 {
+			result[i].Line = line + 1
+		}
+	}
+
+	return breakpointsBody{Breakpoints: result}, nil, nil
+}
+
+func setExceptionBreakpoints(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	return breakpointsBody{Breakpoints: []breakpoint{}}, nil, nil
+}
+
+func configurationDone(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	s.session.configured = true
+	return nil, s.launched, nil
+}
+
+func threads(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	return threadsBody{Threads: []thread{{ID: threadID, Name: "intcode"}}}, nil, nil
+}
+
+func stackTrace(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	if s.session.intcode == nil {
+		return nil, nil, fmt.Errorf("no program has been launched")
+	}
+
+	address := s.session.intcode.InstructionPointer()
+	frame := stackFrame{
+		ID:                          1,
+		Name:                        fmt.Sprintf("%d: %s", address, s.session.describe(address)),
+		Source:                      s.session.source(),
+		Column:                      1,
+		InstructionPointerReference: strconv.Itoa(address),
+	}
+	if line := s.session.lineOf(address); line >= 0 {
+		frame.Line = line + 1
+	}
+
+	return stackTraceBody{StackFrames: []stackFrame{frame}, TotalFrames: 1}, nil, nil
+}
+
+func scopes(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	return scopesBody{Scopes: []scope{
+		{Name: "Registers", VariablesReference: registersReference},
+		{Name: "Memory", VariablesReference: memoryReference, Expensive: true},
+	}}, nil, nil
+}
+
+func variables(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	var args variablesArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, nil, err
+	}
+	if s.session.intcode == nil {
+		return nil, nil, fmt.Errorf("no program has been launched")
+	}
+
+	var result []variable
+	switch reference := args.VariablesReference; {
+	case reference == registersReference:
+		result = []variable{
+			{Name: "InstructionPointer", Value: strconv.Itoa(s.session.intcode.InstructionPointer()), Type: "int"},
+			{Name: "RelativeBase", Value: strconv.Itoa(s.session.intcode.RelativeBase()), Type: "int"},
+		}
+	case reference == memoryReference:
+		size := s.session.intcode.MemorySize()
+		for _, chunk := range usedChunks(s.session.intcode.Cells()) {
+			start := chunk * chunkSize
+			end := start + chunkSize
+			if end > size {
+				end = size
+			}
+			result = append(result, variable{
+				Name:               fmt.Sprintf("[%d..%d]", start, end-1),
+				VariablesReference: chunksReference + chunk,
+				IndexedVariables:   end - start,
+			})
+		}
+	case reference >= chunksReference:
+		start := (reference - chunksReference) * chunkSize
+		length := s.session.intcode.MemorySize() - start
+		if length > chunkSize {
+			length = chunkSize
+		}
+		if length < 0 {
+			length = 0
+		}
+		memory, err := s.session.intcode.ReadMemory(start, length)
+		if err != nil {
+			return nil, nil, err
+		}
+		for offset, value := range memory {
+			result = append(result, variable{
+				Name:  fmt.Sprintf("[%d]", start+offset),
+				Value: strconv.Itoa(value),
+				Type:  "int",
+			})
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown variables reference %d", reference)
+	}
+
+	if result == nil {
+		result = []variable{}
+	}
+	return variablesBody{Variables: result}, nil, nil
+}
+
+func source(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	if s.session.intcode == nil {
+		return nil, nil, fmt.Errorf("no program has been launched")
+	}
+	return sourceBody{Content: s.session.listing}, nil, nil
+}
+
+func disassemble(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	var args disassembleArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, nil, err
+	}
+	if s.session.intcode == nil {
+		return nil, nil, fmt.Errorf("no program has been launched")
+	}
+
+	address, err := strconv.Atoi(args.MemoryReference)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid memory reference %s", args.MemoryReference)
+	}
+	if args.InstructionCount < 0 || args.InstructionCount > maxDisassembled {
+		return nil, nil, fmt.Errorf("invalid instruction count %d, expected at most %d", args.InstructionCount, maxDisassembled)
+	}
+
+	lines := s.session.lines
+	first := s.session.lineOf(address + args.Offset)
+	if first < 0 {
+		first = len(lines)
+	}
+	first += args.InstructionOffset
+
+	// end is the address right after the listing, from which instructions are unknown
+	end := 0
+	if len(lines) > 0 {
+		end = lines[len(lines)-1].Address + len(lines[len(lines)-1].Cells)
+	}
+
+	result := make([]disassembledInstruction, args.InstructionCount)
+	for i := range result {
+		line := first + i
+		switch {
+		case line < 0:
+			result[i] = disassembledInstruction{Address: strconv.Itoa(line), Instruction: "??", PresentationHint: "invalid"}
+		case line >= len(lines):
+			result[i] = disassembledInstruction{Address: strconv.Itoa(end + line - len(lines)), Instruction: "??", PresentationHint: "invalid"}
+		default:
+			cells := make([]string, len(lines[line].Cells))
+			for j, cell := range lines[line].Cells {
+				cells[j] = strconv.Itoa(cell)
+			}
+			result[i] = disassembledInstruction{
+				Address:          strconv.Itoa(lines[line].Address),
+				InstructionBytes: strings.Join(cells, ","),
+				Instruction:      lines[line].Text,
+				Location:         s.session.source(),
+				Line:             line + 1,
+			}
+		}
+	}
+
+	return disassembleBody{Instructions: result}, nil, nil
+}
+
+// evaluate evaluates the registers ip and rb and memory positions such as [12]. In the
+// debug console, a comma separated list of values is queued as inputs of the program.
+func evaluate(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	var args evaluateArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, nil, err
+	}
+	if s.session.intcode == nil {
+		return nil, nil, fmt.Errorf("no program has been launched")
+	}
+
+	expression := strings.TrimSpace(args.Expression)
+	switch {
+	case expression == "ip":
+		return evaluateBody{Result: strconv.Itoa(s.session.intcode.InstructionPointer())}, nil, nil
+	case expression == "rb":
+		return evaluateBody{Result: strconv.Itoa(s.session.intcode.RelativeBase())}, nil, nil
+	case strings.HasPrefix(expression, "[") && strings.HasSuffix(expression, "]"):
+		position, err := strconv.Atoi(expression[1 : len(expression)-1])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid memory position %s", expression)
+		}
+		value, err := s.session.intcode.Peek(position)
+		if err != nil {
+			return nil, nil, err
+		}
+		return evaluateBody{Result: strconv.Itoa(value)}, nil, nil
+	case args.Context == "repl":
+		var inputs []int
+		for _, token := range strings.Split(expression, ",") {
+			input, err := strconv.Atoi(strings.TrimSpace(token))
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid input %s, expected a comma separated list of integers", token)
+			}
+			inputs = append(inputs, input)
+		}
+		s.session.inputs = append(s.session.inputs, inputs...)
+		return evaluateBody{Result: fmt.Sprintf("%d input(s) queued", len(s.session.inputs))}, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("cannot evaluate %s", expression)
+	}
+}
+
+// resumeHandler returns a handler that resumes the program in direction until stepping is done,
+// running it once the response is sent. It fails while the program is running.
+func resumeHandler(direction direction, stepping stepping) handler {
+	return func(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+		if err := s.session.resume(direction, stepping); err != nil {
+			return nil, nil, err
+		}
+		return continueBody{AllThreadsContinued: true}, s.run, nil
+	}
+}
+
+func pause(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	s.session.pauseRequested = true
+	return nil, nil, nil
+}
+
+func disconnect(s *Server, arguments json.RawMessage) (interface{}, func(), error) {
+	s.session.disconnected = true
+	return nil, nil, nil
+}
+
+// usedChunks returns the sorted indexes of the chunks of memory that hold at least one of cells
+func usedChunks(cells map[int]int) []int {
+	seen := make(map[int]bool)
+	var chunks []int
+	for position := range cells {
+		chunk := position / chunkSize
+		if !seen[chunk] {
+			seen[chunk] = true
+			chunks = append(chunks, chunk)
+		}
+	}
+	sort.Ints(chunks)
+	return chunks
+}
@@ -0,0 +1,39 @@
+package dap
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndReadMessage(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, writeMessage(&b, event{Seq: 1, Type: "event", Event: "initialized"}))
+
+	expected := `{"seq":1,"type":"event","event":"initialized"}`
+	assert.Equal(t, "Content-Length: 46\r\n\r\n"+expected, b.String())
+
+	content, err := readMessage(bufio.NewReader(&b))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(content))
+}
+
+func TestReadMessageInvalid(t *testing.T) {
+	testCases := map[string]string{
+		"missing length": "Content-Type: json\r\n\r\n{}",
+		"invalid length": "Content-Length: a\r\n\r\n{}",
+		"invalid header": "Content-Length\r\n\r\n{}",
+		"short content":  "Content-Length: 10\r\n\r\n{}",
+	}
+
+	for name, message := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := readMessage(bufio.NewReader(strings.NewReader(message)))
+			assert.Error(t, err)
+		})
+	}
+}
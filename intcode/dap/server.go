@@ -0,0 +1,211 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+// Server is a Debug Adapter Protocol server that debugs one Intcode program
+type Server struct {
+	reader *bufio.Reader
+
+	// writeMutex guards the writer and the sequence number of the messages
+	writeMutex sync.Mutex
+	writer     io.Writer
+	seq        int
+
+	// mutex guards the debug session, which is accessed by the requests and by the running program
+	mutex   sync.Mutex
+	session *session
+	// running waits for the program to stop running
+	running sync.WaitGroup
+}
+
+// NewServer returns a new Server that reads requests from r and writes responses and events to w
+func NewServer(r io.Reader, w io.Writer) *Server {
+	return &Server{
+		reader:  bufio.NewReader(r),
+		writer:  w,
+		session: newSession(),
+	}
+}
+
+// Serve handles requests until the client disconnects or closes the connection
+func (s *Server) Serve() error {
+	defer s.running.Wait()
+
+	for {
+		content, err := readMessage(s.reader)
+		if errors.Is(err, io.EOF) {
+			s.disconnect()
+			return nil
+		}
+		if err != nil {
+			s.disconnect()
+			return fmt.Errorf("could not read message: %w", err)
+		}
+
+		var req request
+		err = json.Unmarshal(content, &req)
+		if err != nil {
+			s.disconnect()
+			return fmt.Errorf("could not decode message: %w", err)
+		}
+		if req.Type != "request" {
+			continue
+		}
+
+		if done := s.handle(req); done {
+			return nil
+		}
+	}
+}
+
+// handle handles a request and returns true if the client has disconnected
+func (s *Server) handle(req request) bool {
+	handler, ok := handlers[req.Command]
+	if !ok {
+		s.respond(req, nil, fmt.Errorf("unsupported command %s", req.Command))
+		return false
+	}
+
+	s.mutex.Lock()
+	body, then, err := handler(s, req.Arguments)
+	s.mutex.Unlock()
+
+	s.respond(req, body, err)
+	if err == nil && then != nil {
+		then()
+	}
+	return req.Command == "disconnect" || req.Command == "terminate"
+}
+
+func (s *Server) respond(req request, body interface{}, err error) {
+	r := response{
+		Type:       "response",
+		RequestSeq: req.Seq,
+		Success:    err == nil,
+		Command:    req.Command,
+		Body:       body,
+	}
+	if err != nil {
+		r.Message = err.Error()
+	}
+	s.send(&r, &r.Seq)
+}
+
+func (s *Server) sendEvent(name string, body interface{}) {
+	e := event{
+		Type:  "event",
+		Event: name,
+		Body:  body,
+	}
+	s.send(&e, &e.Seq)
+}
+
+// send writes message after setting its sequence number through seq
+func (s *Server) send(message interface{}, seq *int) {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	s.seq++
+	*seq = s.seq
+	// there is nothing to do if the client is not reading anymore
+	_ = writeMessage(s.writer, message)
+}
+
+// output sends text to the debug console of the client
+func (s *Server) output(category, text string) {
+	s.sendEvent("output", outputEventBody{Category: category, Output: text})
+}
+
+// disconnect stops the program if it is running
+func (s *Server) disconnect() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.session.disconnected = true
+}
+
+// run runs the program in the background until it stops, once the session has been resumed
+func (s *Server) run() {
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+
+		for first := true; ; first = false {
+			s.mutex.Lock()
+			stop := s.session.advance(first)
+			if stop != nil {
+				s.session.running = false
+			}
+			s.mutex.Unlock()
+
+			if stop != nil {
+				s.stopped(stop)
+				return
+			}
+		}
+	}()
+}
+
+// stopped notifies the client that the program has stopped
+func (s *Server) stopped(stop *stop) {
+	switch {
+	case stop.disconnected:
+	case stop.terminated:
+		s.sendEvent("exited", exitedEventBody{ExitCode: 0})
+		s.sendEvent("terminated", nil)
+	default:
+		if stop.console != "" {
+			s.output("console", stop.console)
+		}
+		s.sendEvent("stopped", stoppedEventBody{
+			Reason:            stop.reason,
+			Description:       stop.description,
+			Text:              stop.text,
+			ThreadID:          threadID,
+			AllThreadsStopped: true,
+		})
+	}
+}
+
+// launched starts the program once it has been launched and configured
+func (s *Server) launched() {
+	s.mutex.Lock()
+	ready := s.session.intcode != nil && s.session.configured && !s.session.started
+	if ready {
+		s.session.started = true
+	}
+	stopOnEntry := s.session.stopOnEntry
+	var err error
+	if ready && !stopOnEntry {
+		err = s.session.resume(forward, untilStop)
+	}
+	s.mutex.Unlock()
+
+	switch {
+	case !ready:
+	case stopOnEntry:
+		s.stopped(&stop{reason: "entry"})
+	case err != nil:
+		s.output("console", err.Error()+"\n")
+	default:
+		s.run()
+	}
+}
+
+// newIntcode creates the Intcode program of the session, which writes its outputs to the console
+func (s *Server) newIntcode(memory []int) *intcode.Intcode {
+	onOutput := func(output int) {
+		s.output("stdout", fmt.Sprintf("%d\n", output))
+	}
+	return intcode.NewIntcodeFromState(
+		memory, 0, 0, s.session.readInput, onOutput, intcode.WithJournal(journalCapacity),
+	)
+}
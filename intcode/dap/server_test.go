@@ -0,0 +1,388 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// doubler reads a value and writes its double, twice
+const doubler = "3,17,1002,17,2,18,4,18,3,17,1002,17,2,18,4,18,99,0,0"
+
+// caller calls a function at 12 that stores 7 in [20] and outputs [20] once it returns
+const caller = "109,100,21101,0,9,0,1105,1,12,4,20,99,1101,7,0,20,2106,0,0,0,0"
+
+// looper loops forever
+const looper = "1105,1,0"
+
+// message is a response or an event received by the client
+type message struct {
+	Type       string          `json:"type"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Command    string          `json:"command"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// client is a scripted DAP client connected to a server
+type client struct {
+	t        *testing.T
+	writer   io.WriteCloser
+	messages chan message
+	seq      int
+	// events are the events received while waiting for a response
+	events []message
+	// output is the text written by the program to the console
+	output string
+	served chan error
+}
+
+func newClient(t *testing.T) *client {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	c := &client{
+		t:        t,
+		writer:   clientWriter,
+		messages: make(chan message),
+		served:   make(chan error, 1),
+	}
+
+	go func() {
+		c.served <- NewServer(serverReader, serverWriter).Serve()
+		serverWriter.Close()
+	}()
+
+	go func() {
+		defer close(c.messages)
+		reader := bufio.NewReader(clientReader)
+		for {
+			content, err := readMessage(reader)
+			if err != nil {
+				return
+			}
+			var m message
+			if json.Unmarshal(content, &m) == nil {
+				c.messages <- m
+			}
+		}
+	}()
+
+	return c
+}
+
+// receive returns the next message sent by the server
+func (c *client) receive() message {
+	select {
+	case m, ok := <-c.messages:
+		require.True(c.t, ok, "server closed the connection")
+		if m.Event == "output" {
+			var body outputEventBody
+			require.NoError(c.t, json.Unmarshal(m.Body, &body))
+			if body.Category == "stdout" {
+				c.output += body.Output
+			}
+		}
+		return m
+	case <-time.After(5 * time.Second):
+		require.FailNow(c.t, "timeout waiting for a message")
+		return message{}
+	}
+}
+
+// request sends a request and decodes the body of its response into body
+func (c *client) request(command string, arguments interface{}, body interface{}) message {
+	c.seq++
+	require.NoError(c.t, writeMessage(c.writer, request{
+		Seq:       c.seq,
+		Type:      "request",
+		Command:   command,
+		Arguments: mustMarshal(c.t, arguments),
+	}))
+
+	for {
+		m := c.receive()
+		if m.Type == "event" {
+			c.events = append(c.events, m)
+			continue
+		}
+
+		require.Equal(c.t, c.seq, m.RequestSeq)
+		require.Equal(c.t, command, m.Command)
+		if body != nil {
+			require.True(c.t, m.Success, "%s failed: %s", command, m.Message)
+			require.NoError(c.t, json.Unmarshal(m.Body, body))
+		}
+		return m
+	}
+}
+
+// event waits for the event with the given name, skipping the other ones, and decodes its body
+func (c *client) event(name string, body interface{}) {
+	for {
+		var m message
+		if len(c.events) > 0 {
+			m, c.events = c.events[0], c.events[1:]
+		} else {
+			m = c.receive()
+		}
+
+		if m.Type == "event" && m.Event == name {
+			if body != nil {
+				require.NoError(c.t, json.Unmarshal(m.Body, body))
+			}
+			return
+		}
+	}
+}
+
+// stopped waits for the program to stop and returns the reason and the line it stopped at
+func (c *client) stopped() (string, int) {
+	var stopped stoppedEventBody
+	c.event("stopped", &stopped)
+
+	var trace stackTraceBody
+	c.request("stackTrace", map[string]int{"threadId": threadID}, &trace)
+	require.Len(c.t, trace.StackFrames, 1)
+	return stopped.Reason, trace.StackFrames[0].Line
+}
+
+func mustMarshal(t *testing.T, v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	content, err := json.Marshal(v)
+	require.NoError(t, err)
+	return content
+}
+
+func writeProgram(t *testing.T, programString string) string {
+	filename := filepath.Join(t.TempDir(), "program.txt")
+	require.NoError(t, os.WriteFile(filename, []byte(programString+"\n"), 0644))
+	return filename
+}
+
+// launch initializes the session and launches programString, stopping on its entry
+func (c *client) launch(programString string, input []int) {
+	var capabilities capabilities
+	c.request("initialize", map[string]string{"adapterID": "intcode"}, &capabilities)
+	assert.True(c.t, capabilities.SupportsStepBack)
+	c.event("initialized", nil)
+
+	arguments := launchArguments{Program: writeProgram(c.t, programString), StopOnEntry: true, Input: input}
+	m := c.request("launch", arguments, nil)
+	require.True(c.t, m.Success, m.Message)
+}
+
+func (c *client) disconnect() {
+	c.request("disconnect", nil, nil)
+	select {
+	case err := <-c.served:
+		assert.NoError(c.t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(c.t, "timeout waiting for the server to stop")
+	}
+}
+
+func TestServerSession(t *testing.T) {
+	c := newClient(t)
+	c.launch(doubler, []int{3})
+
+	var breakpoints breakpointsBody
+	c.request("setBreakpoints", setBreakpointsArguments{
+		Source:      sourceReferenceBody{SourceReference: sourceReference},
+		Breakpoints: []sourceBreakpoint{{Line: 3}, {Line: 100}},
+	}, &breakpoints)
+	assert.Equal(t, []breakpoint{
+		{Verified: true, Line: 3, InstructionReference: "6"},
+		{Message: "line 100 is not in the listing", Line: 100},
+	}, breakpoints.Breakpoints)
+
+	c.request("configurationDone", nil, nil)
+	reason, line := c.stopped()
+	assert.Equal(t, "entry", reason)
+	assert.Equal(t, 1, line)
+
+	c.request("continue", map[string]int{"threadId": threadID}, &continueBody{})
+	reason, line = c.stopped()
+	assert.Equal(t, "breakpoint", reason)
+	assert.Equal(t, 3, line)
+
+	var registers variablesBody
+	c.request("variables", variablesArguments{VariablesReference: registersReference}, &registers)
+	assert.Equal(t, "6", registers.Variables[0].Value)
+
+	c.request("stepBack", map[string]int{"threadId": threadID}, &continueBody{})
+	reason, line = c.stopped()
+	assert.Equal(t, "step", reason)
+	assert.Equal(t, 2, line)
+
+	c.request("next", map[string]int{"threadId": threadID}, &continueBody{})
+	_, line = c.stopped()
+	assert.Equal(t, 3, line)
+
+	c.request("continue", map[string]int{"threadId": threadID}, &continueBody{})
+	var stopped stoppedEventBody
+	c.event("stopped", &stopped)
+	assert.Equal(t, "pause", stopped.Reason)
+	assert.Equal(t, "Waiting for input", stopped.Description)
+	assert.Equal(t, "6\n", c.output)
+
+	var evaluated evaluateBody
+	c.request("evaluate", evaluateArguments{Expression: "5", Context: "repl"}, &evaluated)
+	assert.Equal(t, "1 input(s) queued", evaluated.Result)
+
+	c.request("continue", map[string]int{"threadId": threadID}, &continueBody{})
+	var exited exitedEventBody
+	c.event("exited", &exited)
+	c.event("terminated", nil)
+	assert.Equal(t, "6\n10\n", c.output)
+
+	c.disconnect()
+}
+
+func TestServerInspection(t *testing.T) {
+	c := newClient(t)
+	c.launch(doubler, []int{4})
+
+	var breakpoints breakpointsBody
+	c.request("setInstructionBreakpoints", setInstructionBreakpointsArguments{
+		Breakpoints: []instructionBreakpoint{{InstructionReference: "2", Offset: 4}},
+	}, &breakpoints)
+	assert.Equal(t, []breakpoint{{Verified: true, Line: 3, InstructionReference: "6"}}, breakpoints.Breakpoints)
+
+	c.request("configurationDone", nil, nil)
+	c.stopped()
+	c.request("continue", map[string]int{"threadId": threadID}, &continueBody{})
+	reason, _ := c.stopped()
+	assert.Equal(t, "instruction breakpoint", reason)
+
+	var evaluated evaluateBody
+	c.request("evaluate", evaluateArguments{Expression: "[18]", Context: "watch"}, &evaluated)
+	assert.Equal(t, "8", evaluated.Result)
+	c.request("evaluate", evaluateArguments{Expression: "ip", Context: "watch"}, &evaluated)
+	assert.Equal(t, "6", evaluated.Result)
+
+	var memory variablesBody
+	c.request("variables", variablesArguments{VariablesReference: memoryReference}, &memory)
+	require.Len(t, memory.Variables, 1)
+	assert.Equal(t, "[0..18]", memory.Variables[0].Name)
+
+	var chunk variablesBody
+	c.request("variables", variablesArguments{VariablesReference: memory.Variables[0].VariablesReference}, &chunk)
+	require.Len(t, chunk.Variables, 19)
+	assert.Equal(t, variable{Name: "[17]", Value: "4", Type: "int"}, chunk.Variables[17])
+
+	var source sourceBody
+	c.request("source", map[string]int{"sourceReference": sourceReference}, &source)
+	assert.Contains(t, source.Content, "    6: out [18]\n")
+
+	var disassembly disassembleBody
+	c.request("disassemble", disassembleArguments{
+		MemoryReference: "6", InstructionOffset: -1, InstructionCount: 3,
+	}, &disassembly)
+	require.Len(t, disassembly.Instructions, 3)
+	assert.Equal(t, "mul [17], 2, [18]", disassembly.Instructions[0].Instruction)
+	assert.Equal(t, "6", disassembly.Instructions[1].Address)
+	assert.Equal(t, "in [17]", disassembly.Instructions[2].Instruction)
+
+	m := c.request("disassemble", disassembleArguments{MemoryReference: "6", InstructionCount: -1}, nil)
+	assert.False(t, m.Success)
+	m = c.request("evaluate", evaluateArguments{Expression: "x", Context: "repl"}, nil)
+	assert.False(t, m.Success)
+	m = c.request("unknown", nil, nil)
+	assert.False(t, m.Success)
+
+	c.disconnect()
+}
+
+func TestServerMemoryAtHighAddress(t *testing.T) {
+	c := newClient(t)
+	c.launch("1101,1,1,100000000000000,99", nil)
+
+	c.request("setInstructionBreakpoints", setInstructionBreakpointsArguments{
+		Breakpoints: []instructionBreakpoint{{InstructionReference: "4"}},
+	}, &breakpointsBody{})
+	c.request("configurationDone", nil, nil)
+	c.stopped()
+	c.request("continue", map[string]int{"threadId": threadID}, &continueBody{})
+	c.stopped()
+
+	var memory variablesBody
+	c.request("variables", variablesArguments{VariablesReference: memoryReference}, &memory)
+	require.Len(t, memory.Variables, 2)
+	assert.Equal(t, "[0..99]", memory.Variables[0].Name)
+	assert.Equal(t, "[100000000000000..100000000000000]", memory.Variables[1].Name)
+
+	var chunk variablesBody
+	c.request("variables", variablesArguments{VariablesReference: memory.Variables[1].VariablesReference}, &chunk)
+	assert.Equal(t, []variable{{Name: "[100000000000000]", Value: "2", Type: "int"}}, chunk.Variables)
+
+	c.disconnect()
+}
+
+func TestServerStepOverAndOut(t *testing.T) {
+	c := newClient(t)
+	c.launch(caller, nil)
+	c.request("configurationDone", nil, nil)
+	c.stopped()
+
+	c.request("stepIn", map[string]int{"threadId": threadID}, &continueBody{})
+	c.stopped()
+	c.request("stepIn", map[string]int{"threadId": threadID}, &continueBody{})
+	_, line := c.stopped()
+	assert.Equal(t, 3, line)
+
+	c.request("next", map[string]int{"threadId": threadID}, &continueBody{})
+	reason, line := c.stopped()
+	assert.Equal(t, "step", reason)
+	assert.Equal(t, 4, line)
+
+	c.request("stepBack", map[string]int{"threadId": threadID}, &continueBody{})
+	c.stopped()
+	c.request("stepBack", map[string]int{"threadId": threadID}, &continueBody{})
+	_, line = c.stopped()
+	assert.Equal(t, 6, line)
+
+	c.request("stepOut", map[string]int{"threadId": threadID}, &continueBody{})
+	reason, line = c.stopped()
+	assert.Equal(t, "step", reason)
+	assert.Equal(t, 4, line)
+
+	c.request("continue", map[string]int{"threadId": threadID}, &continueBody{})
+	c.event("terminated", nil)
+	assert.Equal(t, "7\n", c.output)
+
+	c.disconnect()
+}
+
+func TestServerRejectsResumeWhileRunning(t *testing.T) {
+	c := newClient(t)
+	c.launch(looper, nil)
+	c.request("configurationDone", nil, nil)
+	c.stopped()
+
+	c.request("continue", map[string]int{"threadId": threadID}, &continueBody{})
+	m := c.request("next", map[string]int{"threadId": threadID}, nil)
+	assert.False(t, m.Success)
+	assert.Equal(t, "the program is already running", m.Message)
+
+	c.request("pause", map[string]int{"threadId": threadID}, nil)
+	reason, _ := c.stopped()
+	assert.Equal(t, "pause", reason)
+
+	c.request("next", map[string]int{"threadId": threadID}, &continueBody{})
+	reason, _ = c.stopped()
+	assert.Equal(t, "step", reason)
+
+	c.disconnect()
+}
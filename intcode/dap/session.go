@@ -0,0 +1,267 @@
+package dap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/analysis"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/asm"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// journalCapacity is the number of instructions that can be stepped back
+const journalCapacity = 1 << 16
+
+// direction is the direction in which a program runs
+type direction int
+
+const (
+	forward direction = iota
+	backward
+)
+
+// stepping is how far the program runs once it is resumed
+type stepping int
+
+const (
+	// untilStop runs until a breakpoint, a pause or the end of the program
+	untilStop stepping = iota
+	// stepInstruction executes a single instruction
+	stepInstruction
+	// stepOver executes a single instruction, running the functions it calls until they return
+	stepOver
+	// stepOut runs until the current function returns
+	stepOut
+)
+
+// session is the state of the debug session of a program
+type session struct {
+	// name is the name of the program file
+	name    string
+	intcode *intcode.Intcode
+	listing string
+	lines   []asm.Line
+	inputs  []int
+	// calls and returns are the addresses of the instructions that call a function and that
+	// return from one, as found by the control flow analysis of the launched program
+	calls   map[int]bool
+	returns map[int]bool
+
+	stopOnEntry bool
+	configured  bool
+	started     bool
+
+	// lineBreakpoints are the addresses of the breakpoints set by listing line
+	lineBreakpoints map[int]bool
+	// instructionBreakpoints are the addresses of the breakpoints set by address
+	instructionBreakpoints map[int]bool
+
+	// running indicates that the program has been resumed and has not stopped yet
+	running   bool
+	direction direction
+	stepping  stepping
+	// depth is the number of calls minus the number of returns executed since the program was resumed
+	depth int
+
+	pauseRequested bool
+	disconnected   bool
+}
+
+// stop describes why a running program has stopped
+type stop struct {
+	reason      string
+	description string
+	text        string
+	// console is a message shown in the debug console when the program stops
+	console string
+
+	terminated   bool
+	disconnected bool
+}
+
+func newSession() *session {
+	return &session{
+		calls:                  make(map[int]bool),
+		returns:                make(map[int]bool),
+		lineBreakpoints:        make(map[int]bool),
+		instructionBreakpoints: make(map[int]bool),
+	}
+}
+
+// analyse finds the calls and returns of memory, which are used to step over and out of
+// functions. Code that the analysis does not reach is stepped one instruction at a time.
+func (s *session) analyse(memory []int) {
+	graph, err := analysis.Build(memory)
+	if err != nil {
+		return
+	}
+	for _, block := range graph.Blocks {
+		if len(block.Instructions) == 0 {
+			continue
+		}
+		last := block.Last().Address
+		if block.Returns {
+			s.returns[last] = true
+		}
+		for _, edge := range block.Successors {
+			if edge.Kind == analysis.Call {
+				s.calls[last] = true
+			}
+		}
+	}
+}
+
+// resume prepares the program to run in direction until stepping is done, which fails
+// if it is already running
+func (s *session) resume(direction direction, stepping stepping) error {
+	if s.intcode == nil {
+		return fmt.Errorf("no program has been launched")
+	}
+	if s.running {
+		return fmt.Errorf("the program is already running")
+	}
+	if direction == backward && stepping != untilStop && stepping != stepInstruction {
+		return fmt.Errorf("cannot step over or out of functions backwards")
+	}
+
+	s.running = true
+	s.direction = direction
+	s.stepping = stepping
+	s.depth = 0
+	s.pauseRequested = false
+	return nil
+}
+
+// stepped returns true if the program has run as far as it was requested when it was resumed
+func (s *session) stepped() bool {
+	switch s.stepping {
+	case stepInstruction:
+		return true
+	case stepOver:
+		return s.depth <= 0
+	case stepOut:
+		return s.depth < 0
+	default:
+		return false
+	}
+}
+
+// readInput returns the next input of the queue, which advance ensures is not empty
+func (s *session) readInput() int {
+	input := s.inputs[0]
+	s.inputs = s.inputs[1:]
+	return input
+}
+
+// advance executes one instruction in the direction the program was resumed, returning why
+// the program stopped after it or nil if it must keep running. first indicates that it is the
+// first instruction executed since the program was resumed, which does not stop on breakpoints.
+func (s *session) advance(first bool) *stop {
+	if s.disconnected {
+		return &stop{disconnected: true}
+	}
+	if s.pauseRequested {
+		s.pauseRequested = false
+		return &stop{reason: "pause"}
+	}
+	if !first {
+		if reason, ok := s.breakpoint(s.intcode.InstructionPointer()); ok {
+			return &stop{reason: reason}
+		}
+	}
+	if !first && s.stepped() {
+		return &stop{reason: "step"}
+	}
+
+	if s.direction == backward {
+		err := s.intcode.StepBack()
+		if errors.Is(err, intcode.ErrJournalExhausted) {
+			return &stop{reason: "step", description: "Reached the beginning of the journal"}
+		}
+		if s.stepped() {
+			return &stop{reason: "step"}
+		}
+		return nil
+	}
+
+	if s.intcode.Halted() {
+		return &stop{terminated: true}
+	}
+	if s.expectsInput() && len(s.inputs) == 0 {
+		return &stop{
+			reason:      "pause",
+			description: "Waiting for input",
+			console:     "the program expects an input, type it in the debug console and continue\n",
+		}
+	}
+
+	address := s.intcode.InstructionPointer()
+	err := s.intcode.Step()
+	if s.calls[address] {
+		s.depth++
+	}
+	if s.returns[address] {
+		s.depth--
+	}
+	if err != nil {
+		return &stop{reason: "exception", description: "Error", text: err.Error(), console: err.Error() + "\n"}
+	}
+	if s.intcode.Halted() {
+		return &stop{terminated: true}
+	}
+	return nil
+}
+
+// expectsInput returns true if the instruction at the instruction pointer reads an input
+func (s *session) expectsInput() bool {
+	n, err := s.intcode.Peek(s.intcode.InstructionPointer())
+	return err == nil && n%100 == instruction.InputOpcode
+}
+
+// breakpoint returns the reason to stop at address if there is a breakpoint on it
+func (s *session) breakpoint(address int) (string, bool) {
+	if s.lineBreakpoints[address] {
+		return "breakpoint", true
+	}
+	if s.instructionBreakpoints[address] {
+		return "instruction breakpoint", true
+	}
+	return "", false
+}
+
+// lineOf returns the index of the listing line holding address, or -1 if there is none
+func (s *session) lineOf(address int) int {
+	i := sort.Search(len(s.lines), func(i int) bool {
+		return s.lines[i].Address+len(s.lines[i].Cells) > address
+	})
+	if i == len(s.lines) || s.lines[i].Address > address {
+		return -1
+	}
+	return i
+}
+
+// source returns the source of the listing of the program
+func (s *session) source() *sourceReferenceBody {
+	return &sourceReferenceBody{Name: s.name + ".asm", SourceReference: sourceReference}
+}
+
+// describe returns the assembly of the instruction at address as currently stored in memory
+func (s *session) describe(address int) string {
+	n, err := s.intcode.Peek(address)
+	if err != nil {
+		return err.Error()
+	}
+
+	decoded, err := instruction.Decode(n)
+	if err != nil {
+		return fmt.Sprintf("data %d", n)
+	}
+
+	parameters := make([]int, len(decoded.Modes))
+	for i := range parameters {
+		parameters[i], _ = s.intcode.Peek(address + 1 + i)
+	}
+	return asm.Format(decoded, parameters)
+}
@@ -0,0 +1,159 @@
+package dap
+
+// The types below are the subset of the Debug Adapter Protocol used by the server,
+// see https://microsoft.github.io/debug-adapter-protocol/specification
+
+type capabilities struct {
+	SupportsConfigurationDoneRequest bool `json:"supportsConfigurationDoneRequest"`
+	SupportsStepBack                 bool `json:"supportsStepBack"`
+	SupportsInstructionBreakpoints   bool `json:"supportsInstructionBreakpoints"`
+	SupportsDisassembleRequest       bool `json:"supportsDisassembleRequest"`
+	SupportsTerminateRequest         bool `json:"supportsTerminateRequest"`
+}
+
+type launchArguments struct {
+	// Program is the path of the file holding the Intcode program
+	Program string `json:"program"`
+	// StopOnEntry indicates that the program must stop before executing its first instruction
+	StopOnEntry bool `json:"stopOnEntry"`
+	// Input are the first inputs of the program, the next ones being typed in the debug console
+	Input []int `json:"input"`
+}
+
+type sourceReferenceBody struct {
+	Name            string `json:"name"`
+	SourceReference int    `json:"sourceReference"`
+}
+
+type sourceBreakpoint struct {
+	Line int `json:"line"`
+}
+
+type setBreakpointsArguments struct {
+	Source      sourceReferenceBody `json:"source"`
+	Breakpoints []sourceBreakpoint  `json:"breakpoints"`
+}
+
+type instructionBreakpoint struct {
+	InstructionReference string `json:"instructionReference"`
+	Offset               int    `json:"offset"`
+}
+
+type setInstructionBreakpointsArguments struct {
+	Breakpoints []instructionBreakpoint `json:"breakpoints"`
+}
+
+type breakpoint struct {
+	Verified             bool   `json:"verified"`
+	Message              string `json:"message,omitempty"`
+	Line                 int    `json:"line,omitempty"`
+	InstructionReference string `json:"instructionReference,omitempty"`
+}
+
+type breakpointsBody struct {
+	Breakpoints []breakpoint `json:"breakpoints"`
+}
+
+type thread struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type threadsBody struct {
+	Threads []thread `json:"threads"`
+}
+
+type stackFrame struct {
+	ID                          int                  `json:"id"`
+	Name                        string               `json:"name"`
+	Source                      *sourceReferenceBody `json:"source,omitempty"`
+	Line                        int                  `json:"line"`
+	Column                      int                  `json:"column"`
+	InstructionPointerReference string               `json:"instructionPointerReference"`
+}
+
+type stackTraceBody struct {
+	StackFrames []stackFrame `json:"stackFrames"`
+	TotalFrames int          `json:"totalFrames"`
+}
+
+type scope struct {
+	Name               string `json:"name"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+type scopesBody struct {
+	Scopes []scope `json:"scopes"`
+}
+
+type variablesArguments struct {
+	VariablesReference int `json:"variablesReference"`
+}
+
+type variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+	IndexedVariables   int    `json:"indexedVariables,omitempty"`
+}
+
+type variablesBody struct {
+	Variables []variable `json:"variables"`
+}
+
+type sourceBody struct {
+	Content string `json:"content"`
+}
+
+type disassembleArguments struct {
+	MemoryReference   string `json:"memoryReference"`
+	Offset            int    `json:"offset"`
+	InstructionOffset int    `json:"instructionOffset"`
+	InstructionCount  int    `json:"instructionCount"`
+}
+
+type disassembledInstruction struct {
+	Address          string               `json:"address"`
+	InstructionBytes string               `json:"instructionBytes,omitempty"`
+	Instruction      string               `json:"instruction"`
+	Location         *sourceReferenceBody `json:"location,omitempty"`
+	Line             int                  `json:"line,omitempty"`
+	PresentationHint string               `json:"presentationHint,omitempty"`
+}
+
+type disassembleBody struct {
+	Instructions []disassembledInstruction `json:"instructions"`
+}
+
+type evaluateArguments struct {
+	Expression string `json:"expression"`
+	Context    string `json:"context"`
+}
+
+type evaluateBody struct {
+	Result             string `json:"result"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+type continueBody struct {
+	AllThreadsContinued bool `json:"allThreadsContinued"`
+}
+
+type stoppedEventBody struct {
+	Reason            string `json:"reason"`
+	Description       string `json:"description,omitempty"`
+	Text              string `json:"text,omitempty"`
+	ThreadID          int    `json:"threadId"`
+	AllThreadsStopped bool   `json:"allThreadsStopped"`
+}
+
+type outputEventBody struct {
+	Category string `json:"category"`
+	Output   string `json:"output"`
+}
+
+type exitedEventBody struct {
+	ExitCode int `json:"exitCode"`
+}
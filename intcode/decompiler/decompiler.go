@@ -0,0 +1,212 @@
+package decompiler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/analysis"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// none represents the absence of a block
+const none = -1
+
+// decompilation holds the results of the analyses of a program needed to decompile it
+type decompilation struct {
+	memory []int
+	graph  *analysis.Graph
+
+	// globals are the positions accessed in position mode
+	globals map[int]bool
+	// temporaries are the positions only used to hold the condition of the jump that follows
+	temporaries map[int]bool
+	// written are the positions written in position mode, or in immediate mode which stores
+	// at the same position
+	written map[int]bool
+	// modified are the positions of the instructions whose cells are written
+	modified map[int]bool
+	// parameters are the number of parameters of each function by entry
+	parameters map[int]int
+}
+
+// Decompile lifts the program stored in memory into structured Go-like pseudocode.
+// Memory cells accessed in position mode become global variables, and the cells of the
+// stack frames of functions, accessed relative to the relative base, become their
+// parameters and local variables. An instruction whose opcode is written by the program is
+// only known at runtime, so it becomes a call to exec that ends the decompiled code.
+func Decompile(memory []int) (string, error) {
+	graph, err := analysis.Build(memory)
+	if err != nil {
+		return "", fmt.Errorf("could not build control flow graph: %w", err)
+	}
+
+	p := &decompilation{
+		memory:      memory,
+		graph:       graph,
+		globals:     make(map[int]bool),
+		temporaries: make(map[int]bool),
+		written:     make(map[int]bool),
+		modified:    make(map[int]bool),
+		parameters:  make(map[int]int),
+	}
+	p.analyseMemory()
+	p.analyseCalls()
+
+	var functions []string
+	for _, function := range graph.SortedFunctions() {
+		functions = append(functions, p.decompileFunction(function))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(p.declareGlobals())
+	for _, function := range functions {
+		sb.WriteString("\n")
+		sb.WriteString(function)
+	}
+	return sb.String(), nil
+}
+
+// analyseMemory finds the global variables, the temporaries and the self-modified instructions
+func (p *decompilation) analyseMemory() {
+	reads := make(map[int][]analysis.Instruction)
+	previous := make(map[int]analysis.Instruction)
+
+	for _, block := range p.graph.Blocks {
+		for i, ins := range block.Instructions {
+			if i > 0 {
+				previous[ins.Address] = block.Instructions[i-1]
+			}
+			for j, kind := range ins.Definition.Parameters {
+				if ins.Addressing(j) != instruction.Position {
+					continue
+				}
+				position := ins.Parameters[j]
+				p.globals[position] = true
+				if kind == instruction.Write {
+					p.written[position] = true
+				} else {
+					reads[position] = append(reads[position], ins)
+				}
+			}
+		}
+	}
+
+	for position, readers := range reads {
+		if isTemporary(position, readers, previous) {
+			p.temporaries[position] = true
+		}
+	}
+
+	for _, block := range p.graph.Blocks {
+		for _, ins := range block.Instructions {
+			for position := ins.Address; position < ins.Next(); position++ {
+				if p.written[position] {
+					p.modified[ins.Address] = true
+				}
+			}
+		}
+	}
+}
+
+// isTemporary returns true if position is only read by conditional jumps right after
+// a comparison writes it, in which case the comparison can be folded into the condition
+func isTemporary(position int, readers []analysis.Instruction, previous map[int]analysis.Instruction) bool {
+	for _, reader := range readers {
+		if !isJump(reader) || reader.Modes[0] != instruction.Position || reader.Parameters[0] != position {
+			return false
+		}
+		if reader.Modes[1] == instruction.Position && reader.Parameters[1] == position {
+			return false
+		}
+
+		comparison, ok := previous[reader.Address]
+		if !ok || !isComparison(comparison) {
+			return false
+		}
+		if comparison.Addressing(2) != instruction.Position || comparison.Parameters[2] != position {
+			return false
+		}
+	}
+	return true
+}
+
+// analyseCalls finds the number of parameters of each function, which is the highest
+// slot of the frame of the callee stored by its callers right before calling it
+func (p *decompilation) analyseCalls() {
+	for _, block := range p.graph.Blocks {
+		callee, ok := calleeOf(block)
+		if !ok {
+			continue
+		}
+		for _, ins := range block.Instructions {
+			if len(ins.Parameters) == 0 {
+				continue
+			}
+			last := len(ins.Parameters) - 1
+			if ins.Definition.Parameters[last] != instruction.Write || ins.Modes[last] != instruction.Relative {
+				continue
+			}
+			if slot := ins.Parameters[last]; slot > p.parameters[callee] {
+				p.parameters[callee] = slot
+			}
+		}
+	}
+}
+
+func (p *decompilation) declareGlobals() string {
+	var positions []int
+	for position := range p.globals {
+		if !p.temporaries[position] {
+			positions = append(positions, position)
+		}
+	}
+	if len(positions) == 0 {
+		return ""
+	}
+	sort.Ints(positions)
+
+	var sb strings.Builder
+	sb.WriteString("var (\n")
+	for _, position := range positions {
+		fmt.Fprintf(&sb, "\t%s = %d\n", globalName(position), p.initialValue(position))
+	}
+	sb.WriteString(")\n")
+	return sb.String()
+}
+
+func (p *decompilation) initialValue(position int) int {
+	if position < 0 || position >= len(p.memory) {
+		return 0
+	}
+	return p.memory[position]
+}
+
+func globalName(position int) string {
+	return fmt.Sprintf("m%d", position)
+}
+
+func functionName(entry int) string {
+	if entry == 0 {
+		return "main"
+	}
+	return fmt.Sprintf("f%d", entry)
+}
+
+// calleeOf returns the entry of the function called at the end of block, if any
+func calleeOf(block *analysis.Block) (int, bool) {
+	for _, edge := range block.Successors {
+		if edge.Kind == analysis.Call {
+			return edge.To, true
+		}
+	}
+	return 0, false
+}
+
+func isJump(ins analysis.Instruction) bool {
+	return ins.Opcode == instruction.JumpIfTrueOpcode || ins.Opcode == instruction.JumpIfFalseOpcode
+}
+
+func isComparison(ins analysis.Instruction) bool {
+	return ins.Opcode == instruction.LessThanOpcode || ins.Opcode == instruction.EqualsOpcode
+}
@@ -0,0 +1,157 @@
+package decompiler
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+func TestDecompile(t *testing.T) {
+	testCases := map[string]struct {
+		program  string
+		expected string
+	}{
+		"loop": {
+			program: "1101,0,0,100,1001,100,1,100,1007,100,3,101,1005,101,4,4,100,99",
+			expected: `var (
+	m100 = 0
+)
+
+func main() {
+	m100 = 0
+	for {
+		m100++
+		if m100 >= 3 {
+			break
+		}
+	}
+	output(m100)
+	halt()
+}
+`,
+		},
+		"if else": {
+			program: "3,100,1008,100,5,101,1006,101,14,104,1,1105,1,16,104,0,99",
+			expected: `var (
+	m100 = 0
+)
+
+func main() {
+	m100 = input()
+	if m100 == 5 {
+		output(1)
+	} else {
+		output(0)
+	}
+	halt()
+}
+`,
+		},
+		"function call": {
+			program: "109,100,21101,7,0,1,21101,13,0,0,1105,1,18,204,1,99,0,0," +
+				"109,2,21202,-1,2,-1,109,-2,2106,0,0",
+			expected: `var (
+	m101 = 0
+)
+
+func main() {
+	rb += 100
+	m101 = 7
+	f18(m101)
+	output(m101)
+	halt()
+}
+
+func f18(p1 int) {
+	p1 = p1 * 2
+	return
+}
+`,
+		},
+		"self-modified opcode": {
+			program: "3,100,1,100,6,6,1100,1,100,99",
+			expected: `var (
+	m6 = 1100
+	m100 = 0
+)
+
+func main() {
+	m100 = input()
+	m6 = m100 + m6
+	// self-modified instruction at 6
+	exec(6)
+}
+`,
+		},
+		"immediate mode write": {
+			program: "11101,2,3,100,4,100,99",
+			expected: `var (
+	m100 = 0
+)
+
+func main() {
+	m100 = 2 + 3
+	output(m100)
+	halt()
+}
+`,
+		},
+		"self-modifying code": {
+			program: "1101,4,0,7,1002,0,2,100,99",
+			expected: `var (
+	m0 = 1101
+	m7 = 100
+	m100 = 0
+)
+
+func main() {
+	m7 = 4
+	// self-modified instruction at 4
+	mem[m7] = m0 * 2
+	halt()
+}
+`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			memory, err := program.Parse(testCase.program)
+			require.NoError(t, err)
+
+			pseudocode, err := Decompile(memory)
+			require.NoError(t, err)
+
+			assert.Equal(t, testCase.expected, pseudocode)
+		})
+	}
+}
+
+// TestDecompileGolden checks the pseudocode of the day programs against the golden files in testdata
+func TestDecompileGolden(t *testing.T) {
+	for _, day := range []string{"day05"} {
+		t.Run(day, func(t *testing.T) {
+			bytes, err := os.ReadFile("../../" + day + "/" + day + ".txt")
+			require.NoError(t, err)
+			memory, err := program.Parse(strings.TrimSuffix(string(bytes), "\n"))
+			require.NoError(t, err)
+
+			expected, err := os.ReadFile("testdata/" + day + ".golden")
+			require.NoError(t, err)
+
+			pseudocode, err := Decompile(memory)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), pseudocode)
+		})
+	}
+}
+
+func TestDecompileEmptyProgram(t *testing.T) {
+	_, err := Decompile(nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,404 @@
+package decompiler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/analysis"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// line is a line of pseudocode, which can be a label that is only kept if something jumps to it
+type line struct {
+	indent int
+	text   string
+	label  string
+}
+
+// emitter structures the blocks of a function into pseudocode
+type emitter struct {
+	fn *function
+
+	lines   []line
+	indent  int
+	emitted map[int]bool
+	// loops is the stack of the loops being emitted, innermost last
+	loops []*loop
+	// targets are the labels that are jumped to
+	targets map[string]bool
+	// locals are the local variables used by the function
+	locals map[int]bool
+}
+
+func (p *decompilation) decompileFunction(f *analysis.Function) string {
+	e := &emitter{
+		fn:      newFunction(p, f),
+		emitted: make(map[int]bool),
+		targets: make(map[string]bool),
+		locals:  make(map[int]bool),
+	}
+
+	e.indent = 1
+	e.sequence(f.Entry, none, false)
+	return e.render()
+}
+
+func (e *emitter) render() string {
+	var sb strings.Builder
+
+	parameters := e.fn.d.parameters[e.fn.entry]
+	var names []string
+	for slot := 1; slot <= parameters; slot++ {
+		names = append(names, fmt.Sprintf("p%d", slot))
+	}
+	signature := ""
+	if len(names) > 0 {
+		signature = strings.Join(names, ", ") + " int"
+	}
+	fmt.Fprintf(&sb, "func %s(%s) {\n", functionName(e.fn.entry), signature)
+
+	var locals []int
+	for slot := range e.locals {
+		locals = append(locals, slot)
+	}
+	sort.Ints(locals)
+	if len(locals) > 0 {
+		var names []string
+		for _, slot := range locals {
+			names = append(names, e.slotName(slot))
+		}
+		fmt.Fprintf(&sb, "\tvar %s int\n", strings.Join(names, ", "))
+	}
+
+	for _, l := range e.lines {
+		if l.label != "" {
+			if e.targets[l.label] {
+				fmt.Fprintf(&sb, "%s:\n", l.label)
+			}
+			continue
+		}
+		fmt.Fprintf(&sb, "%s%s\n", strings.Repeat("\t", l.indent), l.text)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func (e *emitter) emit(format string, args ...interface{}) {
+	e.lines = append(e.lines, line{indent: e.indent, text: fmt.Sprintf(format, args...)})
+}
+
+func (e *emitter) label(name string) {
+	e.lines = append(e.lines, line{label: name})
+}
+
+func (e *emitter) goTo(block int) {
+	name := blockLabel(block)
+	e.targets[name] = true
+	e.emit("goto %s", name)
+}
+
+func blockLabel(block int) string {
+	return fmt.Sprintf("L%d", block)
+}
+
+func loopLabel(l *loop) string {
+	return fmt.Sprintf("loop%d", l.header)
+}
+
+// sequence emits the blocks from block until control reaches follow. If loopStart is true,
+// block is the header of the loop that has just been opened.
+func (e *emitter) sequence(block, follow int, loopStart bool) {
+	for block != none && block != follow {
+		if !loopStart {
+			if e.loopJump(block) {
+				return
+			}
+			if e.emitted[block] {
+				e.goTo(block)
+				return
+			}
+			if l, ok := e.fn.loops[block]; ok {
+				e.loop(l)
+				block = l.follow
+				continue
+			}
+		}
+		loopStart = false
+
+		e.emitted[block] = true
+		e.label(blockLabel(block))
+		block = e.block(block, follow)
+	}
+}
+
+// loopJump emits a continue or a break if block is the header or the follow of an open loop
+func (e *emitter) loopJump(block int) bool {
+	for i := len(e.loops) - 1; i >= 0; i-- {
+		l := e.loops[i]
+
+		statement := ""
+		switch block {
+		case l.header:
+			statement = "continue"
+		case l.follow:
+			statement = "break"
+		default:
+			continue
+		}
+
+		if i < len(e.loops)-1 {
+			e.targets[loopLabel(l)] = true
+			statement += " " + loopLabel(l)
+		}
+		e.emit(statement)
+		return true
+	}
+	return false
+}
+
+func (e *emitter) loop(l *loop) {
+	e.label(loopLabel(l))
+	e.emit("for {")
+	e.indent++
+	e.loops = append(e.loops, l)
+
+	e.sequence(l.header, none, true)
+
+	e.loops = e.loops[:len(e.loops)-1]
+	e.indent--
+	if last := e.lines[len(e.lines)-1]; last.text == "continue" && last.indent == e.indent+1 {
+		e.lines = e.lines[:len(e.lines)-1]
+	}
+	e.emit("}")
+}
+
+// selfModified emits the instruction at address whose opcode is written by the program, which
+// can only be executed as it is stored in memory at runtime
+func (e *emitter) selfModified(address int) {
+	e.emit("// self-modified instruction at %d", address)
+	e.emit("exec(%d)", address)
+}
+
+// invalidAddress returns the address of the instruction that could not be decoded in the invalid block
+func invalidAddress(block *analysis.Block) int {
+	if len(block.Instructions) == 0 {
+		return block.Start
+	}
+	return block.Last().Next()
+}
+
+// block emits the statements of block and returns the block where control goes next
+func (e *emitter) block(start, follow int) int {
+	block := e.fn.d.graph.Blocks[start]
+	startDelta := e.fn.deltas[start]
+	delta := startDelta
+
+	for i, ins := range block.Instructions {
+		if e.fn.d.written[ins.Address] {
+			// the opcode is only known at runtime, and so is where control goes after it
+			e.selfModified(ins.Address)
+			return none
+		}
+		if !e.omitted(block, i) {
+			if isJump(ins) {
+				if i == len(block.Instructions)-1 {
+					break
+				}
+				// the jump is never taken
+				continue
+			}
+			if e.fn.d.modified[ins.Address] {
+				e.emit("// self-modified instruction at %d", ins.Address)
+			}
+			e.emit("%s", e.statement(ins, delta))
+		}
+		delta = deltaAfterInstruction(ins, delta)
+	}
+
+	switch {
+	case block.Halts:
+		return none
+	case block.Returns:
+		e.emit("return")
+		return none
+	case block.Invalid:
+		if address := invalidAddress(block); e.fn.d.written[address] {
+			e.selfModified(address)
+			return none
+		}
+		e.emit("panic(\"invalid instruction\")")
+		return none
+	}
+
+	if callee, ok := calleeOf(block); ok {
+		var arguments []string
+		for slot := 1; slot <= e.fn.d.parameters[callee]; slot++ {
+			arguments = append(arguments, e.operand(instruction.Relative, slot, delta))
+		}
+		e.emit("%s(%s)", functionName(callee), strings.Join(arguments, ", "))
+		for _, edge := range block.Successors {
+			if edge.Kind == analysis.CallReturn {
+				return edge.To
+			}
+		}
+	}
+
+	last := block.Last()
+	if block.Indirect {
+		target := e.operand(last.Modes[1], last.Parameters[1], delta)
+		if len(block.Successors) == 0 {
+			e.emit("jump(%s)", target)
+			return none
+		}
+		e.emit("if %s {", e.condition(block, startDelta))
+		e.emit("\tjump(%s)", target)
+		e.emit("}")
+		return block.Successors[0].To
+	}
+
+	if len(block.Successors) == 1 {
+		return block.Successors[0].To
+	}
+	return e.conditional(start, block, startDelta)
+}
+
+// conditional emits the if statement that ends block and returns the block where both branches join
+func (e *emitter) conditional(start int, block *analysis.Block, delta int) int {
+	var taken, notTaken int
+	for _, edge := range block.Successors {
+		if edge.Kind == analysis.Taken {
+			taken = edge.To
+		} else {
+			notTaken = edge.To
+		}
+	}
+
+	cond := e.condition(block, delta)
+	taken, notTaken = e.skipEmpty(taken), e.skipEmpty(notTaken)
+
+	if len(e.loops) > 0 {
+		// prefer leaving the loop inside the if, so that continuing it is implicit
+		switch l := e.loops[len(e.loops)-1]; {
+		case notTaken == l.follow || (taken != l.follow && notTaken == l.header):
+			e.emit("if %s {", cond.negate())
+			e.branch(notTaken, none)
+			e.emit("}")
+			return taken
+		case taken == l.follow || taken == l.header:
+			e.emit("if %s {", cond)
+			e.branch(taken, none)
+			e.emit("}")
+			return notTaken
+		}
+	}
+
+	join, ok := e.fn.ipdom[start]
+	if !ok {
+		join = none
+	}
+	join = e.skipEmpty(join)
+	if len(e.loops) > 0 {
+		l := e.loops[len(e.loops)-1]
+		if !l.body[join] || join == l.header {
+			join = none
+		}
+	}
+
+	switch {
+	case taken == join:
+		e.emit("if %s {", cond.negate())
+		e.branch(notTaken, join)
+		e.emit("}")
+	case notTaken == join:
+		e.emit("if %s {", cond)
+		e.branch(taken, join)
+		e.emit("}")
+	case cond.operator == "!=" || cond.operator == ">=":
+		// prefer the positive form of the condition when both branches are emitted
+		e.emit("if %s {", cond.negate())
+		e.branch(notTaken, join)
+		e.emit("} else {")
+		e.branch(taken, join)
+		e.emit("}")
+	default:
+		e.emit("if %s {", cond)
+		e.branch(taken, join)
+		e.emit("} else {")
+		e.branch(notTaken, join)
+		e.emit("}")
+	}
+	return join
+}
+
+// skipEmpty returns the first block reachable from block that emits any statement, skipping
+// the blocks that only jump somewhere else
+func (e *emitter) skipEmpty(block int) int {
+	for block != none && !e.emitted[block] {
+		if _, ok := e.fn.loops[block]; ok {
+			return block
+		}
+
+		b := e.fn.d.graph.Blocks[block]
+		if len(b.Successors) != 1 || b.Halts || b.Returns || b.Indirect || b.Invalid {
+			return block
+		}
+		for i, ins := range b.Instructions {
+			if !e.omitted(b, i) && !isJump(ins) {
+				return block
+			}
+		}
+		block = b.Successors[0].To
+	}
+	return block
+}
+
+func (e *emitter) branch(block, join int) {
+	e.indent++
+	e.sequence(block, join, false)
+	e.indent--
+}
+
+// omitted returns true if the instruction i of block is part of an idiom: the prologue and
+// epilogue of a function, the call sequence, or a comparison folded into a condition
+func (e *emitter) omitted(block *analysis.Block, i int) bool {
+	ins := block.Instructions[i]
+	last := len(block.Instructions) - 1
+
+	if ins.Opcode == instruction.AdjustRelativeBaseOpcode && ins.Modes[0] == instruction.Immediate {
+		if !e.fn.isMain() && block.Start == e.fn.entry && i == 0 {
+			return true
+		}
+		if block.Returns && i == last-1 {
+			return true
+		}
+	}
+
+	if isComparison(ins) && ins.Addressing(2) == instruction.Position && e.fn.d.temporaries[ins.Parameters[2]] {
+		return true
+	}
+
+	if _, ok := calleeOf(block); ok && i < last {
+		value, ok := storedConstant(ins)
+		if ok && value == block.Last().Next() && ins.Modes[2] == instruction.Relative {
+			return true
+		}
+	}
+
+	return false
+}
+
+// storedConstant returns the value stored by ins if it is an arithmetic
+// instruction whose operands are both in immediate mode
+func storedConstant(ins analysis.Instruction) (int, bool) {
+	if ins.Opcode != instruction.AddOpcode && ins.Opcode != instruction.MultiplyOpcode {
+		return 0, false
+	}
+	if ins.Modes[0] != instruction.Immediate || ins.Modes[1] != instruction.Immediate {
+		return 0, false
+	}
+	if ins.Opcode == instruction.AddOpcode {
+		return ins.Parameters[0] + ins.Parameters[1], true
+	}
+	return ins.Parameters[0] * ins.Parameters[1], true
+}
@@ -0,0 +1,202 @@
+package decompiler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/analysis"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// condition is a comparison between two expressions
+type condition struct {
+	left, operator, right string
+}
+
+var negatedOperators = map[string]string{
+	"==": "!=",
+	"!=": "==",
+	"<":  ">=",
+	">=": "<",
+}
+
+func (c condition) negate() condition {
+	return condition{left: c.left, operator: negatedOperators[c.operator], right: c.right}
+}
+
+func (c condition) String() string {
+	return fmt.Sprintf("%s %s %s", c.left, c.operator, c.right)
+}
+
+// condition returns the condition under which the jump that ends block is taken
+func (e *emitter) condition(block *analysis.Block, delta int) condition {
+	jump := block.Last()
+	for _, ins := range block.Instructions[:len(block.Instructions)-1] {
+		delta = deltaAfterInstruction(ins, delta)
+	}
+
+	var c condition
+	position := jump.Parameters[0]
+	if jump.Modes[0] == instruction.Position && e.fn.d.temporaries[position] {
+		comparison := block.Instructions[len(block.Instructions)-2]
+		c = condition{
+			left:  e.operand(comparison.Modes[0], comparison.Parameters[0], delta),
+			right: e.operand(comparison.Modes[1], comparison.Parameters[1], delta),
+		}
+		if comparison.Opcode == instruction.LessThanOpcode {
+			c.operator = "<"
+		} else {
+			c.operator = "=="
+		}
+	} else {
+		c = condition{
+			left:     e.operand(jump.Modes[0], position, delta),
+			operator: "!=",
+			right:    "0",
+		}
+	}
+
+	if jump.Opcode == instruction.JumpIfFalseOpcode {
+		return c.negate()
+	}
+	return c
+}
+
+// statement returns the pseudocode of an instruction which is not a jump
+func (e *emitter) statement(ins analysis.Instruction, delta int) string {
+	operands := make([]string, len(ins.Parameters))
+	for i, parameter := range ins.Parameters {
+		operands[i] = e.operand(ins.Addressing(i), parameter, delta)
+
+		// the parameter is modified at runtime, so its value is the one of the global variable
+		if cell := ins.Address + 1 + i; e.fn.d.written[cell] {
+			switch ins.Addressing(i) {
+			case instruction.Immediate:
+				operands[i] = globalName(cell)
+			case instruction.Position:
+				operands[i] = fmt.Sprintf("mem[%s]", globalName(cell))
+			default:
+				operands[i] = fmt.Sprintf("rb[%s]", globalName(cell))
+			}
+		}
+	}
+
+	switch ins.Opcode {
+	case instruction.AddOpcode:
+		return assignment(operands[2], sum(operands[0], operands[1]))
+	case instruction.MultiplyOpcode:
+		return assignment(operands[2], product(operands[0], operands[1]))
+	case instruction.LessThanOpcode:
+		return fmt.Sprintf("%s = %s < %s", operands[2], operands[0], operands[1])
+	case instruction.EqualsOpcode:
+		return fmt.Sprintf("%s = %s == %s", operands[2], operands[0], operands[1])
+	case instruction.InputOpcode:
+		return fmt.Sprintf("%s = input()", operands[0])
+	case instruction.OutputOpcode:
+		return fmt.Sprintf("output(%s)", operands[0])
+	case instruction.AdjustRelativeBaseOpcode:
+		return fmt.Sprintf("rb += %s", operands[0])
+	case instruction.HaltOpcode:
+		return "halt()"
+	default:
+		return fmt.Sprintf("// unknown instruction %s", ins)
+	}
+}
+
+// assignment returns the assignment of expression to variable, using the
+// increment and compound assignment statements when possible
+func assignment(variable, expression string) string {
+	switch {
+	case expression == variable+" + 1":
+		return variable + "++"
+	case expression == variable+" - 1":
+		return variable + "--"
+	case strings.HasPrefix(expression, variable+" + "):
+		return variable + " += " + strings.TrimPrefix(expression, variable+" + ")
+	case strings.HasPrefix(expression, variable+" - "):
+		return variable + " -= " + strings.TrimPrefix(expression, variable+" - ")
+	default:
+		return variable + " = " + expression
+	}
+}
+
+func sum(a, b string) string {
+	switch {
+	case a == "0":
+		return b
+	case b == "0":
+		return a
+	case isNegativeLiteral(b):
+		return a + " - " + b[1:]
+	case isNegativeLiteral(a):
+		return b + " - " + a[1:]
+	default:
+		return a + " + " + b
+	}
+}
+
+func product(a, b string) string {
+	switch {
+	case a == "0" || b == "0":
+		return "0"
+	case a == "1":
+		return b
+	case b == "1":
+		return a
+	case a == "-1":
+		return "-" + b
+	case b == "-1":
+		return "-" + a
+	default:
+		return a + " * " + b
+	}
+}
+
+func isNegativeLiteral(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil && strings.HasPrefix(s, "-")
+}
+
+// operand returns the expression of a parameter with the given mode
+func (e *emitter) operand(mode instruction.Mode, value, delta int) string {
+	switch mode {
+	case instruction.Immediate:
+		return strconv.Itoa(value)
+	case instruction.Relative:
+		if delta == unknown {
+			return fmt.Sprintf("rb[%d]", value)
+		}
+		return e.relative(delta + value)
+	default:
+		return globalName(value)
+	}
+}
+
+// relative returns the name of the cell at slot of the current frame
+func (e *emitter) relative(slot int) string {
+	if e.fn.isMain() {
+		// the relative base starts at 0, so the slots of the main function are absolute positions
+		e.fn.d.globals[slot] = true
+		return globalName(slot)
+	}
+	if slot > e.fn.d.parameters[e.fn.entry] {
+		e.locals[slot] = true
+	}
+	return e.slotName(slot)
+}
+
+// slotName returns the name of a slot of the stack frame of a function, slot 0 holding the
+// return address, followed by the parameters and the local variables
+func (e *emitter) slotName(slot int) string {
+	switch {
+	case slot == 0:
+		return "ret"
+	case slot < 0:
+		return fmt.Sprintf("caller%d", -slot)
+	case slot <= e.fn.d.parameters[e.fn.entry]:
+		return fmt.Sprintf("p%d", slot)
+	default:
+		return fmt.Sprintf("v%d", slot)
+	}
+}
@@ -0,0 +1,308 @@
+package decompiler
+
+import (
+	"math"
+	"sort"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/analysis"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// unknown is the offset of the relative base when it cannot be determined statically
+const unknown = math.MinInt
+
+// exit is a virtual block that follows all the blocks that leave a function
+const exit = -2
+
+// function holds the results of the analyses of a function needed to decompile it
+type function struct {
+	d     *decompilation
+	entry int
+
+	blocks       map[int]bool
+	successors   map[int][]int
+	predecessors map[int][]int
+	// order holds the blocks in reverse postorder from the entry
+	order []int
+
+	// deltas are the offsets of the relative base at the start of each block,
+	// relative to its value at the entry of the function
+	deltas map[int]int
+	// frameSize is the size of the stack frame allocated by the prologue of the function
+	frameSize int
+
+	idom  map[int]int
+	ipdom map[int]int
+	loops map[int]*loop
+}
+
+// loop is a natural loop of a function
+type loop struct {
+	header int
+	body   map[int]bool
+	// follow is the block where control goes once the loop is done, none if unknown
+	follow int
+}
+
+func newFunction(p *decompilation, f *analysis.Function) *function {
+	fn := &function{
+		d:            p,
+		entry:        f.Entry,
+		blocks:       make(map[int]bool),
+		successors:   make(map[int][]int),
+		predecessors: make(map[int][]int),
+		deltas:       make(map[int]int),
+		loops:        make(map[int]*loop),
+	}
+
+	for _, start := range f.Blocks {
+		fn.blocks[start] = true
+	}
+	for _, start := range f.Blocks {
+		for _, edge := range p.graph.Blocks[start].Successors {
+			if edge.Kind == analysis.Call || !fn.blocks[edge.To] {
+				continue
+			}
+			fn.successors[start] = append(fn.successors[start], edge.To)
+			fn.predecessors[edge.To] = append(fn.predecessors[edge.To], start)
+		}
+	}
+
+	fn.order = fn.reversePostorder()
+	fn.computeDeltas()
+	fn.idom = fn.dominators(fn.order, fn.predecessors, fn.entry)
+	fn.ipdom = fn.postDominators()
+	fn.findLoops()
+
+	return fn
+}
+
+func (fn *function) isMain() bool {
+	return fn.entry == fn.d.graph.Entry
+}
+
+func (fn *function) reversePostorder() []int {
+	visited := make(map[int]bool)
+	var postorder []int
+
+	var visit func(block int)
+	visit = func(block int) {
+		visited[block] = true
+		for _, successor := range fn.successors[block] {
+			if !visited[successor] {
+				visit(successor)
+			}
+		}
+		postorder = append(postorder, block)
+	}
+	visit(fn.entry)
+
+	order := make([]int, len(postorder))
+	for i, block := range postorder {
+		order[len(postorder)-1-i] = block
+	}
+	return order
+}
+
+// computeDeltas propagates the offset of the relative base through the blocks of the function.
+// Calls are assumed to restore the relative base, and the offset is unknown whenever it is
+// adjusted by a value which is not immediate or it differs between predecessors.
+func (fn *function) computeDeltas() {
+	entryBlock := fn.d.graph.Blocks[fn.entry]
+	if !fn.isMain() && len(entryBlock.Instructions) > 0 {
+		first := entryBlock.Instructions[0]
+		if first.Opcode == instruction.AdjustRelativeBaseOpcode && first.Modes[0] == instruction.Immediate {
+			fn.frameSize = first.Parameters[0]
+		}
+	}
+
+	fn.deltas[fn.entry] = 0
+	for _, start := range fn.order {
+		delta := fn.deltaAfter(start)
+		for _, successor := range fn.successors[start] {
+			previous, ok := fn.deltas[successor]
+			if !ok {
+				fn.deltas[successor] = delta
+			} else if previous != delta {
+				fn.deltas[successor] = unknown
+			}
+		}
+	}
+}
+
+// deltaAfter returns the offset of the relative base after executing block
+func (fn *function) deltaAfter(start int) int {
+	delta := fn.deltas[start]
+	for _, ins := range fn.d.graph.Blocks[start].Instructions {
+		delta = deltaAfterInstruction(ins, delta)
+	}
+	return delta
+}
+
+func deltaAfterInstruction(ins analysis.Instruction, delta int) int {
+	if ins.Opcode != instruction.AdjustRelativeBaseOpcode || delta == unknown {
+		return delta
+	}
+	if ins.Modes[0] != instruction.Immediate {
+		return unknown
+	}
+	return delta + ins.Parameters[0]
+}
+
+// dominators computes the immediate dominator of each block with the iterative
+// algorithm of Cooper, Harvey and Kennedy
+func (fn *function) dominators(order []int, predecessors map[int][]int, root int) map[int]int {
+	index := make(map[int]int, len(order))
+	for i, block := range order {
+		index[block] = i
+	}
+
+	idom := map[int]int{root: root}
+	intersect := func(a, b int) int {
+		for a != b {
+			for index[a] > index[b] {
+				a = idom[a]
+			}
+			for index[b] > index[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, block := range order[1:] {
+			newIdom := none
+			for _, predecessor := range predecessors[block] {
+				if _, ok := idom[predecessor]; !ok {
+					continue
+				}
+				if newIdom == none {
+					newIdom = predecessor
+				} else {
+					newIdom = intersect(predecessor, newIdom)
+				}
+			}
+			if current, ok := idom[block]; newIdom != none && (!ok || current != newIdom) {
+				idom[block] = newIdom
+				changed = true
+			}
+		}
+	}
+
+	delete(idom, root)
+	return idom
+}
+
+// postDominators computes the immediate post dominator of each block, which are
+// the dominators of the reversed graph rooted at the virtual exit block
+func (fn *function) postDominators() map[int]int {
+	successors := make(map[int][]int)
+	predecessors := make(map[int][]int)
+	for _, block := range fn.order {
+		if len(fn.successors[block]) == 0 {
+			successors[exit] = append(successors[exit], block)
+			predecessors[block] = append(predecessors[block], exit)
+		}
+		for _, successor := range fn.successors[block] {
+			successors[successor] = append(successors[successor], block)
+			predecessors[block] = append(predecessors[block], successor)
+		}
+	}
+
+	visited := make(map[int]bool)
+	var postorder []int
+	var visit func(block int)
+	visit = func(block int) {
+		visited[block] = true
+		for _, successor := range successors[block] {
+			if !visited[successor] {
+				visit(successor)
+			}
+		}
+		postorder = append(postorder, block)
+	}
+	visit(exit)
+
+	order := make([]int, len(postorder))
+	for i, block := range postorder {
+		order[len(postorder)-1-i] = block
+	}
+
+	ipdom := fn.dominators(order, predecessors, exit)
+	for block, dominator := range ipdom {
+		if dominator == exit {
+			ipdom[block] = none
+		}
+	}
+	return ipdom
+}
+
+// dominates returns true if a dominates b
+func (fn *function) dominates(a, b int) bool {
+	for {
+		if a == b {
+			return true
+		}
+		dominator, ok := fn.idom[b]
+		if !ok {
+			return false
+		}
+		b = dominator
+	}
+}
+
+// findLoops finds the natural loops of the function, merging the loops with the same header
+func (fn *function) findLoops() {
+	for _, block := range fn.order {
+		for _, successor := range fn.successors[block] {
+			if !fn.dominates(successor, block) {
+				continue
+			}
+
+			l, ok := fn.loops[successor]
+			if !ok {
+				l = &loop{header: successor, body: map[int]bool{successor: true}}
+				fn.loops[successor] = l
+			}
+
+			stack := []int{block}
+			for len(stack) > 0 {
+				current := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if l.body[current] {
+					continue
+				}
+				l.body[current] = true
+				stack = append(stack, fn.predecessors[current]...)
+			}
+		}
+	}
+
+	for _, l := range fn.loops {
+		l.follow = fn.loopFollow(l)
+	}
+}
+
+// loopFollow returns the immediate post dominator of the loop header if it's outside the
+// loop, and otherwise the first block outside the loop reachable from its body
+func (fn *function) loopFollow(l *loop) int {
+	if follow, ok := fn.ipdom[l.header]; ok && follow != none && !l.body[follow] {
+		return follow
+	}
+
+	var exits []int
+	for block := range l.body {
+		for _, successor := range fn.successors[block] {
+			if !l.body[successor] {
+				exits = append(exits, successor)
+			}
+		}
+	}
+	if len(exits) == 0 {
+		return none
+	}
+	sort.Ints(exits)
+	return exits[0]
+}
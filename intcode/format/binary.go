@@ -0,0 +1,126 @@
+package format
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// magic is the magic number that starts programs in the binary format
+var magic = []byte("\x00ICB")
+
+// version is the version of the binary format
+const version = 1
+
+// maxPreallocated bounds the cells allocated before reading them, so that a corrupted
+// header cannot make ReadBinary allocate an arbitrary amount of memory
+const maxPreallocated = 1 << 16
+
+// WriteBinary writes the program in the binary format: the magic number and the version,
+// the number of cells and each of them as a varint, and then the number of symbols
+// and each of them as its name length, its name and its position
+func WriteBinary(w io.Writer, p *Program) error {
+	bw := bufio.NewWriter(w)
+	buffer := make([]byte, binary.MaxVarintLen64)
+
+	bw.Write(magic)
+	bw.WriteByte(version)
+
+	bw.Write(buffer[:binary.PutUvarint(buffer, uint64(len(p.Memory)))])
+	for _, value := range p.Memory {
+		bw.Write(buffer[:binary.PutVarint(buffer, int64(value))])
+	}
+
+	names := make([]string, 0, len(p.Symbols))
+	for name := range p.Symbols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw.Write(buffer[:binary.PutUvarint(buffer, uint64(len(names)))])
+	for _, name := range names {
+		bw.Write(buffer[:binary.PutUvarint(buffer, uint64(len(name)))])
+		bw.WriteString(name)
+		bw.Write(buffer[:binary.PutVarint(buffer, int64(p.Symbols[name]))])
+	}
+
+	return bw.Flush()
+}
+
+// ReadBinary reads a program written by WriteBinary
+func ReadBinary(r io.Reader) (*Program, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("could not read header: %w", truncated(err))
+	}
+	if string(header[:len(magic)]) != string(magic) {
+		return nil, errors.New("invalid magic number")
+	}
+	if header[len(magic)] != version {
+		return nil, fmt.Errorf("unsupported version %d", header[len(magic)])
+	}
+
+	cells, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("could not read number of cells: %w", truncated(err))
+	}
+
+	p := &Program{
+		Memory:  make([]int, 0, minUint(cells, maxPreallocated)),
+		Symbols: make(map[string]int),
+	}
+	for i := uint64(0); i < cells; i++ {
+		value, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("could not read cell %d: %w", i, truncated(err))
+		}
+		p.Memory = append(p.Memory, int(value))
+	}
+
+	symbols, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("could not read number of symbols: %w", truncated(err))
+	}
+	for i := uint64(0); i < symbols; i++ {
+		length, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("could not read symbol %d: %w", i, truncated(err))
+		}
+
+		if length > maxPreallocated {
+			return nil, fmt.Errorf("symbol %d is too long: %d bytes", i, length)
+		}
+		name := make([]byte, length)
+		if _, err := io.ReadFull(br, name); err != nil {
+			return nil, fmt.Errorf("could not read symbol %d: %w", i, truncated(err))
+		}
+
+		position, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("could not read position of symbol %s: %w", name, truncated(err))
+		}
+		p.Symbols[string(name)] = int(position)
+	}
+
+	return p, nil
+}
+
+// truncated reports an unexpected end of file for an error that happens in the middle of a program
+func truncated(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func minUint(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
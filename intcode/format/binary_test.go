@@ -0,0 +1,99 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndReadBinary(t *testing.T) {
+	p := &Program{
+		Memory:  []int{1101, -1, 1 << 40, 0, 99},
+		Symbols: map[string]int{"main": 0, "end": 5},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, WriteBinary(&b, p))
+	assert.Equal(t, []byte("\x00ICB\x01\x05"), b.Bytes()[:6])
+
+	actual, err := ReadBinary(&b)
+	require.NoError(t, err)
+	assert.Equal(t, p, actual)
+}
+
+func TestBinaryIsCompact(t *testing.T) {
+	p := &Program{Memory: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}}
+
+	var b bytes.Buffer
+	require.NoError(t, WriteBinary(&b, p))
+	// header, number of cells, one byte per small cell and the number of symbols
+	assert.Equal(t, 5+1+10+1, b.Len())
+}
+
+func TestReadBinaryInvalid(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteBinary(&b, &Program{Memory: []int{1, 2, 3}, Symbols: map[string]int{"a": 1}}))
+	valid := b.Bytes()
+
+	testCases := map[string]struct {
+		data []byte
+		err  string
+	}{
+		"empty": {
+			data: nil,
+			err:  "could not read header: unexpected EOF",
+		},
+		"invalid magic": {
+			data: []byte("1,2,3"),
+			err:  "invalid magic number",
+		},
+		"unsupported version": {
+			data: []byte("\x00ICB\x02"),
+			err:  "unsupported version 2",
+		},
+		"truncated cells": {
+			data: valid[:7],
+			err:  "could not read cell 1: unexpected EOF",
+		},
+		"truncated symbols": {
+			data: valid[:len(valid)-1],
+			err:  "could not read position of symbol a: unexpected EOF",
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadBinary(bytes.NewReader(test.data))
+			assert.EqualError(t, err, test.err)
+		})
+	}
+
+	_, err := ReadBinary(bytes.NewReader(valid[:7]))
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+// FuzzReadBinary checks that reading arbitrary data does not panic and that the programs
+// which are read are written back to the same program
+func FuzzReadBinary(f *testing.F) {
+	var b bytes.Buffer
+	require.NoError(f, WriteBinary(&b, &Program{Memory: []int{1101, -1, 1 << 40, 0, 99}, Symbols: map[string]int{"main": 0}}))
+	f.Add(b.Bytes())
+	f.Add([]byte("\x00ICB\x01"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p, err := ReadBinary(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+
+		var b bytes.Buffer
+		require.NoError(t, WriteBinary(&b, p))
+		actual, err := ReadBinary(&b)
+		require.NoError(t, err)
+		assert.Equal(t, p, actual)
+	})
+}
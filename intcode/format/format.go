@@ -0,0 +1,43 @@
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Program is a program loaded from a file
+type Program struct {
+	// Memory holds the initial values of the memory of the program
+	Memory []int
+	// Symbols are the names given to memory positions
+	Symbols map[string]int
+}
+
+// Read reads a program in the binary format if it starts with its magic number,
+// and in the text format otherwise
+func Read(r io.Reader) (*Program, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(len(magic))
+	if err == nil && bytes.Equal(header, magic) {
+		return ReadBinary(br)
+	}
+	return ReadText(br)
+}
+
+// Load reads the program stored in filename, in either the binary or the text format
+func Load(filename string) (*Program, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not open file %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	p, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not load program %s: %w", filename, err)
+	}
+	return p, nil
+}
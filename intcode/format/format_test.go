@@ -0,0 +1,54 @@
+package format
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+func TestRead(t *testing.T) {
+	p := &Program{Memory: []int{3, 0, 4, 0, 99}, Symbols: map[string]int{"value": 0}}
+
+	var text, binary bytes.Buffer
+	require.NoError(t, WriteText(&text, p))
+	require.NoError(t, WriteBinary(&binary, p))
+
+	fromText, err := Read(&text)
+	require.NoError(t, err)
+	assert.Equal(t, p, fromText)
+
+	fromBinary, err := Read(&binary)
+	require.NoError(t, err)
+	assert.Equal(t, p, fromBinary)
+}
+
+func TestLoad(t *testing.T) {
+	bytes, err := os.ReadFile("../../day09/day09.txt")
+	require.NoError(t, err)
+	expected, err := program.Parse(strings.TrimSuffix(string(bytes), "\n"))
+	require.NoError(t, err)
+
+	p, err := Load("../../day09/day09.txt")
+	require.NoError(t, err)
+	assert.Equal(t, expected, p.Memory)
+
+	filename := filepath.Join(t.TempDir(), "day09.icb")
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+	require.NoError(t, WriteBinary(f, p))
+	require.NoError(t, f.Close())
+
+	fromBinary, err := Load(filename)
+	require.NoError(t, err)
+	assert.Equal(t, expected, fromBinary.Memory)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,141 @@
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// valuesPerLine is the number of memory values per line written by WriteText
+const valuesPerLine = 16
+
+// symbolPattern is the pattern of the names of symbols
+var symbolPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ReadText reads a program in the text format, a tolerant version of the comma separated
+// format of the puzzle inputs: values may be separated by commas, whitespace and newlines,
+// text following a # is a comment, and a value preceded by a symbol such as "score: 0"
+// gives that name to its position.
+func ReadText(r io.Reader) (*Program, error) {
+	p := &Program{Symbols: make(map[string]int)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	for line := 1; scanner.Scan(); line++ {
+		err := p.readLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *Program) readLine(line string) error {
+	if comment := strings.Index(line, "#"); comment >= 0 {
+		line = line[:comment]
+	}
+
+	fields := strings.Split(line, ",")
+	for i, field := range fields {
+		tokens := strings.Fields(strings.ReplaceAll(field, ":", ": "))
+		if len(tokens) == 0 && i > 0 && i < len(fields)-1 {
+			return fmt.Errorf("missing value between commas")
+		}
+
+		for _, token := range tokens {
+			if name := strings.TrimSuffix(token, ":"); name != token {
+				if err := p.addSymbol(name); err != nil {
+					return err
+				}
+				continue
+			}
+
+			value, err := strconv.Atoi(token)
+			if err != nil {
+				return fmt.Errorf("invalid value %s", token)
+			}
+			p.Memory = append(p.Memory, value)
+		}
+	}
+	return nil
+}
+
+// addSymbol gives name to the position of the next value
+func (p *Program) addSymbol(name string) error {
+	if !symbolPattern.MatchString(name) {
+		return fmt.Errorf("invalid symbol %q", name)
+	}
+	if position, ok := p.Symbols[name]; ok {
+		return fmt.Errorf("symbol %s is already defined at position %d", name, position)
+	}
+	p.Symbols[name] = len(p.Memory)
+	return nil
+}
+
+// WriteText writes the program in the text format, with a fixed number of values per line
+// and each symbol starting the line of the value it names
+func WriteText(w io.Writer, p *Program) error {
+	names := make(map[int][]string)
+	for name, position := range p.Symbols {
+		if position < 0 || position > len(p.Memory) {
+			return fmt.Errorf("symbol %s names position %d, which is out of the program", name, position)
+		}
+		names[position] = append(names[position], name)
+	}
+	for _, n := range names {
+		sort.Strings(n)
+	}
+
+	bw := bufio.NewWriter(w)
+	column := 0
+	for position := 0; position <= len(p.Memory); position++ {
+		labels, labeled := names[position]
+		if labeled && column > 0 {
+			bw.WriteString("\n")
+			column = 0
+		}
+		if position == len(p.Memory) {
+			break
+		}
+
+		if labeled {
+			bw.WriteString(strings.Join(labels, ": ") + ": ")
+		} else if column > 0 {
+			bw.WriteString(", ")
+		}
+		bw.WriteString(strconv.Itoa(p.Memory[position]))
+
+		column++
+		if column == valuesPerLine {
+			bw.WriteString("\n")
+			column = 0
+		}
+	}
+	if column > 0 {
+		bw.WriteString("\n")
+	}
+
+	// symbols may name the position right after the last value
+	if labels, ok := names[len(p.Memory)]; ok {
+		bw.WriteString(strings.Join(labels, ": ") + ":\n")
+	}
+	return bw.Flush()
+}
+
+// WritePlain writes memory in the comma separated format of the puzzle inputs
+func WritePlain(w io.Writer, memory []int) error {
+	values := make([]string, len(memory))
+	for i, value := range memory {
+		values[i] = strconv.Itoa(value)
+	}
+	_, err := fmt.Fprintln(w, strings.Join(values, ","))
+	return err
+}
@@ -0,0 +1,133 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadText(t *testing.T) {
+	testCases := map[string]struct {
+		text     string
+		expected *Program
+	}{
+		"puzzle input": {
+			text:     "1,0,0,3,99\n",
+			expected: &Program{Memory: []int{1, 0, 0, 3, 99}, Symbols: map[string]int{}},
+		},
+		"whitespace and comments": {
+			text: "# adds two numbers\n" +
+				"1101, 2, 3, 5 # stores 5\n" +
+				"  99,\n" +
+				"\n" +
+				"-1 0\t4\n",
+			expected: &Program{Memory: []int{1101, 2, 3, 5, 99, -1, 0, 4}, Symbols: map[string]int{}},
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			p, err := ReadText(strings.NewReader(test.text))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, p)
+		})
+	}
+}
+
+func TestReadTextSymbols(t *testing.T) {
+	p, err := ReadText(strings.NewReader("main: 3, 5, 4, 5, 99\nresult:0\nend:\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{3, 5, 4, 5, 99, 0}, p.Memory)
+	assert.Equal(t, map[string]int{"main": 0, "result": 5, "end": 6}, p.Symbols)
+}
+
+func TestReadTextInvalid(t *testing.T) {
+	testCases := map[string]struct {
+		text string
+		err  string
+	}{
+		"invalid value": {
+			text: "1,2\n3,x",
+			err:  "line 2: invalid value x",
+		},
+		"missing value": {
+			text: "1,,2",
+			err:  "line 1: missing value between commas",
+		},
+		"invalid symbol": {
+			text: "1, 2a: 2",
+			err:  `line 1: invalid symbol "2a"`,
+		},
+		"duplicate symbol": {
+			text: "a: 1\na: 2",
+			err:  "line 2: symbol a is already defined at position 0",
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadText(strings.NewReader(test.text))
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	memory := make([]int, 20)
+	for i := range memory {
+		memory[i] = i
+	}
+	p := &Program{
+		Memory:  memory,
+		Symbols: map[string]int{"start": 0, "begin": 0, "middle": 18, "end": 20},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, WriteText(&b, p))
+
+	expected := "" +
+		"begin: start: 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15\n" +
+		"16, 17\n" +
+		"middle: 18, 19\n" +
+		"end:\n"
+	assert.Equal(t, expected, b.String())
+
+	actual, err := ReadText(&b)
+	require.NoError(t, err)
+	assert.Equal(t, p, actual)
+}
+
+func TestWritePlain(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WritePlain(&b, []int{1, -2, 99}))
+	assert.Equal(t, "1,-2,99\n", b.String())
+}
+
+func TestWriteTextSymbolOutOfProgram(t *testing.T) {
+	p := &Program{Memory: []int{99}, Symbols: map[string]int{"far": 5}}
+	assert.EqualError(t, WriteText(&bytes.Buffer{}, p), "symbol far names position 5, which is out of the program")
+}
+
+// FuzzReadText checks that reading arbitrary text does not panic and that the programs
+// which are read are written back to the same program
+func FuzzReadText(f *testing.F) {
+	f.Add("1,0,0,3,99\n")
+	f.Add("# adds two numbers\nmain: 1101, 2, 3, 5 # stores 5\n99\nend:\n")
+
+	f.Fuzz(func(t *testing.T, text string) {
+		p, err := ReadText(strings.NewReader(text))
+		if err != nil {
+			return
+		}
+
+		var b bytes.Buffer
+		require.NoError(t, WriteText(&b, p))
+		actual, err := ReadText(&b)
+		require.NoError(t, err)
+		assert.Equal(t, p, actual)
+	})
+}
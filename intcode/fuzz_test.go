@@ -0,0 +1,196 @@
+// The fuzz tests are in an external package since the optimizer depends on package intcode
+package intcode_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/optimizer"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+// fuzzBudget is the maximum number of instructions executed by a fuzzed program
+const fuzzBudget = 10000
+
+// fuzzJournalCapacity is the capacity of the journal used to rewind fuzzed programs
+const fuzzJournalCapacity = 1024
+
+// intcodeDays are the days whose puzzle input is an Intcode program
+var intcodeDays = []string{"day02", "day05", "day07", "day09", "day11", "day13", "day15", "day17"}
+
+// addSeedCorpus adds the puzzle inputs and a few small programs to the seed corpus of f
+func addSeedCorpus(f *testing.F) {
+	for _, day := range intcodeDays {
+		content, err := os.ReadFile(filepath.Join("..", day, day+".txt"))
+		require.NoError(f, err)
+		f.Add(strings.TrimSuffix(string(content), "\n"), []byte{1, 5, 0, 2})
+	}
+
+	f.Add("1,9,10,3,2,3,11,0,99,30,40,50", []byte(nil))
+	f.Add("3,9,8,9,10,9,4,9,99,-1,8", []byte{8})
+	f.Add("109,1,204,-1,1001,100,1,100,1008,100,16,101,1006,101,0,99", []byte(nil))
+	f.Add("1105,1,-1", []byte(nil))
+	f.Add("109,-5,203,0,99", []byte{7})
+	f.Add("1101,2,3,11,1002,11,2,12,4,12,99,0,0", []byte(nil))
+	f.Add("3,13,1001,13,-1,13,4,13,1005,13,2,99,0,0", []byte{3})
+}
+
+// trace is everything observable from the execution of a program
+type trace struct {
+	outputs            []int
+	stores             [][2]int
+	instructionPointer int
+	relativeBase       int
+	halted             bool
+	failed             bool
+}
+
+// storeTracer records the stores of a program
+type storeTracer struct {
+	intcode.NopObserver
+	stores [][2]int
+}
+
+func (s *storeTracer) OnStore(position, previous, value int) {
+	s.stores = append(s.stores, [2]int{position, value})
+}
+
+// runWithBudget runs memory for at most fuzzBudget instructions, checking the invariants
+// of the machine after each of them, and returns its trace
+func runWithBudget(t *testing.T, memory []int, inputs []byte, options ...intcode.Option) trace {
+	var tr trace
+	read := 0
+	onInput := func() int {
+		if len(inputs) == 0 {
+			return 0
+		}
+		input := int(int8(inputs[read%len(inputs)]))
+		read++
+		return input
+	}
+	onOutput := func(output int) {
+		tr.outputs = append(tr.outputs, output)
+	}
+
+	tracer := &storeTracer{}
+	options = append(options, intcode.WithObserver(tracer))
+	i := intcode.NewIntcodeFromState(memory, 0, 0, onInput, onOutput, options...)
+
+	for steps := 0; steps < fuzzBudget && !i.Halted(); steps++ {
+		if err := i.Step(); err != nil {
+			tr.failed = true
+			break
+		}
+		checkInvariants(t, i)
+	}
+
+	tr.stores = tracer.stores
+	tr.instructionPointer = i.InstructionPointer()
+	tr.relativeBase = i.RelativeBase()
+	tr.halted = i.Halted()
+	return tr
+}
+
+// checkInvariants checks the invariants that hold after an instruction executes successfully
+func checkInvariants(t *testing.T, i *intcode.Intcode) {
+	ip := i.InstructionPointer()
+	require.GreaterOrEqual(t, ip, 0, "instruction pointer must not be negative")
+
+	if i.Halted() {
+		n, err := i.Peek(ip)
+		require.NoError(t, err)
+		require.Equal(t, instruction.HaltOpcode, n%100, "halted program must point to a halt")
+	}
+}
+
+// FuzzIntcode runs random programs checking that they do not panic and that they keep the
+// invariants of the machine. The interpreter is checked against the optimized program, which is
+// a different program with the same behaviour, and the journal and the instruction set backend
+// are checked not to change the behaviour. It also checks that the journal rewinds the program
+// to its initial state.
+func FuzzIntcode(f *testing.F) {
+	addSeedCorpus(f)
+
+	f.Fuzz(func(t *testing.T, programString string, inputs []byte) {
+		memory, err := program.Parse(programString)
+		if err != nil {
+			return
+		}
+
+		interpreted := runWithBudget(t, memory, inputs)
+		checkOptimized(t, memory, inputs, interpreted)
+
+		withSet := runWithBudget(t, memory, inputs, intcode.WithInstructionSet(instruction.NewSet()))
+		assert.Equal(t, interpreted, withSet, "instruction set backend must behave as the interpreter")
+
+		journaled := runWithBudget(t, memory, inputs, intcode.WithJournal(fuzzJournalCapacity))
+		assert.Equal(t, interpreted, journaled, "journal must not change the behaviour")
+
+		checkRewind(t, memory)
+	})
+}
+
+// checkOptimized checks that the optimized program, if memory can be optimized, produces the same
+// outputs as the interpreted one. It skips the removed instructions, so it gets at least as far
+// within the budget and the outputs of a program that does not complete must be a prefix of its own.
+func checkOptimized(t *testing.T, memory []int, inputs []byte, interpreted trace) {
+	result, err := optimizer.Optimize(memory)
+	if err != nil {
+		return
+	}
+
+	optimized := runWithBudget(t, result.Memory, inputs)
+	if interpreted.halted || interpreted.failed {
+		assert.Equal(t, interpreted.outputs, optimized.outputs, "optimized program must behave as the interpreter")
+		assert.Equal(t, interpreted.halted, optimized.halted, "optimized program must halt as the interpreter")
+		assert.Equal(t, interpreted.failed, optimized.failed, "optimized program must fail as the interpreter")
+		return
+	}
+
+	require.GreaterOrEqual(t, len(optimized.outputs), len(interpreted.outputs), "optimized program must not be slower")
+	assert.Equal(t, interpreted.outputs, optimized.outputs[:len(interpreted.outputs)],
+		"optimized program must behave as the interpreter")
+}
+
+// checkRewind runs memory up to the capacity of a journal and checks that rewinding it
+// restores the initial state
+func checkRewind(t *testing.T, memory []int) {
+	read := 0
+	onInput := func() int {
+		read++
+		return read
+	}
+	tracer := &storeTracer{}
+	i := intcode.NewIntcodeFromState(
+		memory, 0, 0, onInput, func(int) {}, intcode.WithJournal(fuzzJournalCapacity), intcode.WithObserver(tracer),
+	)
+
+	start := i.Checkpoint()
+	for steps := 0; steps < fuzzJournalCapacity && !i.Halted(); steps++ {
+		if err := i.Step(); err != nil {
+			break
+		}
+	}
+
+	require.NoError(t, i.Rewind(start))
+	assert.Equal(t, 0, i.InstructionPointer())
+	assert.Equal(t, 0, i.RelativeBase())
+	assert.False(t, i.Halted())
+	for _, store := range tracer.stores {
+		position := store[0]
+		expected := 0
+		if position < len(memory) {
+			expected = memory[position]
+		}
+		n, err := i.Peek(position)
+		require.NoError(t, err)
+		require.Equal(t, expected, n, "rewound memory differs at position %d", position)
+	}
+}
@@ -0,0 +1,109 @@
+package intcode
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+// Image is a parsed Intcode program which is never modified, so that fresh machines can be
+// created from it or reset to it without parsing the program again
+type Image struct {
+	memory  []int
+	options []Option
+}
+
+// NewImage parses programString into an image whose machines are configured with options
+func NewImage(programString string, options ...Option) (*Image, error) {
+	memory, err := program.Parse(programString)
+	if err != nil {
+		return nil, fmt.Errorf("error creating program: %w", err)
+	}
+
+	return &Image{
+		memory:  memory,
+		options: options,
+	}, nil
+}
+
+// NewImageFromMemory returns an image holding a copy of memory whose machines are configured with options
+func NewImageFromMemory(memory []int, options ...Option) *Image {
+	return &Image{
+		memory:  append([]int(nil), memory...),
+		options: options,
+	}
+}
+
+// Memory returns a copy of the memory of the image
+func (im *Image) Memory() []int {
+	return append([]int(nil), im.memory...)
+}
+
+// WithMemory returns an image holding a copy of memory whose machines are configured with
+// the same options as the ones of im
+func (im *Image) WithMemory(memory []int) *Image {
+	return NewImageFromMemory(memory, im.options...)
+}
+
+// New returns a machine which runs the image from its start and calls onInput and onOutput,
+// configured with the options of the image followed by options
+func (im *Image) New(onInput func() int, onOutput func(output int), options ...Option) *Intcode {
+	all := append(append([]Option(nil), im.options...), options...)
+	return NewIntcodeFromState(im.memory, 0, 0, onInput, onOutput, all...)
+}
+
+// Reset resets the machine i to the start of the image, keeping its callbacks, options and
+// observers. Its journal is emptied, so the reset cannot be undone, and its metrics are zeroed. It fails with
+// ErrWaitingForIO while the machine waits in onInput or onOutput.
+func (im *Image) Reset(i *Intcode) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.waitingForIO {
+		return ErrWaitingForIO
+	}
+	i.shouldStop.Store(false)
+	i.program.Reset(im.memory)
+	if i.journal != nil {
+		i.journal.clear()
+	}
+	if i.metrics != nil {
+		i.metrics.reset(len(im.memory))
+	}
+	return nil
+}
+
+// Pool holds machines of an image which are reset and reused instead of being created again.
+// It can be used concurrently, each machine being used by one goroutine at a time.
+type Pool struct {
+	image    *Image
+	machines sync.Pool
+}
+
+// NewPool returns an empty pool of machines of image
+func NewPool(image *Image) *Pool {
+	return &Pool{image: image}
+}
+
+// Get returns a machine which runs the image from its start and calls onInput and onOutput,
+// reusing a machine put back into the pool if there is any
+func (p *Pool) Get(onInput func() int, onOutput func(output int)) *Intcode {
+	i, ok := p.machines.Get().(*Intcode)
+	if !ok {
+		return p.image.New(onInput, onOutput)
+	}
+
+	// a machine put back while it was still running cannot be reused
+	if err := p.image.Reset(i); err != nil {
+		return p.image.New(onInput, onOutput)
+	}
+	i.setIO(onInput, onOutput)
+	return i
+}
+
+// Put puts the machine i back into the pool once it is no longer used. It must have been
+// returned by Get.
+func (p *Pool) Put(i *Intcode) {
+	p.machines.Put(i)
+}
@@ -0,0 +1,193 @@
+package intcode
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// doubleProgram outputs twice its input and stores it past its end
+const doubleProgram = "3,9,102,2,9,20,4,20,99,0"
+
+func TestNewImage(t *testing.T) {
+	image, err := NewImage("1,0,0,0,99")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 0, 0, 0, 99}, image.Memory())
+
+	_, err = NewImage("1,0,a")
+	assert.Error(t, err)
+}
+
+func TestNewImageFromMemory(t *testing.T) {
+	memory := []int{1, 0, 0, 0, 99}
+	image := NewImageFromMemory(memory)
+	memory[0] = 2
+
+	assert.Equal(t, []int{1, 0, 0, 0, 99}, image.Memory(), "the image must not share memory")
+}
+
+func TestImageNew(t *testing.T) {
+	image, err := NewImage("1,0,0,0,99")
+	require.NoError(t, err)
+
+	first := image.New(MustNotInput, MustNotOutput)
+	require.NoError(t, first.Run())
+	second := image.New(MustNotInput, MustNotOutput)
+
+	assert.Equal(t, []int{2, 0, 0, 0, 99}, first.Memory())
+	assert.Equal(t, []int{1, 0, 0, 0, 99}, second.Memory())
+	assert.Equal(t, []int{1, 0, 0, 0, 99}, image.Memory())
+}
+
+func TestImageNewOptions(t *testing.T) {
+	image, err := NewImage("1102,"+strconv.Itoa(math.MaxInt)+",2,0,99", WithOverflowDetection())
+	require.NoError(t, err)
+
+	var overflowError *instruction.OverflowError
+	err = image.New(MustNotInput, MustNotOutput).Run()
+	assert.True(t, errors.As(err, &overflowError))
+}
+
+func TestImageWithMemory(t *testing.T) {
+	image, err := NewImage("1,0,0,0,99", WithOverflowDetection())
+	require.NoError(t, err)
+
+	memory := []int{1102, math.MaxInt, 2, 0, 99}
+	patched := image.WithMemory(memory)
+	memory[0] = 99
+	assert.Equal(t, []int{1102, math.MaxInt, 2, 0, 99}, patched.Memory())
+	assert.Equal(t, []int{1, 0, 0, 0, 99}, image.Memory())
+
+	var overflowError *instruction.OverflowError
+	err = patched.New(MustNotInput, MustNotOutput).Run()
+	assert.True(t, errors.As(err, &overflowError))
+}
+
+func TestImageReset(t *testing.T) {
+	image, err := NewImage(doubleProgram)
+	require.NoError(t, err)
+
+	var outputs []int
+	onOutput := func(output int) {
+		outputs = append(outputs, output)
+	}
+	observer := &instructionRecorder{}
+	program := image.New(func() int { return len(outputs) + 1 }, onOutput, WithObserver(observer))
+
+	require.NoError(t, program.Run())
+	assert.True(t, program.Halted())
+	assert.Len(t, program.Memory(), 21)
+
+	require.NoError(t, image.Reset(program))
+	assert.False(t, program.Halted())
+	assert.Equal(t, 0, program.InstructionPointer())
+	assert.Equal(t, image.Memory(), program.Memory())
+
+	require.NoError(t, program.Run())
+	assert.Equal(t, []int{2, 4}, outputs)
+	assert.Len(t, observer.events, 8)
+}
+
+func TestImageResetJournal(t *testing.T) {
+	image, err := NewImage(doubleProgram)
+	require.NoError(t, err)
+
+	program := image.New(func() int { return 1 }, func(int) {}, WithJournal(10))
+	require.NoError(t, program.Run())
+
+	require.NoError(t, image.Reset(program))
+	assert.Equal(t, Checkpoint(0), program.Checkpoint())
+	assert.Equal(t, ErrJournalExhausted, program.StepBack())
+}
+
+func TestImageResetStopped(t *testing.T) {
+	image, err := NewImage("1105,1,0")
+	require.NoError(t, err)
+
+	program := image.New(MustNotInput, MustNotOutput)
+	program.Stop()
+	require.NoError(t, program.Run())
+
+	require.NoError(t, image.Reset(program))
+	require.NoError(t, program.Step())
+	assert.False(t, program.shouldStop.Load())
+}
+
+func TestImageResetMetrics(t *testing.T) {
+	image, err := NewImage(doubleProgram)
+	require.NoError(t, err)
+
+	program := image.New(func() int { return 1 }, func(int) {}, WithMetrics())
+	require.NoError(t, program.Run())
+	metrics, err := program.Metrics()
+	require.NoError(t, err)
+	assert.Equal(t, 4, metrics.Instructions)
+
+	require.NoError(t, image.Reset(program))
+	metrics, err = program.Metrics()
+	require.NoError(t, err)
+	assert.Equal(t, Metrics{MemoryHighWater: len(image.Memory())}, metrics)
+
+	require.NoError(t, program.Run())
+	metrics, err = program.Metrics()
+	require.NoError(t, err)
+	assert.Equal(t, 4, metrics.Instructions)
+	assert.Equal(t, 1, metrics.Inputs)
+	assert.Equal(t, 1, metrics.Outputs)
+}
+
+func TestPool(t *testing.T) {
+	image, err := NewImage(doubleProgram)
+	require.NoError(t, err)
+	pool := NewPool(image)
+
+	const goroutines, runs = 8, 50
+	outputs := make([][]int, goroutines)
+
+	var group sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		g := g
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for run := 0; run < runs; run++ {
+				program := pool.Get(func() int { return run }, func(output int) {
+					outputs[g] = append(outputs[g], output)
+				})
+				assert.NoError(t, program.Run())
+				pool.Put(program)
+			}
+		}()
+	}
+	group.Wait()
+
+	for g := 0; g < goroutines; g++ {
+		require.Len(t, outputs[g], runs)
+		for run, output := range outputs[g] {
+			assert.Equal(t, 2*run, output)
+		}
+	}
+}
+
+func TestPoolReuse(t *testing.T) {
+	image, err := NewImage(doubleProgram)
+	require.NoError(t, err)
+	pool := NewPool(image)
+
+	program := pool.Get(func() int { return 3 }, func(int) {})
+	require.NoError(t, program.Run())
+	pool.Put(program)
+
+	var outputs []int
+	reused := pool.Get(func() int { return 5 }, func(output int) { outputs = append(outputs, output) })
+	assert.False(t, reused.Halted())
+	require.NoError(t, reused.Run())
+	assert.Equal(t, []int{10}, outputs)
+}
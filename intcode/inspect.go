@@ -0,0 +1,70 @@
+package intcode
+
+import "fmt"
+
+// Registers holds the registers of an Intcode program
+type Registers struct {
+	InstructionPointer int
+	RelativeBase       int
+	Halted             bool
+}
+
+// Snapshot is the state of an Intcode program between two instructions, from which its
+// execution can be resumed with NewIntcodeFromSnapshot
+type Snapshot struct {
+	Registers
+	// Cells are the values of the positions of memory that have been set
+	Cells map[int]int
+}
+
+// Registers returns the registers of the Intcode program. It can be called from any goroutine
+// while the program runs, and waits for the instruction being executed to complete.
+func (i *Intcode) Registers() Registers {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.registers()
+}
+
+// registers returns the registers of the Intcode program with the lock held
+func (i *Intcode) registers() Registers {
+	return Registers{
+		InstructionPointer: i.program.InstructionPointer,
+		RelativeBase:       i.program.RelativeBase,
+		Halted:             i.program.Halted,
+	}
+}
+
+// ReadMemory returns a copy of length values of memory from start, without notifying the
+// observers. It can be called from any goroutine while the program runs, and waits for the
+// instruction being executed to complete.
+func (i *Intcode) ReadMemory(start, length int) ([]int, error) {
+	if start < 0 || length < 0 {
+		return nil, fmt.Errorf("invalid memory range of %d values from %d", length, start)
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	values := make([]int, length)
+	for offset := range values {
+		value, err := i.program.Peek(start + offset)
+		if err != nil {
+			return nil, err
+		}
+		values[offset] = value
+	}
+	return values, nil
+}
+
+// Snapshot returns the registers and the memory of the Intcode program at the next instruction
+// boundary. It can be called from any goroutine while the program runs: it waits for the
+// instruction being executed to complete, unless the program is waiting in onInput or onOutput,
+// in which case the snapshot is taken before the instruction that reads or writes the value.
+func (i *Intcode) Snapshot() Snapshot {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return Snapshot{
+		Registers: i.registers(),
+		Cells:     i.program.Cells(),
+	}
+}
@@ -0,0 +1,230 @@
+package intcode
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// counter increments the value at position 7 forever
+const counter = "1001,7,1,7,1105,1,0,0"
+
+func TestRegisters(t *testing.T) {
+	program, err := NewIntcodeProgram(accumulator, MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+	assert.Equal(t, Registers{}, program.Registers())
+
+	require.NoError(t, program.Run())
+	assert.Equal(t, Registers{InstructionPointer: 10, RelativeBase: 7, Halted: true}, program.Registers())
+}
+
+func TestReadMemory(t *testing.T) {
+	program, err := NewIntcodeProgram(accumulator, MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+	require.NoError(t, program.Run())
+
+	values, err := program.ReadMemory(10, 4)
+	require.NoError(t, err)
+	assert.Equal(t, []int{99, 10, 0, 0}, values)
+
+	_, err = program.ReadMemory(-1, 2)
+	assert.EqualError(t, err, "invalid memory range of 2 values from -1")
+	_, err = program.ReadMemory(0, -1)
+	assert.EqualError(t, err, "invalid memory range of -1 values from 0")
+}
+
+func TestSnapshot(t *testing.T) {
+	var outputs []int
+	onOutput := func(output int) { outputs = append(outputs, output) }
+	program, err := NewIntcodeProgram(doubleProgram, func() int { return 3 }, onOutput)
+	require.NoError(t, err)
+	require.NoError(t, program.Step())
+
+	snapshot := program.Snapshot()
+	assert.Equal(t, Registers{InstructionPointer: 2}, snapshot.Registers)
+
+	resumed := NewIntcodeFromSnapshot(snapshot, MustNotInput, onOutput)
+	require.NoError(t, resumed.Run())
+	require.NoError(t, program.Run())
+	assert.Equal(t, []int{6, 6}, outputs)
+}
+
+func TestSnapshotAtHighAddress(t *testing.T) {
+	program, err := NewIntcodeProgram("1101,1,1,100000000000000,99", MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+	require.NoError(t, program.Step())
+
+	snapshot := program.Snapshot()
+	assert.Len(t, snapshot.Cells, 6)
+	assert.Equal(t, 2, snapshot.Cells[100000000000000])
+	assert.Equal(t, 100000000000001, program.MemorySize())
+
+	resumed := NewIntcodeFromSnapshot(snapshot, MustNotInput, MustNotOutput)
+	require.NoError(t, resumed.Run())
+	assert.Equal(t, snapshot.Cells, resumed.Cells())
+}
+
+func TestInspectWhileRunning(t *testing.T) {
+	program, err := NewIntcodeProgram(counter, MustNotInput, MustNotOutput, WithJournal(10))
+	require.NoError(t, err)
+
+	execution := program.Start(context.Background())
+
+	// every inspection waits for the instruction being executed, so the instruction pointer
+	// is always at the start of an instruction and the count never decreases
+	count := 0
+	for count < 1000 {
+		snapshot := program.Snapshot()
+		assert.Contains(t, []int{0, 4}, snapshot.InstructionPointer)
+		require.GreaterOrEqual(t, snapshot.Cells[7], count)
+		count = snapshot.Cells[7]
+
+		registers := program.Registers()
+		assert.Contains(t, []int{0, 4}, registers.InstructionPointer)
+		values, err := program.ReadMemory(7, 1)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, values[0], count)
+
+		_, err = program.Peek(7)
+		require.NoError(t, err)
+		program.InstructionPointer()
+		program.Memory()
+		program.Checkpoint()
+	}
+
+	require.NoError(t, program.Poke(7, -1000000))
+	program.Stop()
+	require.NoError(t, execution.Wait())
+
+	value, err := program.Peek(7)
+	require.NoError(t, err)
+	assert.Less(t, value, 0)
+}
+
+func TestInspectWhileWaitingForInput(t *testing.T) {
+	waiting := make(chan struct{})
+	inputs := make(chan int)
+	onInput := func() int {
+		waiting <- struct{}{}
+		return <-inputs
+	}
+
+	var outputs []int
+	program, err := NewIntcodeProgram(doubleProgram, onInput, func(output int) { outputs = append(outputs, output) })
+	require.NoError(t, err)
+
+	execution := program.Start(context.Background())
+
+	// the multiplier is poked while the program waits, so it is used by the next instruction
+	<-waiting
+	assert.Equal(t, Registers{}, program.Registers())
+	require.NoError(t, program.Poke(3, 3))
+	inputs <- 5
+
+	require.NoError(t, execution.Wait())
+	assert.Equal(t, []int{15}, outputs)
+}
+
+func TestMutateWhileWaitingForInput(t *testing.T) {
+	image, err := NewImage(doubleProgram)
+	require.NoError(t, err)
+
+	waiting := make(chan struct{})
+	inputs := make(chan int)
+	onInput := func() int {
+		waiting <- struct{}{}
+		return <-inputs
+	}
+	program := image.New(onInput, MustNotOutput, WithJournal(10))
+	checkpoint := program.Checkpoint()
+
+	execution := program.Start(context.Background())
+
+	<-waiting
+	assert.Equal(t, ErrWaitingForIO, program.Step())
+	assert.Equal(t, ErrWaitingForIO, program.StepBack())
+	assert.Equal(t, ErrWaitingForIO, program.Rewind(checkpoint))
+	assert.Equal(t, ErrWaitingForIO, program.RunBackToWrite(9))
+	assert.Equal(t, ErrWaitingForIO, image.Reset(program))
+
+	// the input instruction has already read where it stores the input
+	require.NoError(t, program.Poke(1, 10))
+	program.Stop()
+	inputs <- 5
+
+	require.NoError(t, execution.Wait())
+	assert.Equal(t, Registers{InstructionPointer: 2}, program.Registers())
+	values, err := program.ReadMemory(9, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{5, 0}, values)
+}
+
+func TestInspectFromCallbacks(t *testing.T) {
+	var program *Intcode
+	var registers []Registers
+	var snapshots []Snapshot
+	onInput := func() int {
+		registers = append(registers, program.Registers())
+		return 4
+	}
+	onOutput := func(output int) {
+		snapshots = append(snapshots, program.Snapshot())
+		program.Stop()
+	}
+
+	var err error
+	program, err = NewIntcodeProgram(doubleProgram, onInput, onOutput)
+	require.NoError(t, err)
+	require.NoError(t, program.Run())
+
+	assert.Equal(t, []Registers{{}}, registers)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, 6, snapshots[0].InstructionPointer)
+	assert.Equal(t, 8, snapshots[0].Cells[20])
+}
+
+func TestInspectAfterPanic(t *testing.T) {
+	program, err := NewIntcodeProgram(doubleProgram, MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+
+	assert.Panics(t, func() { _ = program.Run() })
+	assert.Equal(t, Registers{}, program.Registers())
+}
+
+func TestInspectConcurrently(t *testing.T) {
+	image, err := NewImage(counter)
+	require.NoError(t, err)
+	program := image.New(MustNotInput, MustNotOutput, WithMetrics())
+
+	const inspectors = 4
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for n := 0; n < inspectors; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				program.Snapshot()
+				_, _ = program.Metrics()
+			}
+		}()
+	}
+
+	for step := 0; step < 1000; step++ {
+		require.NoError(t, program.Step())
+	}
+	close(stop)
+	wg.Wait()
+
+	value, err := program.Peek(7)
+	require.NoError(t, err)
+	assert.Equal(t, 500, value)
+}
@@ -27,7 +27,12 @@ func (a add) Execute(program *program.Program) error {
 		return fmt.Errorf("could not get second parameter: %w", err)
 	}
 
-	err = storeWithThirdParameter(firstParameter+secondParameter, a.thirdParameterMode, program)
+	result, err := sum(firstParameter, secondParameter, program)
+	if err != nil {
+		return err
+	}
+
+	err = storeWithThirdParameter(result, a.thirdParameterMode, program)
 	if err != nil {
 		return fmt.Errorf("could not store with third parameter: %w", err)
 	}
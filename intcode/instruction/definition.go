@@ -0,0 +1,92 @@
+package instruction
+
+// Opcodes of the instructions of the Intcode instruction set
+const (
+	AddOpcode                = int(addOpcode)
+	MultiplyOpcode           = int(multiplyOpcode)
+	InputOpcode              = int(inputOpcode)
+	OutputOpcode             = int(outputOpcode)
+	JumpIfTrueOpcode         = int(jumpIfTrueOpcode)
+	JumpIfFalseOpcode        = int(jumpIfFalseOpcode)
+	LessThanOpcode           = int(lessThanOpcode)
+	EqualsOpcode             = int(equalsOpcode)
+	AdjustRelativeBaseOpcode = int(adjustRelativeBaseOpcode)
+	HaltOpcode               = int(haltOpcode)
+)
+
+// Mode is the mode of a parameter of an instruction
+type Mode int
+
+const (
+	// Position mode parameters are interpreted as a position
+	Position = Mode(positionMode)
+	// Immediate mode parameters are interpreted as a value
+	Immediate = Mode(immediateMode)
+	// Relative mode parameters are interpreted as a position relative to the relative base
+	Relative = Mode(relativeMode)
+)
+
+// ParameterKind indicates how an instruction uses one of its parameters
+type ParameterKind int
+
+const (
+	// Read parameters are values read by the instruction
+	Read ParameterKind = iota
+	// Write parameters are positions written by the instruction
+	Write
+)
+
+// Definition describes an instruction of the Intcode instruction set
+type Definition struct {
+	// Opcode is the opcode of the instruction
+	Opcode int
+	// Mnemonic is the name of the instruction in assembly
+	Mnemonic string
+	// Parameters are the kinds of the parameters of the instruction
+	Parameters []ParameterKind
+}
+
+// Size returns the number of memory positions taken by the instruction
+func (d Definition) Size() int {
+	return 1 + len(d.Parameters)
+}
+
+// Decoded is an instruction decoded from its value in memory
+type Decoded struct {
+	Definition
+	// Modes are the modes of each parameter of the instruction
+	Modes []Mode
+}
+
+// Addressing returns the mode in which the parameter i is accessed. Write parameters in immediate
+// mode are stored at the position they hold, exactly like in position mode.
+func (d Decoded) Addressing(i int) Mode {
+	if d.Parameters[i] == Write && d.Modes[i] == Immediate {
+		return Position
+	}
+	return d.Modes[i]
+}
+
+var definitions = map[opcode]Definition{
+	addOpcode:                {Opcode: int(addOpcode), Mnemonic: "add", Parameters: []ParameterKind{Read, Read, Write}},
+	multiplyOpcode:           {Opcode: int(multiplyOpcode), Mnemonic: "mul", Parameters: []ParameterKind{Read, Read, Write}},
+	inputOpcode:              {Opcode: int(inputOpcode), Mnemonic: "in", Parameters: []ParameterKind{Write}},
+	outputOpcode:             {Opcode: int(outputOpcode), Mnemonic: "out", Parameters: []ParameterKind{Read}},
+	jumpIfTrueOpcode:         {Opcode: int(jumpIfTrueOpcode), Mnemonic: "jnz", Parameters: []ParameterKind{Read, Read}},
+	jumpIfFalseOpcode:        {Opcode: int(jumpIfFalseOpcode), Mnemonic: "jz", Parameters: []ParameterKind{Read, Read}},
+	lessThanOpcode:           {Opcode: int(lessThanOpcode), Mnemonic: "lt", Parameters: []ParameterKind{Read, Read, Write}},
+	equalsOpcode:             {Opcode: int(equalsOpcode), Mnemonic: "eq", Parameters: []ParameterKind{Read, Read, Write}},
+	adjustRelativeBaseOpcode: {Opcode: int(adjustRelativeBaseOpcode), Mnemonic: "arb", Parameters: []ParameterKind{Read}},
+	haltOpcode:               {Opcode: int(haltOpcode), Mnemonic: "halt"},
+}
+
+// Lookup returns the definition of the instruction with the given opcode in the standard instruction set
+func Lookup(n int) (Definition, bool) {
+	return standard.Lookup(n)
+}
+
+// Decode decodes the value n of an instruction of the standard instruction set into its
+// definition and parameter modes
+func Decode(n int) (Decoded, error) {
+	return standard.Decode(n)
+}
@@ -0,0 +1,97 @@
+package instruction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	definition, ok := Lookup(1)
+	require.True(t, ok)
+	assert.Equal(t, "add", definition.Mnemonic)
+	assert.Equal(t, 4, definition.Size())
+
+	definition, ok = Lookup(99)
+	require.True(t, ok)
+	assert.Equal(t, 1, definition.Size())
+
+	_, ok = Lookup(42)
+	assert.False(t, ok)
+
+	_, ok = Lookup(101)
+	assert.False(t, ok)
+}
+
+func TestDecode(t *testing.T) {
+	testCases := map[string]struct {
+		n        int
+		mnemonic string
+		modes    []Mode
+		invalid  bool
+	}{
+		"add with default modes": {
+			n:        1,
+			mnemonic: "add",
+			modes:    []Mode{Position, Position, Position},
+		},
+		"multiply with mixed modes": {
+			n:        21102,
+			mnemonic: "mul",
+			modes:    []Mode{Immediate, Immediate, Relative},
+		},
+		"adjust relative base with relative mode": {
+			n:        209,
+			mnemonic: "arb",
+			modes:    []Mode{Relative},
+		},
+		"halt": {
+			n:        99,
+			mnemonic: "halt",
+			modes:    []Mode{},
+		},
+		"unknown opcode": {
+			n:       42,
+			invalid: true,
+		},
+		"negative value": {
+			n:       -1,
+			invalid: true,
+		},
+		"invalid parameter mode": {
+			n:       301,
+			invalid: true,
+		},
+		"too many parameter modes": {
+			n:       1104,
+			invalid: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			decoded, err := Decode(testCase.n)
+			if testCase.invalid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, testCase.mnemonic, decoded.Mnemonic)
+			assert.Equal(t, testCase.modes, decoded.Modes)
+		})
+	}
+}
+
+func TestAddressing(t *testing.T) {
+	decoded, err := Decode(11101)
+	require.NoError(t, err)
+	assert.Equal(t, Immediate, decoded.Addressing(0))
+	assert.Equal(t, Immediate, decoded.Addressing(1))
+	assert.Equal(t, Position, decoded.Addressing(2))
+
+	decoded, err = Decode(203)
+	require.NoError(t, err)
+	assert.Equal(t, Relative, decoded.Addressing(0))
+}
@@ -14,10 +14,17 @@ func (input) opcode() opcode {
 	return inputOpcode
 }
 
+// Execute resolves where the input is stored before reading it, so that the instruction is not
+// affected by the memory being modified while the program waits for the input
 func (i input) Execute(program *program.Program) error {
+	address, err := storeAddress(1, i.firstParameterMode, program)
+	if err != nil {
+		return fmt.Errorf("could not store with first parameter: %w", err)
+	}
+
 	value := program.ReadInput()
 
-	err := storeWithFirstParameter(value, i.firstParameterMode, program)
+	err = program.Store(address, value)
 	if err != nil {
 		return fmt.Errorf("could not store with first parameter: %w", err)
 	}
@@ -75,30 +75,42 @@ func storeWithParameter(
 	parameterMode parameterMode,
 	program *program.Program,
 ) error {
-	address, err := program.Fetch(program.InstructionPointer + position)
+	address, err := storeAddress(position, parameterMode, program)
 	if err != nil {
 		return err
 	}
+	return program.Store(address, value)
+}
+
+// storeAddress returns the address where the parameter at position stores its value
+func storeAddress(position int, parameterMode parameterMode, program *program.Program) (int, error) {
+	address, err := program.Fetch(program.InstructionPointer + position)
+	if err != nil {
+		return 0, err
+	}
 	switch parameterMode {
-	case positionMode:
-		return program.Store(address, value)
-	case immediateMode:
-		return program.Store(address, value)
+	case positionMode, immediateMode:
+		return address, nil
 	case relativeMode:
-		return program.Store(address+program.RelativeBase, value)
+		return address + program.RelativeBase, nil
 	default:
-		return fmt.Errorf("invalid parameter mode: %d", parameterMode)
+		return 0, fmt.Errorf("invalid parameter mode: %d", parameterMode)
 	}
 }
 
-func storeWithFirstParameter(value int, parameterMode parameterMode, program *program.Program) error {
-	return storeWithParameter(1, value, parameterMode, program)
-}
-
 func storeWithThirdParameter(value int, parameterMode parameterMode, program *program.Program) error {
 	return storeWithParameter(3, value, parameterMode, program)
 }
 
+// jump moves the instruction pointer of program to target, which must be a valid memory position
+func jump(target int, program *program.Program) error {
+	if target < 0 {
+		return fmt.Errorf("invalid jump target: %d", target)
+	}
+	program.InstructionPointer = target
+	return nil
+}
+
 // ParseInstruction parses a value n to an instruction
 func ParseInstruction(n int) (Instruction, error) {
 	switch opcode(n % 100) {
@@ -26,8 +26,7 @@ func (jf jumpIfFalse) Execute(program *program.Program) error {
 		if err != nil {
 			return fmt.Errorf("could not get second parameter: %w", err)
 		}
-		program.InstructionPointer = secondParameter
-		return nil
+		return jump(secondParameter, program)
 	}
 
 	program.InstructionPointer += 3
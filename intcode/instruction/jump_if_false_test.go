@@ -4,8 +4,23 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
 )
 
 func TestJumpIfFalseOpcode(t *testing.T) {
 	assert.Equal(t, jumpIfFalseOpcode, jumpIfFalse{}.opcode())
 }
+
+func TestJumpIfFalseNegativeTarget(t *testing.T) {
+	p, err := program.NewProgram("1106,0,-7", nil, nil)
+	require.NoError(t, err)
+
+	ins, err := ParseInstruction(1106)
+	require.NoError(t, err)
+
+	err = ins.Execute(p)
+	assert.EqualError(t, err, "invalid jump target: -7")
+	assert.Equal(t, 0, p.InstructionPointer)
+}
@@ -26,8 +26,7 @@ func (jt jumpIfTrue) Execute(program *program.Program) error {
 		if err != nil {
 			return fmt.Errorf("could not get second parameter: %w", err)
 		}
-		program.InstructionPointer = secondParameter
-		return nil
+		return jump(secondParameter, program)
 	}
 
 	program.InstructionPointer += 3
@@ -4,8 +4,23 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
 )
 
 func TestJumpIfTrueOpcode(t *testing.T) {
 	assert.Equal(t, jumpIfTrueOpcode, jumpIfTrue{}.opcode())
 }
+
+func TestJumpIfTrueNegativeTarget(t *testing.T) {
+	p, err := program.NewProgram("1105,1,-7", nil, nil)
+	require.NoError(t, err)
+
+	ins, err := ParseInstruction(1105)
+	require.NoError(t, err)
+
+	err = ins.Execute(p)
+	assert.EqualError(t, err, "invalid jump target: -7")
+	assert.Equal(t, 0, p.InstructionPointer)
+}
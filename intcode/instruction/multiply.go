@@ -27,7 +27,12 @@ func (m multiply) Execute(program *program.Program) error {
 		return fmt.Errorf("could not get second parameter: %w", err)
 	}
 
-	err = storeWithThirdParameter(firstParameter*secondParameter, m.thirdParameterMode, program)
+	result, err := product(firstParameter, secondParameter, program)
+	if err != nil {
+		return err
+	}
+
+	err = storeWithThirdParameter(result, m.thirdParameterMode, program)
 	if err != nil {
 		return fmt.Errorf("could not store with third parameter: %w", err)
 	}
@@ -0,0 +1,53 @@
+package instruction
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+// OverflowError is returned by arithmetic instructions when the program detects overflows
+// and the result of the operation does not fit in an int
+type OverflowError struct {
+	// Address is the position of the instruction that overflowed
+	Address int
+	// Operation is the arithmetic operation that overflowed
+	Operation string
+	// Operands are the operands of the operation
+	Operands [2]int
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf(
+		"%s overflow at position %d: %d and %d",
+		e.Operation, e.Address, e.Operands[0], e.Operands[1],
+	)
+}
+
+// sum returns a+b, failing if the program detects overflows and the sum wraps around
+func sum(a, b int, program *program.Program) (int, error) {
+	result := a + b
+	if program.DetectOverflow && (result > a) != (b > 0) {
+		return 0, &OverflowError{
+			Address:   program.InstructionPointer,
+			Operation: "add",
+			Operands:  [2]int{a, b},
+		}
+	}
+	return result, nil
+}
+
+// product returns a*b, failing if the program detects overflows and the product wraps around
+func product(a, b int, program *program.Program) (int, error) {
+	result := a * b
+	overflow := a != 0 && (result/a != b || (a == -1 && b == math.MinInt))
+	if program.DetectOverflow && overflow {
+		return 0, &OverflowError{
+			Address:   program.InstructionPointer,
+			Operation: "multiply",
+			Operands:  [2]int{a, b},
+		}
+	}
+	return result, nil
+}
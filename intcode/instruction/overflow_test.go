@@ -0,0 +1,91 @@
+package instruction
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+func TestSum(t *testing.T) {
+	testCases := map[string]struct {
+		a, b     int
+		expected int
+		overflow bool
+	}{
+		"positive":          {a: 2, b: 3, expected: 5},
+		"negative":          {a: -2, b: -3, expected: -5},
+		"mixed":             {a: math.MaxInt, b: math.MinInt, expected: -1},
+		"zero":              {a: math.MinInt, b: 0, expected: math.MinInt},
+		"positive overflow": {a: math.MaxInt, b: 1, overflow: true},
+		"negative overflow": {a: math.MinInt, b: -1, overflow: true},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			p, err := program.NewProgram("1", nil, nil)
+			require.NoError(t, err)
+			p.DetectOverflow = true
+
+			result, err := sum(testCase.a, testCase.b, p)
+			if testCase.overflow {
+				var overflowError *OverflowError
+				require.True(t, errors.As(err, &overflowError))
+				assert.Equal(t, [2]int{testCase.a, testCase.b}, overflowError.Operands)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, result)
+		})
+	}
+}
+
+func TestProduct(t *testing.T) {
+	testCases := map[string]struct {
+		a, b     int
+		expected int
+		overflow bool
+	}{
+		"positive":          {a: 2, b: 3, expected: 6},
+		"negative":          {a: -2, b: 3, expected: -6},
+		"zero":              {a: 0, b: math.MinInt, expected: 0},
+		"large":             {a: 1 << 31, b: 1 << 31, expected: 1 << 62},
+		"positive overflow": {a: 1 << 32, b: 1 << 31, overflow: true},
+		"negative overflow": {a: -1, b: math.MinInt, overflow: true},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			p, err := program.NewProgram("1", nil, nil)
+			require.NoError(t, err)
+			p.DetectOverflow = true
+
+			result, err := product(testCase.a, testCase.b, p)
+			if testCase.overflow {
+				var overflowError *OverflowError
+				require.True(t, errors.As(err, &overflowError))
+				assert.Equal(t, "multiply", overflowError.Operation)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, result)
+		})
+	}
+}
+
+func TestWrapAround(t *testing.T) {
+	p, err := program.NewProgram("1", nil, nil)
+	require.NoError(t, err)
+
+	result, err := sum(math.MaxInt, 1, p)
+	require.NoError(t, err)
+	assert.Equal(t, math.MinInt, result)
+
+	result, err = product(1<<32, 1<<32, p)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result)
+}
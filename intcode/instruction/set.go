@@ -0,0 +1,203 @@
+package instruction
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+// mnemonicPattern is the pattern that the mnemonics of custom instructions must match
+var mnemonicPattern = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// dataMnemonic is reserved by the assembler to list memory values that are not instructions
+const dataMnemonic = "data"
+
+// Executor executes a custom instruction whose parameters are accessed through operands.
+// The instruction pointer is moved past the instruction unless the executor jumps.
+type Executor func(operands *Operands) error
+
+// Operands gives a custom instruction access to its parameters and to the program executing it
+type Operands struct {
+	// Program is the program executing the instruction
+	Program *program.Program
+
+	modes  []Mode
+	jumped bool
+	target int
+}
+
+// Read returns the value of the parameter i, counting from 0, according to its mode
+func (o *Operands) Read(i int) (int, error) {
+	if i < 0 || i >= len(o.modes) {
+		return 0, fmt.Errorf("instruction has no parameter %d", i)
+	}
+	return getParameter(i+1, parameterMode(o.modes[i]), o.Program)
+}
+
+// Write stores value in the position given by the parameter i, counting from 0, according to its mode
+func (o *Operands) Write(i int, value int) error {
+	if i < 0 || i >= len(o.modes) {
+		return fmt.Errorf("instruction has no parameter %d", i)
+	}
+	return storeWithParameter(i+1, value, parameterMode(o.modes[i]), o.Program)
+}
+
+// Jump moves the instruction pointer to target once the instruction has been executed
+func (o *Operands) Jump(target int) {
+	o.jumped = true
+	o.target = target
+}
+
+// custom is an instruction registered in a Set
+type custom struct {
+	Decoded
+	execute Executor
+}
+
+func (c custom) opcode() opcode {
+	return opcode(c.Opcode)
+}
+
+func (c custom) Execute(program *program.Program) error {
+	operands := &Operands{Program: program, modes: c.Modes}
+	err := c.execute(operands)
+	if err != nil {
+		return fmt.Errorf("could not execute %s: %w", c.Mnemonic, err)
+	}
+
+	if operands.jumped {
+		return jump(operands.target, program)
+	}
+	program.InstructionPointer += c.Size()
+	return nil
+}
+
+// Set is an instruction set: the standard Intcode instructions, extended with custom
+// instructions that are picked up by the machines, the disassembler and the assembler using it
+type Set struct {
+	definitions map[int]Definition
+	mnemonics   map[string]int
+	executors   map[int]Executor
+	// frozen indicates that the set cannot be extended
+	frozen bool
+}
+
+// standard is the standard Intcode instruction set
+var standard = newStandardSet()
+
+func newStandardSet() *Set {
+	s := NewSet()
+	s.frozen = true
+	return s
+}
+
+// Standard returns the standard Intcode instruction set, which cannot be extended
+func Standard() *Set {
+	return standard
+}
+
+// NewSet returns a new instruction set holding the standard Intcode instructions
+func NewSet() *Set {
+	s := &Set{
+		definitions: make(map[int]Definition),
+		mnemonics:   make(map[string]int),
+		executors:   make(map[int]Executor),
+	}
+	for _, definition := range definitions {
+		s.definitions[definition.Opcode] = definition
+		s.mnemonics[definition.Mnemonic] = definition.Opcode
+	}
+	return s
+}
+
+// Register registers a custom instruction executed by execute. Its opcode must be between 1 and 98
+// and its mnemonic a lowercase identifier, neither of them being already used in the set.
+func (s *Set) Register(definition Definition, execute Executor) error {
+	if s.frozen {
+		return fmt.Errorf("the standard instruction set cannot be extended, use NewSet")
+	}
+	if definition.Opcode < 1 || definition.Opcode > 98 {
+		return fmt.Errorf("invalid opcode %d, it must be between 1 and 98", definition.Opcode)
+	}
+	if existing, ok := s.definitions[definition.Opcode]; ok {
+		return fmt.Errorf("opcode %d is already used by %s", definition.Opcode, existing.Mnemonic)
+	}
+	if !mnemonicPattern.MatchString(definition.Mnemonic) || definition.Mnemonic == dataMnemonic {
+		return fmt.Errorf("invalid mnemonic %q", definition.Mnemonic)
+	}
+	if opcode, ok := s.mnemonics[definition.Mnemonic]; ok {
+		return fmt.Errorf("mnemonic %s is already used by opcode %d", definition.Mnemonic, opcode)
+	}
+	if execute == nil {
+		return fmt.Errorf("missing executor for %s", definition.Mnemonic)
+	}
+
+	definition.Parameters = append([]ParameterKind(nil), definition.Parameters...)
+	s.definitions[definition.Opcode] = definition
+	s.mnemonics[definition.Mnemonic] = definition.Opcode
+	s.executors[definition.Opcode] = execute
+	return nil
+}
+
+// Lookup returns the definition of the instruction with the given opcode
+func (s *Set) Lookup(n int) (Definition, bool) {
+	if n < 0 || n > 99 {
+		return Definition{}, false
+	}
+	definition, ok := s.definitions[n]
+	return definition, ok
+}
+
+// LookupMnemonic returns the definition of the instruction with the given mnemonic
+func (s *Set) LookupMnemonic(mnemonic string) (Definition, bool) {
+	opcode, ok := s.mnemonics[mnemonic]
+	if !ok {
+		return Definition{}, false
+	}
+	return s.definitions[opcode], true
+}
+
+// Decode decodes the value n of an instruction into its definition and parameter modes
+func (s *Set) Decode(n int) (Decoded, error) {
+	if n < 0 {
+		return Decoded{}, fmt.Errorf("invalid instruction %d", n)
+	}
+
+	definition, ok := s.Lookup(n % 100)
+	if !ok {
+		return Decoded{}, fmt.Errorf("unknown opcode %d", n)
+	}
+
+	modes := make([]Mode, len(definition.Parameters))
+	remainingModes := n / 100
+	for i := range modes {
+		modes[i] = Mode(remainingModes % 10)
+		if modes[i] != Position && modes[i] != Immediate && modes[i] != Relative {
+			return Decoded{}, fmt.Errorf("invalid parameter mode %d in instruction %d", modes[i], n)
+		}
+		remainingModes /= 10
+	}
+	if remainingModes != 0 {
+		return Decoded{}, fmt.Errorf("too many parameter modes in instruction %d", n)
+	}
+
+	return Decoded{
+		Definition: definition,
+		Modes:      modes,
+	}, nil
+}
+
+// Parse parses a value n to an instruction of the set
+func (s *Set) Parse(n int) (Instruction, error) {
+	execute, ok := s.executors[n%100]
+	if !ok {
+		return ParseInstruction(n)
+	}
+
+	decoded, err := s.Decode(n)
+	if err != nil {
+		return nil, err
+	}
+	return custom{Decoded: decoded, execute: execute}, nil
+}
@@ -0,0 +1,148 @@
+package instruction
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+func TestSetRegister(t *testing.T) {
+	nop := func(operands *Operands) error { return nil }
+
+	testCases := map[string]struct {
+		definition Definition
+		execute    Executor
+		err        string
+	}{
+		"valid": {
+			definition: Definition{Opcode: 50, Mnemonic: "print", Parameters: []ParameterKind{Read}},
+			execute:    nop,
+		},
+		"opcode too low": {
+			definition: Definition{Opcode: 0, Mnemonic: "zero"},
+			execute:    nop,
+			err:        "invalid opcode 0, it must be between 1 and 98",
+		},
+		"opcode of halt": {
+			definition: Definition{Opcode: 99, Mnemonic: "stop"},
+			execute:    nop,
+			err:        "invalid opcode 99, it must be between 1 and 98",
+		},
+		"opcode already used": {
+			definition: Definition{Opcode: 1, Mnemonic: "plus"},
+			execute:    nop,
+			err:        "opcode 1 is already used by add",
+		},
+		"mnemonic already used": {
+			definition: Definition{Opcode: 50, Mnemonic: "add"},
+			execute:    nop,
+			err:        "mnemonic add is already used by opcode 1",
+		},
+		"reserved mnemonic": {
+			definition: Definition{Opcode: 50, Mnemonic: "data"},
+			execute:    nop,
+			err:        `invalid mnemonic "data"`,
+		},
+		"invalid mnemonic": {
+			definition: Definition{Opcode: 50, Mnemonic: "Print"},
+			execute:    nop,
+			err:        `invalid mnemonic "Print"`,
+		},
+		"missing executor": {
+			definition: Definition{Opcode: 50, Mnemonic: "print"},
+			err:        "missing executor for print",
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := NewSet().Register(test.definition, test.execute)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestStandardSetIsFrozen(t *testing.T) {
+	err := Standard().Register(Definition{Opcode: 50, Mnemonic: "print"}, func(*Operands) error { return nil })
+	assert.Error(t, err)
+
+	_, ok := Standard().LookupMnemonic("print")
+	assert.False(t, ok)
+}
+
+func TestSetCustomInstruction(t *testing.T) {
+	set := NewSet()
+	// swap jumps to its second parameter after storing its first parameter in the third one
+	err := set.Register(
+		Definition{Opcode: 42, Mnemonic: "swap", Parameters: []ParameterKind{Read, Read, Write}},
+		func(operands *Operands) error {
+			value, err := operands.Read(0)
+			if err != nil {
+				return err
+			}
+			target, err := operands.Read(1)
+			if err != nil {
+				return err
+			}
+			operands.Jump(target)
+			return operands.Write(2, value)
+		},
+	)
+	require.NoError(t, err)
+
+	definition, ok := set.LookupMnemonic("swap")
+	require.True(t, ok)
+	assert.Equal(t, 4, definition.Size())
+
+	decoded, err := set.Decode(1142)
+	require.NoError(t, err)
+	assert.Equal(t, []Mode{Immediate, Immediate, Position}, decoded.Modes)
+
+	p, err := program.NewProgram("1142,7,9,5,99,0", nil, nil)
+	require.NoError(t, err)
+
+	ins, err := set.Parse(1142)
+	require.NoError(t, err)
+	require.NoError(t, ins.Execute(p))
+
+	assert.Equal(t, 9, p.InstructionPointer)
+	value, err := p.Fetch(5)
+	require.NoError(t, err)
+	assert.Equal(t, 7, value)
+
+	_, err = Decode(1142)
+	assert.Error(t, err, "the standard instruction set must not know custom instructions")
+}
+
+func TestSetCustomInstructionError(t *testing.T) {
+	errTrap := errors.New("trap")
+
+	set := NewSet()
+	err := set.Register(Definition{Opcode: 50, Mnemonic: "brk"}, func(*Operands) error { return errTrap })
+	require.NoError(t, err)
+
+	p, err := program.NewProgram("50,99", nil, nil)
+	require.NoError(t, err)
+
+	ins, err := set.Parse(50)
+	require.NoError(t, err)
+
+	err = ins.Execute(p)
+	assert.True(t, errors.Is(err, errTrap))
+	assert.EqualError(t, err, "could not execute brk: trap")
+	assert.Equal(t, 0, p.InstructionPointer)
+}
+
+func TestSetParseStandardInstruction(t *testing.T) {
+	ins, err := NewSet().Parse(1001)
+	require.NoError(t, err)
+	assert.Equal(t, addOpcode, ins.opcode())
+}
@@ -1,20 +1,30 @@
 package intcode
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
 	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
 )
 
+// Positions of the memory of the gravity assist programs of day02, which RunWithNounAndVerb uses
 const (
-	outputPosition = 0
-	nounPosition   = 1
-	verbPosition   = 2
+	// OutputPosition holds the output of the program once it halts
+	OutputPosition = 0
+	// NounPosition and VerbPosition hold the noun and the verb given as inputs to the program
+	NounPosition = 1
+	VerbPosition = 2
 )
 
 var (
+	// ErrWaitingForIO is returned when the execution or the registers of a program are changed
+	// while it waits in onInput or onOutput, in the middle of an instruction
+	ErrWaitingForIO = errors.New("intcode program is waiting for an input or an output in the middle of an instruction")
+
 	// MustNotInput panics if Intcode program expects an input
 	MustNotInput = func() int {
 		panic("intcode program expects an input")
@@ -26,41 +36,124 @@ var (
 	}
 )
 
-// Intcode represents an Intcode program
+// Option configures an optional behaviour of an Intcode program
+type Option func(i *Intcode)
+
+// WithOverflowDetection makes arithmetic instructions fail with an *instruction.OverflowError
+// instead of silently wrapping around when their result does not fit in an int
+func WithOverflowDetection() Option {
+	return func(i *Intcode) {
+		i.program.DetectOverflow = true
+	}
+}
+
+// WithInstructionSet makes the Intcode program execute the instructions of set, which
+// may extend the standard instruction set with custom instructions
+func WithInstructionSet(set *instruction.Set) Option {
+	return func(i *Intcode) {
+		i.instructions = set
+	}
+}
+
+// Intcode represents an Intcode program. Its state can be inspected from any goroutine while it
+// runs, since each instruction is executed with a lock held. The lock is released while onInput
+// and onOutput run, so the program can be inspected while it waits for an input or an output,
+// and the callbacks themselves can inspect it. In that window the instruction is only half
+// executed: memory can be read, forked and poked, but executing instructions, stepping back,
+// rewinding and resetting the program fail with ErrWaitingForIO. Observers are notified of
+// memory accesses and I/O with the lock held, so they must not call the methods of the program.
 type Intcode struct {
-	sync.RWMutex
+	// mutex guards the state of program
+	mutex sync.RWMutex
+	// waitingForIO indicates that the lock has been released while onInput or onOutput runs
+	waitingForIO bool
 
 	program    *program.Program
-	shouldStop bool
+	shouldStop atomic.Bool
+	observers  []Observer
+	journal    *journal
+	metrics    *metrics
+	// instructions is the instruction set of the program, nil for the standard one
+	instructions *instruction.Set
 }
 
 // NewIntcodeProgram creates a new Intcode program from the following parameters:
 // - programString is the string representation of the program
 // - onInput is the function that will be called whenever the program expects an input
 // - onOutput is the function that will be called whenever the program produces an output
+// - options are the optional behaviours of the program
 func NewIntcodeProgram(
 	programString string,
 	onInput func() int,
 	onOutput func(output int),
+	options ...Option,
 ) (*Intcode, error) {
-	p, err := program.NewProgram(programString, onInput, onOutput)
+	i := &Intcode{}
+	p, err := program.NewProgram(programString, i.unlockedInput(onInput), i.unlockedOutput(onOutput))
 	if err != nil {
 		return nil, fmt.Errorf("error creating program: %w", err)
 	}
 
-	return &Intcode{
-		program: p,
-	}, nil
+	i.program = p
+	for _, option := range options {
+		option(i)
+	}
+
+	return i, nil
+}
+
+// NewIntcodeFromState creates an Intcode program that resumes the execution of a program
+// from the given memory, instruction pointer and relative base
+func NewIntcodeFromState(
+	memory []int,
+	instructionPointer, relativeBase int,
+	onInput func() int,
+	onOutput func(output int),
+	options ...Option,
+) *Intcode {
+	i := &Intcode{}
+	p := program.NewProgramFromMemory(memory, i.unlockedInput(onInput), i.unlockedOutput(onOutput))
+	p.InstructionPointer = instructionPointer
+	p.RelativeBase = relativeBase
+
+	i.program = p
+	for _, option := range options {
+		option(i)
+	}
+
+	return i
+}
+
+// NewIntcodeFromSnapshot creates an Intcode program that resumes the execution of a program
+// from snapshot
+func NewIntcodeFromSnapshot(
+	snapshot Snapshot,
+	onInput func() int,
+	onOutput func(output int),
+	options ...Option,
+) *Intcode {
+	i := &Intcode{}
+	p := program.NewProgramFromCells(snapshot.Cells, i.unlockedInput(onInput), i.unlockedOutput(onOutput))
+	p.InstructionPointer = snapshot.InstructionPointer
+	p.RelativeBase = snapshot.RelativeBase
+	p.Halted = snapshot.Halted
+
+	i.program = p
+	for _, option := range options {
+		option(i)
+	}
+
+	return i
 }
 
 // RunWithNounAndVerb runs an Intcode program with the given noun and verb
 func (i *Intcode) RunWithNounAndVerb(noun, verb int) (int, error) {
-	err := i.program.Store(nounPosition, noun)
+	err := i.store(NounPosition, noun)
 	if err != nil {
 		return 0, fmt.Errorf("error setting noun: %w", err)
 	}
 
-	err = i.program.Store(verbPosition, verb)
+	err = i.store(VerbPosition, verb)
 	if err != nil {
 		return 0, fmt.Errorf("error setting verb: %w", err)
 	}
@@ -70,42 +163,207 @@ func (i *Intcode) RunWithNounAndVerb(noun, verb int) (int, error) {
 		return 0, err
 	}
 
-	output, err := i.program.Fetch(outputPosition)
-	return output, err
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.program.Fetch(OutputPosition)
+}
+
+// store stores value at position in memory, notifying the observers
+func (i *Intcode) store(position, value int) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return i.program.Store(position, value)
 }
 
 // Run runs the Intcode program
 func (i *Intcode) Run() error {
-	for !i.program.Halted {
+	return i.RunContext(context.Background())
+}
 
-		n, err := i.program.Fetch(i.program.InstructionPointer)
-		if err != nil {
-			return fmt.Errorf("error fetching instruction: %w", err)
-		}
+// Step executes the instruction pointed by the instruction pointer
+func (i *Intcode) Step() error {
+	event, err := i.execute()
+	if err != nil {
+		return err
+	}
 
-		parsedInstruction, err := instruction.ParseInstruction(n)
-		if err != nil {
-			return fmt.Errorf("error parsing instruction: %w", err)
-		}
+	for _, observer := range i.observers {
+		observer.OnInstruction(event)
+	}
+
+	return nil
+}
+
+// execute executes the instruction pointed by the instruction pointer with the lock held
+func (i *Intcode) execute() (InstructionEvent, error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.waitingForIO {
+		return InstructionEvent{}, ErrWaitingForIO
+	}
+	address := i.program.InstructionPointer
+
+	n, err := i.program.Fetch(address)
+	if err != nil {
+		return InstructionEvent{}, fmt.Errorf("error fetching instruction: %w", err)
+	}
 
-		err = parsedInstruction.Execute(i.program)
+	parsedInstruction, err := i.parse(n)
+	if err != nil {
+		return InstructionEvent{}, fmt.Errorf("error parsing instruction: %w", err)
+	}
+
+	if i.journal != nil {
+		i.journal.begin(address, i.program.RelativeBase, i.program.Halted)
+	}
+	err = parsedInstruction.Execute(i.program)
+	if i.journal != nil {
 		if err != nil {
-			return fmt.Errorf("error executing instruction: %w", err)
+			// the failing instruction is not journaled, so the stores it did before failing are undone
+			i.undo(i.journal.discard())
+		} else {
+			i.journal.end()
 		}
+	}
+	if err != nil {
+		return InstructionEvent{}, fmt.Errorf("error executing instruction: %w", err)
+	}
+	if i.metrics != nil {
+		i.metrics.instructions.Add(1)
+	}
 
-		i.RLock()
-		if i.shouldStop {
-			i.RUnlock()
-			break
-		}
-		i.RUnlock()
+	return InstructionEvent{
+		Address: address,
+		Opcode:  n % 100,
+		Next:    i.program.InstructionPointer,
+	}, nil
+}
+
+// unlockedInput returns an onInput function that releases the lock of the program while onInput
+// runs. It is only called while an instruction is executed, so with the lock held.
+func (i *Intcode) unlockedInput(onInput func() int) func() int {
+	return func() int {
+		i.unlock()
+		defer i.relock()
+		return onInput()
 	}
-	return nil
 }
 
-// Stop stops the Intcode program
+// unlockedOutput returns an onOutput function that releases the lock of the program while
+// onOutput runs. It is only called while an instruction is executed, so with the lock held.
+func (i *Intcode) unlockedOutput(onOutput func(output int)) func(output int) {
+	return func(output int) {
+		i.unlock()
+		defer i.relock()
+		onOutput(output)
+	}
+}
+
+// unlock releases the lock held by the instruction being executed while it waits for I/O
+func (i *Intcode) unlock() {
+	i.waitingForIO = true
+	i.mutex.Unlock()
+}
+
+// relock takes back the lock released by unlock once the I/O completes
+func (i *Intcode) relock() {
+	i.mutex.Lock()
+	i.waitingForIO = false
+}
+
+// setIO makes the program call onInput and onOutput
+func (i *Intcode) setIO(onInput func() int, onOutput func(output int)) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.program.SetIO(i.unlockedInput(onInput), i.unlockedOutput(onOutput))
+}
+
+// parse parses the value n to an instruction of the instruction set of the program
+func (i *Intcode) parse(n int) (instruction.Instruction, error) {
+	if i.instructions == nil {
+		return instruction.ParseInstruction(n)
+	}
+	return i.instructions.Parse(n)
+}
+
+// Halted indicates if the Intcode program has been halted
+func (i *Intcode) Halted() bool {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.program.Halted
+}
+
+// InstructionPointer returns the current position of the instruction pointer
+func (i *Intcode) InstructionPointer() int {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.program.InstructionPointer
+}
+
+// RelativeBase returns the current position of the relative base
+func (i *Intcode) RelativeBase() int {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.program.RelativeBase
+}
+
+// Peek returns the value at position in memory without notifying the observers
+func (i *Intcode) Peek(position int) (int, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.program.Peek(position)
+}
+
+// Poke stores value at position in memory without notifying the observers. It can be called
+// while the program runs, the value being stored between two instructions or, if the program
+// waits in onInput or onOutput, before the instruction completes. The instruction has already
+// read its parameters by then, so it is not affected by the new value, but a value it then
+// stores at the same position overwrites it.
+func (i *Intcode) Poke(position, value int) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return i.program.Poke(position, value)
+}
+
+// Fork returns a copy of the Intcode program in its current state which calls onInput and
+// onOutput. The copy keeps the instruction set and the overflow detection of the program,
+// but not its observers, its journal nor its metrics.
+func (i *Intcode) Fork(onInput func() int, onOutput func(output int)) *Intcode {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	forked := &Intcode{instructions: i.instructions}
+	forked.program = i.program.Fork(forked.unlockedInput(onInput), forked.unlockedOutput(onOutput))
+	return forked
+}
+
+// Memory returns a dense copy of the memory of the Intcode program. Its size is one more than
+// the highest position that has been set, so ReadMemory or Cells must be used instead on
+// programs that may access very large positions.
+func (i *Intcode) Memory() []int {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.program.Memory()
+}
+
+// MemorySize returns the size of the memory returned by Memory without copying it
+func (i *Intcode) MemorySize() int {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.program.Size()
+}
+
+// Cells returns a sparse copy of the memory of the Intcode program, holding the value of
+// every position that has been set
+func (i *Intcode) Cells() map[int]int {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.program.Cells()
+}
+
+// Stop stops the Intcode program once the instruction being executed completes. It can be
+// called from any goroutine, including from onInput and onOutput.
 func (i *Intcode) Stop() {
-	i.Lock()
-	defer i.Unlock()
-	i.shouldStop = true
+	i.shouldStop.Store(true)
 }
@@ -1,10 +1,15 @@
 package intcode
 
 import (
+	"errors"
+	"math"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
 )
 
 func TestRunWithNounAndVerb(t *testing.T) {
@@ -157,3 +162,166 @@ func TestRunWithInput(t *testing.T) {
 		})
 	}
 }
+
+func TestStep(t *testing.T) {
+	program, err := NewIntcodeProgram("1101,2,3,5,99,0", MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+
+	require.NoError(t, program.Step())
+	assert.False(t, program.Halted())
+
+	require.NoError(t, program.Step())
+	assert.True(t, program.Halted())
+
+	output, err := program.program.Fetch(5)
+	require.NoError(t, err)
+	assert.Equal(t, 5, output)
+}
+
+func TestWithOverflowDetection(t *testing.T) {
+	testCases := map[string]struct {
+		program string
+	}{
+		"add overflow": {
+			program: "1101," + strconv.Itoa(math.MaxInt) + ",1,0,99",
+		},
+		"multiply overflow": {
+			program: "1102," + strconv.Itoa(math.MaxInt) + ",2,0,99",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			program, err := NewIntcodeProgram(testCase.program, MustNotInput, MustNotOutput)
+			require.NoError(t, err)
+			require.NoError(t, program.Run())
+
+			program, err = NewIntcodeProgram(
+				testCase.program, MustNotInput, MustNotOutput, WithOverflowDetection(),
+			)
+			require.NoError(t, err)
+
+			err = program.Run()
+			var overflowError *instruction.OverflowError
+			require.True(t, errors.As(err, &overflowError))
+			assert.Equal(t, 0, overflowError.Address)
+		})
+	}
+}
+
+func TestWithOverflowDetectionLargeNumbers(t *testing.T) {
+	var output int
+	onOutput := func(o int) { output = o }
+
+	program, err := NewIntcodeProgram(
+		"1102,34915192,34915192,7,4,7,99,0", MustNotInput, onOutput, WithOverflowDetection(),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, program.Run())
+	assert.Equal(t, 1219070632396864, output)
+}
+
+type instructionRecorder struct {
+	NopObserver
+	events []InstructionEvent
+}
+
+func (r *instructionRecorder) OnInstruction(event InstructionEvent) {
+	r.events = append(r.events, event)
+}
+
+func TestWithObserver(t *testing.T) {
+	recorder := &instructionRecorder{}
+	program, err := NewIntcodeProgram(
+		"1105,1,4,99,1101,1,1,0,99", MustNotInput, MustNotOutput, WithObserver(recorder),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, program.Run())
+
+	expected := []InstructionEvent{
+		{Address: 0, Opcode: 5, Next: 4},
+		{Address: 4, Opcode: 1, Next: 8},
+		{Address: 8, Opcode: 99, Next: 8},
+	}
+	assert.Equal(t, expected, recorder.events)
+}
+
+func TestNewIntcodeFromState(t *testing.T) {
+	var outputs []int
+	onOutput := func(output int) { outputs = append(outputs, output) }
+
+	// resume right before the second output, with the relative base pointing to 10
+	memory := []int{104, 1, 204, -3, 99, 0, 0, 42}
+	program := NewIntcodeFromState(memory, 2, 10, MustNotInput, onOutput)
+
+	require.NoError(t, program.Run())
+	assert.Equal(t, []int{42}, outputs)
+
+	memory[7] = 0
+	output, err := program.program.Fetch(7)
+	require.NoError(t, err)
+	assert.Equal(t, 42, output, "memory must be copied")
+}
+
+func TestInspection(t *testing.T) {
+	program, err := NewIntcodeProgram("109,5,1101,1,2,9,99", MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+	require.NoError(t, program.Step())
+	require.NoError(t, program.Step())
+
+	assert.Equal(t, 6, program.InstructionPointer())
+	assert.Equal(t, 5, program.RelativeBase())
+
+	value, err := program.Peek(9)
+	require.NoError(t, err)
+	assert.Equal(t, 3, value)
+	assert.Equal(t, []int{109, 5, 1101, 1, 2, 9, 99, 0, 0, 3}, program.Memory())
+}
+
+func TestFork(t *testing.T) {
+	program, err := NewIntcodeProgram("3,10,1002,10,2,10,4,10,99,0,0", MustNotInput, MustNotOutput, WithJournal(8))
+	require.NoError(t, err)
+	// triples the input instead of doubling it
+	require.NoError(t, program.Poke(4, 3))
+
+	run := func(input int) int {
+		var output int
+		forked := program.Fork(func() int { return input }, func(o int) { output = o })
+		require.NoError(t, forked.Run())
+		assert.True(t, errors.Is(forked.StepBack(), ErrNoJournal))
+		return output
+	}
+
+	assert.Equal(t, 9, run(3))
+	assert.Equal(t, 21, run(7))
+	assert.False(t, program.Halted())
+
+	value, err := program.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, value, "forks must not modify the program")
+}
+
+func TestWithInstructionSet(t *testing.T) {
+	var printed []int
+	set := instruction.NewSet()
+	err := set.Register(
+		instruction.Definition{Opcode: 50, Mnemonic: "print", Parameters: []instruction.ParameterKind{instruction.Read}},
+		func(operands *instruction.Operands) error {
+			value, err := operands.Read(0)
+			printed = append(printed, value)
+			return err
+		},
+	)
+	require.NoError(t, err)
+
+	program, err := NewIntcodeProgram("1101,2,3,7,50,7,99,0", MustNotInput, MustNotOutput, WithInstructionSet(set))
+	require.NoError(t, err)
+	require.NoError(t, program.Run())
+	assert.Equal(t, []int{5}, printed)
+
+	program, err = NewIntcodeProgram("1101,2,3,7,50,7,99,0", MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+	assert.Error(t, program.Run(), "the standard instruction set must not know custom instructions")
+}
@@ -0,0 +1,239 @@
+package intcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoJournal is returned when stepping back a program without journal
+	ErrNoJournal = errors.New("intcode program has no journal, enable it with WithJournal")
+	// ErrJournalExhausted is returned when there are no more instructions in the journal to undo
+	ErrJournalExhausted = errors.New("no more instructions to undo in the journal")
+)
+
+// Checkpoint identifies a point of the execution of a program, given by the number
+// of instructions executed up to it
+type Checkpoint int
+
+// journalEntry holds the state needed to undo an instruction
+type journalEntry struct {
+	instructionPointer int
+	relativeBase       int
+	halted             bool
+	// writes are the stores done by the instruction, in order
+	writes []write
+}
+
+// write is a store to position, which previously held previous
+type write struct {
+	position int
+	previous int
+}
+
+// journal is a bounded undo journal of the last executed instructions
+type journal struct {
+	NopObserver
+
+	// entries is a ring buffer holding the last capacity instructions at most, with room for
+	// the entry of the instruction being executed
+	entries  []journalEntry
+	capacity int
+	// oldest is the index in entries of the oldest instruction
+	oldest int
+	// length is the number of instructions held in entries
+	length int
+	// executed is the number of instructions executed
+	executed int
+	// current is the entry of the instruction being executed, if any
+	current *journalEntry
+}
+
+// WithJournal records an undo journal of the last capacity executed instructions, so that
+// the program can be stepped back with StepBack, RunBackToWrite and Rewind. Inputs and outputs
+// are not undone: executing again an instruction that was undone reads or writes a new value.
+func WithJournal(capacity int) Option {
+	return func(i *Intcode) {
+		if capacity < 1 {
+			capacity = 1
+		}
+		i.journal = &journal{entries: make([]journalEntry, capacity+1), capacity: capacity}
+		i.program.AddObserver(i.journal)
+	}
+}
+
+// begin starts the entry of the instruction about to be executed
+func (j *journal) begin(instructionPointer, relativeBase int, halted bool) {
+	index := (j.oldest + j.length) % len(j.entries)
+	j.entries[index] = journalEntry{
+		instructionPointer: instructionPointer,
+		relativeBase:       relativeBase,
+		halted:             halted,
+		writes:             j.entries[index].writes[:0],
+	}
+	j.current = &j.entries[index]
+}
+
+// end adds the entry of the instruction that has been executed, dropping the oldest one if the
+// journal is full
+func (j *journal) end() {
+	j.current = nil
+	j.length++
+	j.executed++
+	if j.length > j.capacity {
+		j.oldest = (j.oldest + 1) % len(j.entries)
+		j.length--
+	}
+}
+
+// discard drops the entry of the instruction that failed, returning it so that its stores can be undone
+func (j *journal) discard() journalEntry {
+	entry := *j.current
+	j.current = nil
+	return entry
+}
+
+// OnStore records a store done by the instruction being executed
+func (j *journal) OnStore(position, previous, value int) {
+	if j.current != nil {
+		j.current.writes = append(j.current.writes, write{position: position, previous: previous})
+	}
+}
+
+// clear removes all the entries of the journal
+func (j *journal) clear() {
+	j.oldest = 0
+	j.length = 0
+	j.executed = 0
+	j.current = nil
+}
+
+// last returns the entry of the most recent instruction
+func (j *journal) last() *journalEntry {
+	return &j.entries[(j.oldest+j.length-1)%len(j.entries)]
+}
+
+// pop removes the entry of the most recent instruction
+func (j *journal) pop() journalEntry {
+	entry := *j.last()
+	j.length--
+	j.executed--
+	return entry
+}
+
+// Checkpoint returns the current point of the execution, which can be later rewound to with Rewind
+func (i *Intcode) Checkpoint() Checkpoint {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if i.journal == nil {
+		return 0
+	}
+	return Checkpoint(i.journal.executed)
+}
+
+// StepBack undoes the last executed instruction. It fails with ErrWaitingForIO while the program
+// waits in onInput or onOutput.
+func (i *Intcode) StepBack() error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return i.stepBack()
+}
+
+// stepBack undoes the last executed instruction with the lock held
+func (i *Intcode) stepBack() error {
+	if i.waitingForIO {
+		return ErrWaitingForIO
+	}
+	if i.journal == nil {
+		return ErrNoJournal
+	}
+	if i.journal.length == 0 {
+		return ErrJournalExhausted
+	}
+
+	i.undo(i.journal.pop())
+	return nil
+}
+
+// undo restores the state of the program before the instruction of entry with the lock held
+func (i *Intcode) undo(entry journalEntry) {
+	for w := len(entry.writes) - 1; w >= 0; w-- {
+		i.program.Restore(entry.writes[w].position, entry.writes[w].previous)
+	}
+	i.program.InstructionPointer = entry.instructionPointer
+	i.program.RelativeBase = entry.relativeBase
+	i.program.Halted = entry.halted
+}
+
+// RunBackToWrite steps back until the instruction that last wrote position is undone, leaving
+// the instruction pointer at it. The program is not modified if the journal does not hold
+// any instruction that wrote position.
+func (i *Intcode) RunBackToWrite(position int) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.waitingForIO {
+		return ErrWaitingForIO
+	}
+	if i.journal == nil {
+		return ErrNoJournal
+	}
+
+	j := i.journal
+	for undone := 1; undone <= j.length; undone++ {
+		entry := j.entries[(j.oldest+j.length-undone)%len(j.entries)]
+		if !entry.wrote(position) {
+			continue
+		}
+
+		for ; undone > 0; undone-- {
+			if err := i.stepBack(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("no write to position %d in the journal: %w", position, ErrJournalExhausted)
+}
+
+// Rewind steps back until the execution is at checkpoint. The program is not modified if
+// checkpoint is not held in the journal anymore, or if it has not been reached yet.
+func (i *Intcode) Rewind(checkpoint Checkpoint) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.waitingForIO {
+		return ErrWaitingForIO
+	}
+	if i.journal == nil {
+		return ErrNoJournal
+	}
+
+	j := i.journal
+	undone := j.executed - int(checkpoint)
+	if undone < 0 {
+		return fmt.Errorf("checkpoint %d has not been reached, %d instructions executed", checkpoint, j.executed)
+	}
+	if undone > j.length {
+		return fmt.Errorf("checkpoint %d is older than the journal: %w", checkpoint, ErrJournalExhausted)
+	}
+
+	for ; undone > 0; undone-- {
+		if err := i.stepBack(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wrote returns true if the instruction of the entry wrote position
+func (e journalEntry) wrote(position int) bool {
+	for _, w := range e.writes {
+		if w.position == position {
+			return true
+		}
+	}
+	return false
+}
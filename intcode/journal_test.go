@@ -0,0 +1,126 @@
+package intcode
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// accumulator stores 5 at position 11, then adds 5 to it and adjusts the relative base
+const accumulator = "1101,2,3,11,1001,11,5,11,109,7,99,0"
+
+type state struct {
+	instructionPointer int
+	relativeBase       int
+	halted             bool
+	value              int
+}
+
+func stateOf(t *testing.T, i *Intcode) state {
+	value, err := i.program.Fetch(11)
+	require.NoError(t, err)
+	return state{
+		instructionPointer: i.program.InstructionPointer,
+		relativeBase:       i.program.RelativeBase,
+		halted:             i.program.Halted,
+		value:              value,
+	}
+}
+
+func TestStepBack(t *testing.T) {
+	program, err := NewIntcodeProgram(accumulator, MustNotInput, MustNotOutput, WithJournal(10))
+	require.NoError(t, err)
+	require.NoError(t, program.Run())
+	assert.Equal(t, state{10, 7, true, 10}, stateOf(t, program))
+
+	expected := []state{
+		{10, 7, false, 10},
+		{8, 0, false, 10},
+		{4, 0, false, 5},
+		{0, 0, false, 0},
+	}
+	for _, e := range expected {
+		require.NoError(t, program.StepBack())
+		assert.Equal(t, e, stateOf(t, program))
+	}
+
+	assert.True(t, errors.Is(program.StepBack(), ErrJournalExhausted))
+
+	require.NoError(t, program.Run())
+	assert.Equal(t, state{10, 7, true, 10}, stateOf(t, program))
+}
+
+func TestRunBackToWrite(t *testing.T) {
+	program, err := NewIntcodeProgram(accumulator, MustNotInput, MustNotOutput, WithJournal(10))
+	require.NoError(t, err)
+	require.NoError(t, program.Run())
+
+	require.NoError(t, program.RunBackToWrite(11))
+	assert.Equal(t, state{4, 0, false, 5}, stateOf(t, program))
+
+	require.NoError(t, program.RunBackToWrite(11))
+	assert.Equal(t, state{0, 0, false, 0}, stateOf(t, program))
+
+	assert.True(t, errors.Is(program.RunBackToWrite(11), ErrJournalExhausted))
+	assert.Equal(t, state{0, 0, false, 0}, stateOf(t, program))
+}
+
+func TestRewind(t *testing.T) {
+	program, err := NewIntcodeProgram(accumulator, MustNotInput, MustNotOutput, WithJournal(10))
+	require.NoError(t, err)
+
+	require.NoError(t, program.Step())
+	checkpoint := program.Checkpoint()
+	assert.Equal(t, Checkpoint(1), checkpoint)
+
+	require.NoError(t, program.Run())
+	require.NoError(t, program.Rewind(checkpoint))
+	assert.Equal(t, state{4, 0, false, 5}, stateOf(t, program))
+	assert.Equal(t, checkpoint, program.Checkpoint())
+
+	assert.Error(t, program.Rewind(checkpoint+1))
+	assert.Equal(t, state{4, 0, false, 5}, stateOf(t, program))
+}
+
+func TestJournalCapacity(t *testing.T) {
+	program, err := NewIntcodeProgram(accumulator, MustNotInput, MustNotOutput, WithJournal(2))
+	require.NoError(t, err)
+	require.NoError(t, program.Run())
+
+	assert.True(t, errors.Is(program.Rewind(0), ErrJournalExhausted))
+	assert.True(t, errors.Is(program.RunBackToWrite(11), ErrJournalExhausted))
+
+	require.NoError(t, program.StepBack())
+	require.NoError(t, program.StepBack())
+	assert.Equal(t, state{8, 0, false, 10}, stateOf(t, program))
+	assert.True(t, errors.Is(program.StepBack(), ErrJournalExhausted))
+}
+
+func TestStepBackAfterFailingInstruction(t *testing.T) {
+	// the instruction at 4 fails storing to a negative position
+	program, err := NewIntcodeProgram("1101,1,2,9,1101,5,0,-1,99,0", MustNotInput, MustNotOutput, WithJournal(1))
+	require.NoError(t, err)
+
+	require.NoError(t, program.Step())
+	require.Error(t, program.Step())
+	assert.Equal(t, Checkpoint(1), program.Checkpoint())
+	assert.Equal(t, 4, program.InstructionPointer())
+
+	require.NoError(t, program.StepBack())
+	assert.Equal(t, 0, program.InstructionPointer())
+	assert.Equal(t, 0, program.Memory()[9])
+	assert.Equal(t, Checkpoint(0), program.Checkpoint())
+	assert.True(t, errors.Is(program.StepBack(), ErrJournalExhausted))
+}
+
+func TestWithoutJournal(t *testing.T) {
+	program, err := NewIntcodeProgram(accumulator, MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+	require.NoError(t, program.Run())
+
+	assert.Equal(t, ErrNoJournal, program.StepBack())
+	assert.Equal(t, ErrNoJournal, program.RunBackToWrite(11))
+	assert.Equal(t, ErrNoJournal, program.Rewind(0))
+}
@@ -0,0 +1,108 @@
+package intcode
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoMetrics is returned when reading the metrics of a program without metrics
+var ErrNoMetrics = errors.New("intcode program has no metrics, enable them with WithMetrics")
+
+// Metrics holds the counters of the execution of a program
+type Metrics struct {
+	// Instructions is the number of instructions executed
+	Instructions int
+	// Inputs is the number of inputs consumed and Outputs the number of outputs produced
+	Inputs  int
+	Outputs int
+	// MemoryHighWater is the highest number of memory cells held by the program, which is one
+	// more than the highest position that has been accessed
+	MemoryHighWater int
+	// InputWait is the total time blocked waiting for inputs and OutputWait writing outputs
+	InputWait  time.Duration
+	OutputWait time.Duration
+}
+
+// metrics collects the metrics of a program. Its counters are updated atomically so that they
+// can be read from any goroutine while the program runs.
+type metrics struct {
+	NopObserver
+
+	instructions    atomic.Int64
+	inputs          atomic.Int64
+	outputs         atomic.Int64
+	memoryHighWater atomic.Int64
+	inputWait       atomic.Int64
+	outputWait      atomic.Int64
+}
+
+// WithMetrics collects the metrics of the program, which can be read with Metrics at any time
+// and from any goroutine, even while the program runs
+func WithMetrics() Option {
+	return func(i *Intcode) {
+		i.metrics = &metrics{}
+		i.metrics.access(i.program.Size() - 1)
+		i.program.AddObserver(i.metrics)
+	}
+}
+
+// reset zeroes the counters of a program whose memory holds size cells
+func (m *metrics) reset(size int) {
+	m.instructions.Store(0)
+	m.inputs.Store(0)
+	m.outputs.Store(0)
+	m.memoryHighWater.Store(int64(size))
+	m.inputWait.Store(0)
+	m.outputWait.Store(0)
+}
+
+// access raises the memory high-water mark to hold position
+func (m *metrics) access(position int) {
+	size := int64(position) + 1
+	for {
+		current := m.memoryHighWater.Load()
+		if size <= current || m.memoryHighWater.CompareAndSwap(current, size) {
+			return
+		}
+	}
+}
+
+// OnFetch raises the memory high-water mark
+func (m *metrics) OnFetch(position, value int) {
+	m.access(position)
+}
+
+// OnStore raises the memory high-water mark
+func (m *metrics) OnStore(position, previous, value int) {
+	m.access(position)
+}
+
+// OnInput counts an input and the time spent waiting for it
+func (m *metrics) OnInput(value int, wait time.Duration) {
+	m.inputs.Add(1)
+	m.inputWait.Add(int64(wait))
+}
+
+// OnOutput counts an output and the time spent writing it
+func (m *metrics) OnOutput(value int, wait time.Duration) {
+	m.outputs.Add(1)
+	m.outputWait.Add(int64(wait))
+}
+
+// Metrics returns the metrics of the program, or ErrNoMetrics if it was not created WithMetrics
+func (i *Intcode) Metrics() (Metrics, error) {
+	if i.metrics == nil {
+		return Metrics{}, ErrNoMetrics
+	}
+
+	m := i.metrics
+	return Metrics{
+		Instructions:    int(m.instructions.Load()),
+		Inputs:          int(m.inputs.Load()),
+		Outputs:         int(m.outputs.Load()),
+		MemoryHighWater: int(m.memoryHighWater.Load()),
+		InputWait:       time.Duration(m.inputWait.Load()),
+		OutputWait:      time.Duration(m.outputWait.Load()),
+	}, nil
+}
@@ -0,0 +1,213 @@
+// Package metrics exports the metrics of Intcode machines in the Prometheus text format
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+// contentType is the content type of the Prometheus text format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Source is a machine whose metrics can be read while it runs, such as an *intcode.Intcode
+// created WithMetrics
+type Source interface {
+	Metrics() (intcode.Metrics, error)
+}
+
+// Machine is a source of metrics identified by its name, which labels its samples
+type Machine struct {
+	Name   string
+	Source Source
+}
+
+// family is a metric of the machines, whose samples are labelled by machine
+type family struct {
+	name  string
+	kind  string
+	help  string
+	value func(m intcode.Metrics) float64
+}
+
+var families = []family{
+	{
+		name:  "intcode_instructions_total",
+		kind:  "counter",
+		help:  "Number of instructions executed.",
+		value: func(m intcode.Metrics) float64 { return float64(m.Instructions) },
+	},
+	{
+		name:  "intcode_inputs_total",
+		kind:  "counter",
+		help:  "Number of inputs consumed.",
+		value: func(m intcode.Metrics) float64 { return float64(m.Inputs) },
+	},
+	{
+		name:  "intcode_outputs_total",
+		kind:  "counter",
+		help:  "Number of outputs produced.",
+		value: func(m intcode.Metrics) float64 { return float64(m.Outputs) },
+	},
+	{
+		name:  "intcode_memory_high_water_cells",
+		kind:  "gauge",
+		help:  "Highest number of memory cells held.",
+		value: func(m intcode.Metrics) float64 { return float64(m.MemoryHighWater) },
+	},
+	{
+		name:  "intcode_input_wait_seconds_total",
+		kind:  "counter",
+		help:  "Time blocked waiting for inputs.",
+		value: func(m intcode.Metrics) float64 { return m.InputWait.Seconds() },
+	},
+	{
+		name:  "intcode_output_wait_seconds_total",
+		kind:  "counter",
+		help:  "Time blocked writing outputs.",
+		value: func(m intcode.Metrics) float64 { return m.OutputWait.Seconds() },
+	},
+}
+
+// Write writes the metrics of machines to w in the Prometheus text format, with the samples
+// of each metric in the order of machines
+func Write(w io.Writer, machines ...Machine) error {
+	all := make([]intcode.Metrics, len(machines))
+	for i, machine := range machines {
+		m, err := machine.Source.Metrics()
+		if err != nil {
+			return fmt.Errorf("machine %s: %w", machine.Name, err)
+		}
+		all[i] = m
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.kind)
+		for i, machine := range machines {
+			value := strconv.FormatFloat(f.value(all[i]), 'f', -1, 64)
+			fmt.Fprintf(bw, "%s{machine=\"%s\"} %s\n", f.name, escape(machine.Name), value)
+		}
+	}
+	return bw.Flush()
+}
+
+// escape escapes a label value of the Prometheus text format
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// Exporter holds the machines whose metrics are exported, which can be added and removed while
+// the metrics are written or served
+type Exporter struct {
+	mutex    sync.Mutex
+	machines []Machine
+}
+
+// NewExporter returns an exporter without machines
+func NewExporter() *Exporter {
+	return &Exporter{}
+}
+
+// Add adds a machine whose metrics are exported, which fails if there is already a machine
+// with the same name
+func (e *Exporter) Add(name string, source Source) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, machine := range e.machines {
+		if machine.Name == name {
+			return fmt.Errorf("duplicated machine %s", name)
+		}
+	}
+	e.machines = append(e.machines, Machine{Name: name, Source: source})
+	return nil
+}
+
+// Remove removes the machine with the given name, if any
+func (e *Exporter) Remove(name string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for i, machine := range e.machines {
+		if machine.Name == name {
+			e.machines = append(e.machines[:i:i], e.machines[i+1:]...)
+			return
+		}
+	}
+}
+
+// Machines returns the machines of the exporter in the order they were added
+func (e *Exporter) Machines() []Machine {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]Machine(nil), e.machines...)
+}
+
+// Write writes the metrics of the machines of the exporter to w
+func (e *Exporter) Write(w io.Writer) error {
+	return Write(w, e.Machines()...)
+}
+
+// WriteFile writes the metrics of the machines of the exporter to filename, replacing it
+// atomically so that a collector reading it never sees a partial file
+func (e *Exporter) WriteFile(filename string) error {
+	tmp := filename + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	err = e.Write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// ServeHTTP serves the metrics of the machines of the exporter
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var b strings.Builder
+	if err := e.Write(&b); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = io.WriteString(w, b.String())
+}
+
+// WriteEvery writes the metrics of the machines of the exporter to filename every interval,
+// until stop is closed, when they are written one last time
+func (e *Exporter) WriteEvery(filename string, interval time.Duration, stop <-chan struct{}) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := e.WriteFile(filename); err != nil {
+				return err
+			}
+		case <-stop:
+			return e.WriteFile(filename)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+// source is a Source returning fixed metrics
+type source struct {
+	metrics intcode.Metrics
+	err     error
+}
+
+func (s source) Metrics() (intcode.Metrics, error) {
+	return s.metrics, s.err
+}
+
+var amplifier = source{metrics: intcode.Metrics{
+	Instructions:    12345678,
+	Inputs:          2,
+	Outputs:         1,
+	MemoryHighWater: 507,
+	InputWait:       1500 * time.Millisecond,
+	OutputWait:      250 * time.Microsecond,
+}}
+
+const expected = `# HELP intcode_instructions_total Number of instructions executed.
+# TYPE intcode_instructions_total counter
+intcode_instructions_total{machine="A"} 12345678
+intcode_instructions_total{machine="say \"B\"\n"} 0
+# HELP intcode_inputs_total Number of inputs consumed.
+# TYPE intcode_inputs_total counter
+intcode_inputs_total{machine="A"} 2
+intcode_inputs_total{machine="say \"B\"\n"} 0
+# HELP intcode_outputs_total Number of outputs produced.
+# TYPE intcode_outputs_total counter
+intcode_outputs_total{machine="A"} 1
+intcode_outputs_total{machine="say \"B\"\n"} 0
+# HELP intcode_memory_high_water_cells Highest number of memory cells held.
+# TYPE intcode_memory_high_water_cells gauge
+intcode_memory_high_water_cells{machine="A"} 507
+intcode_memory_high_water_cells{machine="say \"B\"\n"} 0
+# HELP intcode_input_wait_seconds_total Time blocked waiting for inputs.
+# TYPE intcode_input_wait_seconds_total counter
+intcode_input_wait_seconds_total{machine="A"} 1.5
+intcode_input_wait_seconds_total{machine="say \"B\"\n"} 0
+# HELP intcode_output_wait_seconds_total Time blocked writing outputs.
+# TYPE intcode_output_wait_seconds_total counter
+intcode_output_wait_seconds_total{machine="A"} 0.00025
+intcode_output_wait_seconds_total{machine="say \"B\"\n"} 0
+`
+
+func TestWrite(t *testing.T) {
+	var b strings.Builder
+	err := Write(&b, Machine{Name: "A", Source: amplifier}, Machine{Name: "say \"B\"\n", Source: source{}})
+	require.NoError(t, err)
+	assert.Equal(t, expected, b.String())
+
+	err = Write(&b, Machine{Name: "C", Source: source{err: errors.New("no metrics")}})
+	assert.EqualError(t, err, "machine C: no metrics")
+}
+
+func TestWriteIntcode(t *testing.T) {
+	program, err := intcode.NewIntcodeProgram("1101,1,2,5,99,0", intcode.MustNotInput, intcode.MustNotOutput, intcode.WithMetrics())
+	require.NoError(t, err)
+	require.NoError(t, program.Run())
+
+	var b strings.Builder
+	require.NoError(t, Write(&b, Machine{Name: "adder", Source: program}))
+	assert.Contains(t, b.String(), "intcode_instructions_total{machine=\"adder\"} 2\n")
+	assert.Contains(t, b.String(), "intcode_memory_high_water_cells{machine=\"adder\"} 6\n")
+}
+
+func TestExporter(t *testing.T) {
+	e := NewExporter()
+	require.NoError(t, e.Add("A", amplifier))
+	require.NoError(t, e.Add("C", source{}))
+	require.NoError(t, e.Add("say \"B\"\n", source{}))
+	assert.EqualError(t, e.Add("A", source{}), "duplicated machine A")
+
+	e.Remove("C")
+	e.Remove("D")
+	var b strings.Builder
+	require.NoError(t, e.Write(&b))
+	assert.Equal(t, expected, b.String())
+}
+
+func TestExporterWriteFile(t *testing.T) {
+	e := NewExporter()
+	require.NoError(t, e.Add("A", amplifier))
+	require.NoError(t, e.Add("say \"B\"\n", source{}))
+
+	filename := filepath.Join(t.TempDir(), "intcode.prom")
+	stop := make(chan struct{})
+	close(stop)
+	require.NoError(t, e.WriteEvery(filename, time.Hour, stop))
+
+	written, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(written))
+	_, err = os.Stat(filename + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExporterServeHTTP(t *testing.T) {
+	e := NewExporter()
+	require.NoError(t, e.Add("A", amplifier))
+	require.NoError(t, e.Add("say \"B\"\n", source{}))
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, contentType, recorder.Header().Get("Content-Type"))
+	assert.Equal(t, expected, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	require.NoError(t, e.Add("C", source{err: errors.New("no metrics")}))
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
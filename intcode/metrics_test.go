@@ -0,0 +1,71 @@
+package intcode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// farStore stores its input at position 20 and outputs it
+const farStore = "3,20,4,20,99"
+
+func TestMetrics(t *testing.T) {
+	onInput := func() int {
+		time.Sleep(10 * time.Millisecond)
+		return 7
+	}
+	program, err := NewIntcodeProgram(farStore, onInput, func(output int) {}, WithMetrics())
+	require.NoError(t, err)
+
+	m, err := program.Metrics()
+	require.NoError(t, err)
+	assert.Equal(t, Metrics{MemoryHighWater: 5}, m)
+
+	require.NoError(t, program.Run())
+	m, err = program.Metrics()
+	require.NoError(t, err)
+	assert.Equal(t, 3, m.Instructions)
+	assert.Equal(t, 1, m.Inputs)
+	assert.Equal(t, 1, m.Outputs)
+	assert.Equal(t, 21, m.MemoryHighWater)
+	assert.GreaterOrEqual(t, int64(m.InputWait), int64(10*time.Millisecond))
+}
+
+func TestMetricsConcurrentReads(t *testing.T) {
+	program, err := NewIntcodeProgram("1101,0,0,7,1105,1,0,0", MustNotInput, MustNotOutput, WithMetrics())
+	require.NoError(t, err)
+
+	execution := program.Start(context.Background())
+
+	// the program loops forever, so it keeps executing instructions while its metrics are read
+	previous := 0
+	for previous < 1000 {
+		select {
+		case <-execution.Done():
+			require.FailNow(t, "the program stopped", "%v", execution.Wait())
+		default:
+		}
+		m, err := program.Metrics()
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, m.Instructions, previous)
+		previous = m.Instructions
+	}
+	program.Stop()
+	assert.NoError(t, execution.Wait())
+}
+
+func TestNoMetrics(t *testing.T) {
+	program, err := NewIntcodeProgram(farStore, MustNotInput, MustNotOutput)
+	require.NoError(t, err)
+
+	_, err = program.Metrics()
+	assert.Equal(t, ErrNoMetrics, err)
+
+	withMetrics, err := NewIntcodeProgram(farStore, MustNotInput, MustNotOutput, WithMetrics())
+	require.NoError(t, err)
+	_, err = withMetrics.Fork(MustNotInput, MustNotOutput).Metrics()
+	assert.Equal(t, ErrNoMetrics, err)
+}
@@ -0,0 +1,230 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+const (
+	// NATAddress is the address of the NAT device
+	NATAddress = 255
+
+	// emptyQueue is the value received by a computer when its incoming packet queue is empty
+	emptyQueue = -1
+
+	// quantum is the maximum number of instructions that a computer executes before
+	// the next computer is scheduled
+	quantum = 1000
+)
+
+// Packet represents a packet that is sent through the network
+type Packet struct {
+	// Source is the address of the sender
+	Source int
+	// Destination is the address of the receiver
+	Destination int
+	X, Y        int
+}
+
+func (p Packet) String() string {
+	return fmt.Sprintf("%d -> %d: X=%d Y=%d", p.Source, p.Destination, p.X, p.Y)
+}
+
+// nic represents the network interface controller of a computer
+type nic struct {
+	address int
+	intcode *intcode.Intcode
+
+	// queue holds the values that have been received but not read yet
+	queue []int
+	// output holds the values of the packet that is being sent
+	output []int
+	// idle indicates that the computer tried to read from an empty queue
+	// and has not sent nor received anything since then
+	idle bool
+	// yield indicates that the computer must give way to the next one
+	yield bool
+}
+
+// Network represents a network of Intcode computers monitored by a NAT device
+type Network struct {
+	nics []*nic
+	// nat holds the last packet received by the NAT device, nil if none was received
+	nat *Packet
+
+	pending []Packet
+}
+
+// NewNetwork creates a network of size computers running the same program.
+// Each computer is assigned its address, from 0 to size-1, as its first input.
+func NewNetwork(program string, size int) (*Network, error) {
+	if size <= 0 || size > NATAddress {
+		return nil, fmt.Errorf("invalid network size: %d", size)
+	}
+
+	n := &Network{}
+	for address := 0; address < size; address++ {
+		nic := &nic{
+			address: address,
+			queue:   []int{address},
+		}
+
+		intcodeProgram, err := intcode.NewIntcodeProgram(program, nic.onInput(), n.onOutput(nic))
+		if err != nil {
+			return nil, fmt.Errorf("could not create computer %d: %w", address, err)
+		}
+		nic.intcode = intcodeProgram
+
+		n.nics = append(n.nics, nic)
+	}
+
+	return n, nil
+}
+
+// Run runs the network until onPacket returns true, and returns the packet that stopped it.
+// onPacket is called for every packet in the order they are sent, including the ones sent by
+// the NAT device, so it can be used to log the traffic of the network.
+// Computers are scheduled in round-robin order of address, which makes the run reproducible:
+// each one runs until it reads from an empty queue, halts or executes a quantum of instructions.
+// Whenever all computers are idle, the NAT device sends the last packet it received to address 0.
+// It fails once all computers have halted, or if the NAT device has to wake up a halted computer 0,
+// since the network would never make progress again.
+func (n *Network) Run(onPacket func(packet Packet) bool) (Packet, error) {
+	for {
+		for _, nic := range n.nics {
+			err := nic.run()
+			if err != nil {
+				return Packet{}, fmt.Errorf("computer %d failed: %w", nic.address, err)
+			}
+
+			packet, stop, err := n.deliver(onPacket)
+			if err != nil {
+				return Packet{}, err
+			}
+			if stop {
+				return packet, nil
+			}
+		}
+
+		if n.allHalted() {
+			return Packet{}, errors.New("all computers have halted")
+		}
+		if !n.isIdle() {
+			continue
+		}
+
+		if n.nat == nil {
+			return Packet{}, errors.New("network is idle and NAT has not received any packet")
+		}
+		if n.nics[0].intcode.Halted() {
+			return Packet{}, errors.New("network is idle and computer 0 has halted, so NAT cannot wake it up")
+		}
+
+		n.pending = append(n.pending, Packet{
+			Source:      NATAddress,
+			Destination: 0,
+			X:           n.nat.X,
+			Y:           n.nat.Y,
+		})
+		packet, stop, err := n.deliver(onPacket)
+		if err != nil {
+			return Packet{}, err
+		}
+		if stop {
+			return packet, nil
+		}
+	}
+}
+
+// deliver routes all pending packets to their destination
+func (n *Network) deliver(onPacket func(packet Packet) bool) (Packet, bool, error) {
+	for len(n.pending) > 0 {
+		packet := n.pending[0]
+		n.pending = n.pending[1:]
+
+		switch {
+		case packet.Destination == NATAddress:
+			nat := packet
+			n.nat = &nat
+		case packet.Destination >= 0 && packet.Destination < len(n.nics):
+			receiver := n.nics[packet.Destination]
+			receiver.queue = append(receiver.queue, packet.X, packet.Y)
+			receiver.idle = false
+		default:
+			return Packet{}, false, fmt.Errorf("packet sent to unknown address: %v", packet)
+		}
+
+		if onPacket(packet) {
+			return packet, true, nil
+		}
+	}
+	return Packet{}, false, nil
+}
+
+// isIdle returns true if all computers are waiting for a packet that nobody sends
+func (n *Network) isIdle() bool {
+	for _, nic := range n.nics {
+		if !nic.intcode.Halted() && (!nic.idle || len(nic.queue) > 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// allHalted returns true if no computer can run anymore
+func (n *Network) allHalted() bool {
+	for _, nic := range n.nics {
+		if !nic.intcode.Halted() {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *Network) onOutput(nic *nic) func(output int) {
+	return func(output int) {
+		nic.idle = false
+		nic.output = append(nic.output, output)
+		if len(nic.output) < 3 {
+			return
+		}
+
+		n.pending = append(n.pending, Packet{
+			Source:      nic.address,
+			Destination: nic.output[0],
+			X:           nic.output[1],
+			Y:           nic.output[2],
+		})
+		nic.output = nil
+	}
+}
+
+// onInput never blocks: it returns emptyQueue if there is nothing to read
+func (nic *nic) onInput() func() int {
+	return func() int {
+		if len(nic.queue) == 0 {
+			nic.idle = true
+			nic.yield = true
+			return emptyQueue
+		}
+
+		value := nic.queue[0]
+		nic.queue = nic.queue[1:]
+		nic.idle = false
+		return value
+	}
+}
+
+// run runs the computer until it has to yield
+func (nic *nic) run() error {
+	nic.yield = false
+	for steps := 0; steps < quantum && !nic.yield && !nic.intcode.Halted(); steps++ {
+		err := nic.intcode.Step()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoProgram sends the packet (255, address, address) on boot and then
+// forwards to the NAT device every packet that it receives
+const echoProgram = "3,100,104,255,4,100,4,100," +
+	"3,101,1008,101,-1,102,1005,102,8," +
+	"3,103,104,255,4,101,4,103,1105,1,8"
+
+func TestNewNetwork(t *testing.T) {
+	_, err := NewNetwork(echoProgram, 0)
+	assert.Error(t, err)
+
+	_, err = NewNetwork(echoProgram, NATAddress+1)
+	assert.Error(t, err)
+
+	_, err = NewNetwork("invalid", 1)
+	assert.Error(t, err)
+
+	network, err := NewNetwork(echoProgram, 50)
+	require.NoError(t, err)
+	assert.Len(t, network.nics, 50)
+}
+
+func TestRunFirstPacketToNAT(t *testing.T) {
+	network, err := NewNetwork(echoProgram, 3)
+	require.NoError(t, err)
+
+	packet, err := network.Run(func(packet Packet) bool {
+		return packet.Destination == NATAddress
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, Packet{Source: 0, Destination: NATAddress, X: 0, Y: 0}, packet)
+}
+
+func TestRunUntilNATRepeatsY(t *testing.T) {
+	network, err := NewNetwork(echoProgram, 3)
+	require.NoError(t, err)
+
+	var log []Packet
+	lastY := emptyQueue
+	packet, err := network.Run(func(packet Packet) bool {
+		log = append(log, packet)
+		if packet.Source != NATAddress {
+			return false
+		}
+		if packet.Y == lastY {
+			return true
+		}
+		lastY = packet.Y
+		return false
+	})
+	require.NoError(t, err)
+
+	expected := []Packet{
+		{Source: 0, Destination: NATAddress, X: 0, Y: 0},
+		{Source: 1, Destination: NATAddress, X: 1, Y: 1},
+		{Source: 2, Destination: NATAddress, X: 2, Y: 2},
+		{Source: NATAddress, Destination: 0, X: 2, Y: 2},
+		{Source: 0, Destination: NATAddress, X: 2, Y: 2},
+		{Source: NATAddress, Destination: 0, X: 2, Y: 2},
+	}
+	assert.Equal(t, expected, log)
+	assert.Equal(t, expected[len(expected)-1], packet)
+}
+
+func TestRunUnknownAddress(t *testing.T) {
+	network, err := NewNetwork("104,7,104,1,104,2,99", 2)
+	require.NoError(t, err)
+
+	_, err = network.Run(func(packet Packet) bool { return false })
+	assert.Error(t, err)
+}
+
+func TestRunIdleWithoutNATPacket(t *testing.T) {
+	network, err := NewNetwork("3,100,3,101,1105,1,2", 2)
+	require.NoError(t, err)
+
+	_, err = network.Run(func(packet Packet) bool { return false })
+	assert.Error(t, err)
+}
+
+func TestRunAllHalted(t *testing.T) {
+	network, err := NewNetwork("104,255,104,1,104,2,99", 2)
+	require.NoError(t, err)
+
+	var log []Packet
+	_, err = network.Run(func(packet Packet) bool {
+		log = append(log, packet)
+		return false
+	})
+	assert.EqualError(t, err, "all computers have halted")
+	assert.Len(t, log, 2)
+}
+
+func TestRunIdleWithHaltedNATReceiver(t *testing.T) {
+	// computer 0 halts right away while the others send a packet to the NAT device and wait
+	network, err := NewNetwork("3,100,1005,100,7,99,0,104,255,104,1,104,2,3,101,1105,1,13", 2)
+	require.NoError(t, err)
+
+	_, err = network.Run(func(packet Packet) bool { return false })
+	assert.EqualError(t, err, "network is idle and computer 0 has halted, so NAT cannot wake it up")
+}
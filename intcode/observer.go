@@ -0,0 +1,52 @@
+package intcode
+
+import (
+	"time"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+// Observer is notified of the events that happen while an Intcode program runs
+type Observer interface {
+	program.Observer
+
+	// OnInstruction is called whenever an instruction has been executed
+	OnInstruction(event InstructionEvent)
+}
+
+// InstructionEvent describes an instruction that has been executed
+type InstructionEvent struct {
+	// Address is the position of the instruction
+	Address int
+	// Opcode is the opcode of the instruction
+	Opcode int
+	// Next is the position of the instruction pointer after executing the instruction
+	Next int
+}
+
+// NopObserver is an Observer that ignores all events, it can be embedded
+// by observers that are only interested in some of them
+type NopObserver struct{}
+
+// OnFetch does nothing
+func (NopObserver) OnFetch(position, value int) {}
+
+// OnStore does nothing
+func (NopObserver) OnStore(position, previous, value int) {}
+
+// OnInput does nothing
+func (NopObserver) OnInput(value int, wait time.Duration) {}
+
+// OnOutput does nothing
+func (NopObserver) OnOutput(value int, wait time.Duration) {}
+
+// OnInstruction does nothing
+func (NopObserver) OnInstruction(event InstructionEvent) {}
+
+// WithObserver registers an observer that will be notified while the Intcode program runs
+func WithObserver(observer Observer) Option {
+	return func(i *Intcode) {
+		i.program.AddObserver(observer)
+		i.observers = append(i.observers, observer)
+	}
+}
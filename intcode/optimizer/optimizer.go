@@ -0,0 +1,417 @@
+package optimizer
+
+import (
+	"math"
+	"sort"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/analysis"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// Result is a program optimized by Optimize
+type Result struct {
+	// Memory is the memory of the optimized program, which has the same layout as the original one
+	Memory []int
+	// Rewritten are the addresses of the instructions that were rewritten, in increasing order
+	Rewritten []int
+
+	// Folded is the number of instructions whose operands or results were computed statically
+	Folded int
+	// Removed is the number of dead stores and jumps never taken that are skipped
+	Removed int
+	// Threaded is the number of jumps retargeted to the final destination of a chain of jumps
+	Threaded int
+
+	// Unproven are the reasons why some instructions could not be proven not to be modified by
+	// the program, which are left unchanged
+	Unproven []string
+
+	// sizes are the sizes of the rewritten instructions in the original program
+	sizes map[int]int
+}
+
+// operand is the mode and the value of a read parameter
+type operand struct {
+	mode  instruction.Mode
+	value int
+}
+
+// optimizer rewrites the instructions of a program
+type optimizer struct {
+	*facts
+	memory []int
+	// instructions are the instructions being rewritten by address
+	instructions map[int]*analysis.Instruction
+	// rewritten holds the addresses of the instructions that were rewritten
+	rewritten map[int]bool
+	// removable holds the addresses of the instructions that can be skipped
+	removable map[int]bool
+	result    *Result
+}
+
+// Optimize optimizes the program stored in memory with peephole passes on its decoded instructions:
+// constant folding and propagation of the values of moves, elimination of the stores overwritten
+// before being read and threading of jumps. Only the instructions proven not to be modified by
+// the program are rewritten, in place so that the layout of memory is kept, and the rest of the
+// program is left unchanged. No instruction is proven if the control flow of the program or the
+// positions it accesses are not known statically, as any cell may then be modified.
+//
+// Arithmetic is assumed to wrap around on overflow, and the host to only inspect the memory of
+// the program while it reads an input or writes an output.
+func Optimize(memory []int) (*Result, error) {
+	f, err := prove(memory)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &optimizer{
+		facts:        f,
+		memory:       memory,
+		instructions: make(map[int]*analysis.Instruction, len(f.instructions)),
+		rewritten:    make(map[int]bool),
+		removable:    make(map[int]bool),
+		result:       &Result{Unproven: f.unproven, sizes: make(map[int]int)},
+	}
+	for address, ins := range f.instructions {
+		copied := ins
+		copied.Modes = append([]instruction.Mode(nil), ins.Modes...)
+		copied.Parameters = append([]int(nil), ins.Parameters...)
+		o.instructions[address] = &copied
+	}
+
+	blocks := f.graph.SortedBlocks()
+	for _, block := range blocks {
+		o.fold(block)
+	}
+	for _, block := range blocks {
+		o.eliminateDeadStores(block)
+	}
+	for _, block := range blocks {
+		o.skipRemovable(block)
+	}
+	o.threadJumps()
+
+	o.result.Memory = o.encode()
+	return o.result, nil
+}
+
+// fold replaces the operands read in position mode with their values when they are known, either
+// because the cell is never modified or because a previous instruction of block stored a constant
+// or moved another cell into it, and computes the result of arithmetic on constants
+func (o *optimizer) fold(block *analysis.Block) {
+	var known map[int]operand
+	for _, original := range block.Instructions {
+		ins := o.instructions[original.Address]
+		if o.entries[ins.Address] {
+			// control may reach the instruction from other blocks
+			known = make(map[int]operand)
+		}
+
+		if o.rewritable(ins.Address) {
+			folded := false
+			for i, kind := range ins.Definition.Parameters {
+				if kind == instruction.Write || ins.Modes[i] != instruction.Position {
+					continue
+				}
+				if value, ok := o.value(ins.Parameters[i], known); ok {
+					ins.Modes[i], ins.Parameters[i] = value.mode, value.value
+					folded = true
+				}
+			}
+			if o.foldArithmetic(ins) {
+				folded = true
+			}
+			if folded {
+				o.result.Folded++
+				o.rewritten[ins.Address] = true
+			}
+		}
+
+		o.track(ins, known)
+	}
+}
+
+// value returns the operand holding the value of cell, which is known or constant
+func (o *optimizer) value(cell int, known map[int]operand) (operand, bool) {
+	if value, ok := known[cell]; ok {
+		return value, true
+	}
+	if !o.constant(cell) {
+		return operand{}, false
+	}
+	if cell >= len(o.memory) {
+		return operand{mode: instruction.Immediate}, true
+	}
+	return operand{mode: instruction.Immediate, value: o.memory[cell]}, true
+}
+
+// foldArithmetic rewrites an arithmetic instruction on constants into a move of its result,
+// returning true if it was rewritten
+func (o *optimizer) foldArithmetic(ins *analysis.Instruction) bool {
+	if !arithmetic(ins.Opcode) || ins.Modes[0] != instruction.Immediate || ins.Modes[1] != instruction.Immediate {
+		return false
+	}
+	if _, ok := move(ins); ok {
+		return false
+	}
+	a, b := ins.Parameters[0], ins.Parameters[1]
+
+	var result int
+	switch ins.Opcode {
+	case instruction.AddOpcode:
+		result = a + b
+		if (result > a) != (b > 0) {
+			return false
+		}
+	case instruction.MultiplyOpcode:
+		result = a * b
+		if a != 0 && (result/a != b || (a == -1 && b == math.MinInt)) {
+			return false
+		}
+	case instruction.LessThanOpcode:
+		result = boolToInt(a < b)
+	default:
+		result = boolToInt(a == b)
+	}
+
+	o.rewrite(ins, instruction.AddOpcode, []instruction.Mode{instruction.Immediate, instruction.Immediate, ins.Modes[2]},
+		[]int{result, 0, ins.Parameters[2]})
+	return true
+}
+
+// track updates the operands known to be held by cells after ins
+func (o *optimizer) track(ins *analysis.Instruction, known map[int]operand) {
+	last := len(ins.Definition.Parameters) - 1
+	if last < 0 || ins.Definition.Parameters[last] != instruction.Write {
+		return
+	}
+
+	mode, cell := ins.Modes[last], ins.Parameters[last]
+	for held, value := range known {
+		if o.mayWrite(mode, cell, held) || (value.mode == instruction.Position && o.mayWrite(mode, cell, value.value)) {
+			delete(known, held)
+		}
+	}
+	if mode != instruction.Position {
+		return
+	}
+
+	// the values of the parameters of patched instructions are not known
+	if value, ok := move(ins); ok && !o.patched[ins.Address] && value != (operand{mode: instruction.Position, value: cell}) {
+		known[cell] = value
+	}
+}
+
+// mayWrite returns true if writing to the parameter cell in mode may modify target
+func (o *optimizer) mayWrite(mode instruction.Mode, cell, target int) bool {
+	if mode == instruction.Relative {
+		return target >= o.minDynamic
+	}
+	return cell == target
+}
+
+// move returns the operand copied by ins if it is a move, an addition of 0 or a multiplication by 1
+func move(ins *analysis.Instruction) (operand, bool) {
+	identity := map[int]int{instruction.AddOpcode: 0, instruction.MultiplyOpcode: 1}
+	neutral, ok := identity[ins.Opcode]
+	if !ok {
+		return operand{}, false
+	}
+
+	for i := 0; i < 2; i++ {
+		other := 1 - i
+		if ins.Modes[i] != instruction.Immediate || ins.Parameters[i] != neutral {
+			continue
+		}
+		if ins.Modes[other] == instruction.Relative {
+			return operand{}, false
+		}
+		return operand{mode: ins.Modes[other], value: ins.Parameters[other]}, true
+	}
+	return operand{}, false
+}
+
+// eliminateDeadStores finds the arithmetic instructions of block that store a value which is
+// overwritten before being read, marking them as removable
+func (o *optimizer) eliminateDeadStores(block *analysis.Block) {
+	overwritten := make(map[int]bool)
+	for i := len(block.Instructions) - 1; i >= 0; i-- {
+		ins := o.instructions[block.Instructions[i].Address]
+
+		if ins.Opcode == instruction.InputOpcode || ins.Opcode == instruction.OutputOpcode {
+			// the host may inspect memory
+			overwritten = make(map[int]bool)
+			continue
+		}
+		last := len(ins.Definition.Parameters) - 1
+		if arithmetic(ins.Opcode) && ins.Modes[last] == instruction.Position {
+			cell := ins.Parameters[last]
+			if overwritten[cell] && o.rewritable(ins.Address) {
+				// the reads of the store are kept live, as it is only skipped if it is part of a run
+				o.removable[ins.Address] = true
+			}
+			if cell >= 0 {
+				overwritten[cell] = true
+			}
+		}
+
+		// executing the instruction reads its own cells
+		for cell := ins.Address; cell < ins.Next(); cell++ {
+			delete(overwritten, cell)
+		}
+		for i, kind := range ins.Definition.Parameters {
+			if kind == instruction.Write {
+				continue
+			}
+			switch ins.Modes[i] {
+			case instruction.Position:
+				delete(overwritten, ins.Parameters[i])
+			case instruction.Relative:
+				for cell := range overwritten {
+					if cell >= o.minDynamic {
+						delete(overwritten, cell)
+					}
+				}
+			}
+		}
+	}
+}
+
+// skipRemovable replaces each run of removable instructions of block, including jumps that are
+// never taken, by a jump over them when it saves executing instructions
+func (o *optimizer) skipRemovable(block *analysis.Block) {
+	for i := 0; i < len(block.Instructions); i++ {
+		start := o.instructions[block.Instructions[i].Address]
+		if !o.skippable(start) {
+			continue
+		}
+
+		j := i
+		for j+1 < len(block.Instructions) && o.skippable(o.instructions[block.Instructions[j+1].Address]) {
+			j++
+		}
+		end := block.Instructions[j].Next()
+
+		next, ok := o.instructions[end]
+		if j > i || (ok && unconditionalJump(next)) {
+			o.rewrite(start, instruction.JumpIfTrueOpcode, []instruction.Mode{instruction.Immediate, instruction.Immediate},
+				[]int{1, end})
+			o.result.Removed += j - i + 1
+		}
+		i = j
+	}
+}
+
+// skippable returns true if ins can be skipped without changing the behaviour of the program
+func (o *optimizer) skippable(ins *analysis.Instruction) bool {
+	if !o.rewritable(ins.Address) {
+		return false
+	}
+	if o.removable[ins.Address] {
+		return true
+	}
+	taken, known := jumpTaken(ins)
+	return known && !taken
+}
+
+// threadJumps retargets the jumps to unconditional jumps to the final destination of the chain
+func (o *optimizer) threadJumps() {
+	addresses := make([]int, 0, len(o.instructions))
+	for address := range o.instructions {
+		addresses = append(addresses, address)
+	}
+	sort.Ints(addresses)
+
+	for _, address := range addresses {
+		ins := o.instructions[address]
+		if !jump(ins.Opcode) || ins.Modes[1] != instruction.Immediate || !o.rewritable(address) {
+			continue
+		}
+		if taken, known := jumpTaken(ins); known && !taken {
+			continue
+		}
+
+		target := ins.Parameters[1]
+		visited := map[int]bool{address: true}
+		for {
+			next, ok := o.instructions[target]
+			if !ok || visited[target] || !unconditionalJump(next) {
+				break
+			}
+			visited[target] = true
+			target = next.Parameters[1]
+		}
+
+		if target != ins.Parameters[1] {
+			ins.Parameters[1] = target
+			o.rewritten[address] = true
+			o.result.Threaded++
+		}
+	}
+}
+
+// rewrite replaces ins by the instruction with opcode, modes and parameters, which must not be larger
+func (o *optimizer) rewrite(ins *analysis.Instruction, opcode int, modes []instruction.Mode, parameters []int) {
+	definition, _ := instruction.Lookup(opcode)
+	ins.Definition = definition
+	ins.Modes = modes
+	ins.Parameters = parameters
+	o.rewritten[ins.Address] = true
+}
+
+// encode returns the memory of the program with the rewritten instructions
+func (o *optimizer) encode() []int {
+	memory := append([]int(nil), o.memory...)
+	for address := range o.rewritten {
+		o.result.Rewritten = append(o.result.Rewritten, address)
+		o.result.sizes[address] = o.facts.instructions[address].Size()
+
+		ins := o.instructions[address]
+		value, scale := ins.Opcode, 100
+		for _, mode := range ins.Modes {
+			value += int(mode) * scale
+			scale *= 10
+		}
+		memory[address] = value
+		copy(memory[address+1:], ins.Parameters)
+	}
+	sort.Ints(o.result.Rewritten)
+	return memory
+}
+
+// jumpTaken returns whether the conditional jump ins is taken, if its condition is a constant
+func jumpTaken(ins *analysis.Instruction) (taken bool, known bool) {
+	if !jump(ins.Opcode) || ins.Modes[0] != instruction.Immediate {
+		return false, false
+	}
+	if ins.Opcode == instruction.JumpIfTrueOpcode {
+		return ins.Parameters[0] != 0, true
+	}
+	return ins.Parameters[0] == 0, true
+}
+
+// unconditionalJump returns true if ins always jumps to a target known statically
+func unconditionalJump(ins *analysis.Instruction) bool {
+	taken, known := jumpTaken(ins)
+	return known && taken && ins.Modes[1] == instruction.Immediate
+}
+
+func jump(opcode int) bool {
+	return opcode == instruction.JumpIfTrueOpcode || opcode == instruction.JumpIfFalseOpcode
+}
+
+func arithmetic(opcode int) bool {
+	switch opcode {
+	case instruction.AddOpcode, instruction.MultiplyOpcode, instruction.LessThanOpcode, instruction.EqualsOpcode:
+		return true
+	default:
+		return false
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
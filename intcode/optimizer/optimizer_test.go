@@ -0,0 +1,159 @@
+package optimizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/format"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deadStoresProgram stores values in two cells twice before outputting them
+const deadStoresProgram = "1101,1,0,21,1101,2,0,22,1101,3,0,21,1101,4,0,22,4,21,4,22,99"
+
+func optimize(t *testing.T, programString string) (*Result, []int) {
+	memory := parse(t, programString)
+	result, err := Optimize(memory)
+	require.NoError(t, err)
+
+	_, err = result.Validate(memory, [][]int{nil})
+	require.NoError(t, err)
+	return result, memory
+}
+
+func TestOptimizeFoldsConstants(t *testing.T) {
+	// the sum of the constant cells 9 and 10 is stored in 11 and then written
+	result, _ := optimize(t, "1,9,10,11,4,11,99,0,0,5,7,0")
+
+	assert.Equal(t, parse(t, "1101,12,0,11,104,12,99,0,0,5,7,0"), result.Memory)
+	assert.Equal(t, []int{0, 4}, result.Rewritten)
+	assert.Equal(t, 2, result.Folded)
+}
+
+func TestOptimizeFoldsJumpConditions(t *testing.T) {
+	// the jump at 0 depends on the constant cell 12, so it is never taken
+	result, _ := optimize(t, "1005,12,9,104,1,99,0,0,0,104,2,99,0")
+
+	assert.Equal(t, parse(t, "1105,0,9,104,1,99,0,0,0,104,2,99,0"), result.Memory)
+	assert.Equal(t, 1, result.Folded)
+	assert.Equal(t, 0, result.Removed)
+}
+
+func TestOptimizeEliminatesDeadStores(t *testing.T) {
+	result, _ := optimize(t, deadStoresProgram)
+
+	expected := "1105,1,8,21,1101,2,0,22,1101,3,0,21,1101,4,0,22,104,3,104,4,99"
+	assert.Equal(t, parse(t, expected), result.Memory)
+	assert.Equal(t, []int{0, 16, 18}, result.Rewritten)
+	assert.Equal(t, 2, result.Folded)
+	assert.Equal(t, 2, result.Removed)
+}
+
+func TestOptimizeThreadsJumps(t *testing.T) {
+	result, _ := optimize(t, "1105,1,4,99,1105,1,7,104,1,99")
+
+	assert.Equal(t, parse(t, "1105,1,7,99,1105,1,7,104,1,99"), result.Memory)
+	assert.Equal(t, 1, result.Threaded)
+}
+
+func TestOptimizeSkipsJumpsNeverTaken(t *testing.T) {
+	// the jump at 0 is never taken and is followed by a jump to 8
+	result, _ := optimize(t, "1106,1,100,1105,1,8,99,99,104,5,99")
+
+	assert.Equal(t, parse(t, "1105,1,8,1105,1,8,99,99,104,5,99"), result.Memory)
+	assert.Equal(t, 1, result.Removed)
+	assert.Equal(t, 1, result.Threaded)
+}
+
+func TestOptimizeKeepsPatchedInstructions(t *testing.T) {
+	// the instruction at 0 stores the value written by the instruction at 4
+	result, _ := optimize(t, "1101,5,10,5,104,0,99")
+
+	assert.Equal(t, parse(t, "1101,15,0,5,104,0,99"), result.Memory)
+	assert.Equal(t, []int{0}, result.Rewritten)
+}
+
+func TestOptimizeKeepsPatchingStores(t *testing.T) {
+	// the store at 0 patches the instruction at 4, which is executed before the store at 8 patches it again
+	result, memory := optimize(t, "1101,5,0,6,1101,0,0,20,1101,9,0,6,4,20,99")
+
+	assert.Equal(t, memory, result.Memory)
+	assert.Empty(t, result.Rewritten)
+}
+
+func TestOptimizeKeepsLayout(t *testing.T) {
+	result, memory := optimize(t, callingProgram)
+
+	assert.Len(t, result.Memory, len(memory))
+}
+
+func TestOptimizeKeepsInstructionsReachedInRelativeMode(t *testing.T) {
+	// the relative write at 15 may modify the instructions from 15, but not the ones before
+	result, _ := optimize(t, "1105,1,7,3,4,0,0,1,3,4,5,4,5,109,15,21101,1,1,0,99")
+
+	assert.Equal(t, parse(t, "1105,1,7,3,4,0,0,1101,7,0,5,104,7,109,15,21101,1,1,0,99"), result.Memory)
+	assert.Equal(t, []int{7, 11}, result.Rewritten)
+	assert.Equal(t, []string{"relative mode accesses may reach the instructions from position 15"}, result.Unproven)
+}
+
+func TestOptimizeKeepsUnprovenProgram(t *testing.T) {
+	result, memory := optimize(t, "1101,1,1,4,99")
+
+	assert.Equal(t, memory, result.Memory)
+	assert.Empty(t, result.Rewritten)
+	assert.Equal(t, []string{"the program modifies the instruction at 4"}, result.Unproven)
+}
+
+func TestOptimizeDayInputs(t *testing.T) {
+	testCases := map[string][][]int{
+		"../../day05/day05.txt": {{1}, {5}},
+		"../../day09/day09.txt": {{1}},
+		"../../day15/day15.txt": {nil},
+	}
+
+	for filename, runs := range testCases {
+		t.Run(filename, func(t *testing.T) {
+			p, err := format.Load(filename)
+			require.NoError(t, err)
+
+			result, err := Optimize(p.Memory)
+			require.NoError(t, err)
+			assert.NotEmpty(t, result.Unproven)
+
+			_, err = result.Validate(p.Memory, runs)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// fuzzSteps bounds the instructions executed by each program when fuzzing
+const fuzzSteps = 1 << 12
+
+// FuzzOptimize optimizes random programs and checks that the optimized programs behave as the original ones
+func FuzzOptimize(f *testing.F) {
+	f.Add("1,9,10,11,4,11,99,0,0,5,7,0", 0)
+	f.Add(deadStoresProgram, 0)
+	f.Add("1106,1,100,1105,1,8,99,99,104,5,99", 0)
+	f.Add("3,12,1008,12,8,13,1005,13,11,104,0,99,0,0", 8)
+	f.Add(callingProgram, 0)
+
+	f.Fuzz(func(t *testing.T, programString string, input int) {
+		memory, err := program.Parse(programString)
+		if err != nil {
+			return
+		}
+		result, err := Optimize(memory)
+		if err != nil {
+			return
+		}
+		assert.Len(t, result.Memory, len(memory))
+
+		_, err = result.validate(memory, [][]int{{input, input}}, fuzzSteps)
+		if err != nil && !errors.Is(err, errNotHalting) {
+			t.Fatalf("optimized program differs: %v", err)
+		}
+	})
+}
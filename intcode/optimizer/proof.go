@@ -0,0 +1,314 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/analysis"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// facts holds what is proven about the code of a program and its memory accesses
+type facts struct {
+	graph *analysis.Graph
+	// instructions are the instructions reachable through the control flow graph by address
+	instructions map[int]analysis.Instruction
+	// code holds the memory cells of the instructions
+	code map[int]bool
+	// reads and writes hold the memory cells accessed in position mode
+	reads  map[int]bool
+	writes map[int]bool
+	// minDynamic is the lowest memory cell that may be accessed at a position not known
+	// statically, such as in relative mode, math.MaxInt if there are none
+	minDynamic int
+	// unproven are the reasons why some instructions could not be proven not to be modified
+	unproven []string
+	// patched holds the addresses of the instructions whose parameters are written by the program
+	patched map[int]bool
+	// entries holds the addresses of the instructions where control may enter a block, which
+	// are the first instruction of a block or instructions shared by several blocks
+	entries map[int]bool
+	// overlapping holds the addresses of the instructions which share cells with other instructions
+	overlapping map[int]bool
+}
+
+// prove proves which instructions of the program stored in memory are not modified, except for
+// the values of some of their parameters, and bounds the cells it accesses in relative mode.
+// The instructions that the program may write to are left out of the proof, and none of them
+// is proven if the control flow of the program or the positions it accesses are not known
+// statically, as any cell may then be modified.
+func prove(memory []int) (*facts, error) {
+	graph, err := analysis.Build(memory)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &facts{
+		graph:        graph,
+		instructions: make(map[int]analysis.Instruction),
+		code:         make(map[int]bool),
+		reads:        make(map[int]bool),
+		writes:       make(map[int]bool),
+		minDynamic:   math.MaxInt,
+		patched:      make(map[int]bool),
+		entries:      make(map[int]bool),
+		overlapping:  make(map[int]bool),
+	}
+
+	for _, block := range graph.SortedBlocks() {
+		for i, ins := range block.Instructions {
+			// write parameters in immediate mode are stored like in position mode, which is the
+			// only mode of direct writes that the proof and the passes need to consider
+			for p := range ins.Modes {
+				ins.Modes[p] = ins.Addressing(p)
+			}
+			if _, ok := f.instructions[ins.Address]; ok || i == 0 {
+				f.entries[ins.Address] = true
+			}
+			f.instructions[ins.Address] = ins
+		}
+	}
+
+	// owners holds the address of the instruction of each cell
+	owners := make(map[int]int)
+	for _, ins := range f.instructions {
+		for cell := ins.Address; cell < ins.Next(); cell++ {
+			if owner, ok := owners[cell]; ok {
+				f.overlapping[owner] = true
+				f.overlapping[ins.Address] = true
+			}
+			owners[cell] = ins.Address
+			f.code[cell] = true
+		}
+	}
+
+	for _, ins := range f.instructions {
+		for i, kind := range ins.Definition.Parameters {
+			if ins.Modes[i] != instruction.Position {
+				continue
+			}
+			if kind == instruction.Write {
+				f.writes[ins.Parameters[i]] = true
+			} else {
+				f.reads[ins.Parameters[i]] = true
+			}
+		}
+	}
+
+	lowest, err := minRelative(graph)
+	if err != nil {
+		f.unprove("%v", err)
+	} else {
+		f.minDynamic = lowest
+		for cell := range f.code {
+			if cell >= f.minDynamic {
+				f.unproven = append(f.unproven,
+					fmt.Sprintf("relative mode accesses may reach the instructions from position %d", f.minDynamic))
+				break
+			}
+		}
+	}
+	f.checkControl()
+	f.checkPatches()
+
+	return f, nil
+}
+
+// unprove records why the program could not be proven not to modify any cell
+func (f *facts) unprove(format string, a ...interface{}) {
+	f.unproven = append(f.unproven, fmt.Sprintf(format, a...))
+	f.minDynamic = 0
+}
+
+// checkControl checks that control never leaves the instructions of the control flow graph,
+// which happens through jumps with targets not known statically or by flowing to a value that
+// is not an instruction but that the program may turn into one
+func (f *facts) checkControl() {
+	for _, block := range f.graph.SortedBlocks() {
+		if block.Indirect {
+			f.unprove("jump with a target not known statically at %d", block.Last().Address)
+		}
+		if !block.Invalid {
+			continue
+		}
+
+		// the program fails when control reaches the invalid value, unless it modifies it first
+		invalid := block.Start
+		if len(block.Instructions) > 0 {
+			invalid = block.Last().Next()
+		}
+		for cell := invalid; cell < invalid+maxInstructionSize; cell++ {
+			if f.writes[cell] {
+				f.unprove("control flows to an instruction modified by the program at %d", invalid)
+				break
+			}
+		}
+	}
+}
+
+// maxInstructionSize is the number of cells of the largest instruction
+const maxInstructionSize = 4
+
+// checkPatches finds the instructions whose parameters are written by the program, which may
+// only patch the values of parameters in immediate mode of instructions other than jumps and
+// relative base adjustments. Patching any other parameter changes the control flow of the
+// program or the positions it accesses at runtime.
+func (f *facts) checkPatches() {
+	addresses := make([]int, 0, len(f.instructions))
+	for address := range f.instructions {
+		addresses = append(addresses, address)
+	}
+	sort.Ints(addresses)
+
+	for _, address := range addresses {
+		ins := f.instructions[address]
+		for cell := ins.Address; cell < ins.Next(); cell++ {
+			if !f.writes[cell] {
+				continue
+			}
+			f.patched[ins.Address] = true
+
+			switch {
+			case cell == ins.Address:
+				f.unprove("the program modifies the instruction at %d", ins.Address)
+			case ins.Opcode == instruction.JumpIfTrueOpcode, ins.Opcode == instruction.JumpIfFalseOpcode,
+				ins.Opcode == instruction.AdjustRelativeBaseOpcode:
+				f.unprove("the program modifies the parameters of the instruction at %d", ins.Address)
+			case ins.Modes[cell-ins.Address-1] != instruction.Immediate:
+				f.unprove("the program computes the positions accessed by the instruction at %d", ins.Address)
+			default:
+				continue
+			}
+			break
+		}
+	}
+}
+
+// callSite is a call to a function with the offset of the relative base of the caller
+type callSite struct {
+	callee int
+	offset int
+}
+
+// minRelative returns the lowest memory cell that can be accessed in relative mode. The relative
+// base of each function is tracked as an offset from its value when the function is entered, which
+// must be the same through every path and be restored when the function returns.
+func minRelative(graph *analysis.Graph) (int, error) {
+	lowest := make(map[int]int, len(graph.Functions))
+	calls := make(map[int][]callSite, len(graph.Functions))
+	for entry, function := range graph.Functions {
+		var err error
+		lowest[entry], calls[entry], err = relativeOffsets(graph, function)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// the relative base when each function is entered is bounded from below by relaxing the
+	// call sites, which fails to converge if recursive calls keep decreasing it
+	bases := map[int]int{graph.Entry: 0}
+	for round := 0; ; round++ {
+		changed := false
+		for caller, sites := range calls {
+			base, ok := bases[caller]
+			if !ok {
+				continue
+			}
+			for _, site := range sites {
+				if calleeBase, ok := bases[site.callee]; !ok || base+site.offset < calleeBase {
+					bases[site.callee] = base + site.offset
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+		if round == len(graph.Functions) {
+			return 0, fmt.Errorf("recursive calls decrease the relative base")
+		}
+	}
+
+	lowestCell := math.MaxInt
+	for entry, base := range bases {
+		if lowest[entry] != math.MaxInt && base+lowest[entry] < lowestCell {
+			lowestCell = base + lowest[entry]
+		}
+	}
+	return lowestCell, nil
+}
+
+// relativeOffsets returns the lowest offset from the relative base of function when it is
+// entered that is accessed in relative mode, and the calls that it makes
+func relativeOffsets(graph *analysis.Graph, function *analysis.Function) (int, []callSite, error) {
+	lowest := math.MaxInt
+	var calls []callSite
+
+	offsets := map[int]int{function.Entry: 0}
+	worklist := []int{function.Entry}
+	for len(worklist) > 0 {
+		start := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+		block := graph.Blocks[start]
+
+		offset := offsets[start]
+		for _, ins := range block.Instructions {
+			for i, mode := range ins.Modes {
+				if mode == instruction.Relative && offset+ins.Parameters[i] < lowest {
+					lowest = offset + ins.Parameters[i]
+				}
+			}
+
+			if ins.Opcode == instruction.AdjustRelativeBaseOpcode {
+				if ins.Modes[0] != instruction.Immediate {
+					return 0, nil, fmt.Errorf("relative base adjusted by a value not known statically at %d", ins.Address)
+				}
+				offset += ins.Parameters[0]
+			}
+		}
+
+		if block.Returns && offset != 0 {
+			return 0, nil, fmt.Errorf("function %d returns without restoring its relative base", function.Entry)
+		}
+
+		for _, edge := range block.Successors {
+			if edge.Kind == analysis.Call {
+				calls = append(calls, callSite{callee: edge.To, offset: offset})
+				continue
+			}
+
+			previous, ok := offsets[edge.To]
+			if !ok {
+				offsets[edge.To] = offset
+				worklist = append(worklist, edge.To)
+				continue
+			}
+			if previous != offset {
+				return 0, nil, fmt.Errorf("the relative base at %d depends on the path taken", edge.To)
+			}
+		}
+	}
+	return lowest, calls, nil
+}
+
+// constant returns true if the value of cell is never modified by the program
+func (f *facts) constant(cell int) bool {
+	return cell >= 0 && !f.writes[cell] && cell < f.minDynamic
+}
+
+// rewritable returns true if the instruction at address can be rewritten: it is not patched
+// nor accessed at positions not known statically, it does not overlap other instructions and
+// none of its cells are read as data
+func (f *facts) rewritable(address int) bool {
+	ins, ok := f.instructions[address]
+	if !ok || f.patched[address] || f.overlapping[address] || ins.Next() > f.minDynamic {
+		return false
+	}
+	for cell := ins.Address; cell < ins.Next(); cell++ {
+		if f.reads[cell] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package optimizer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+// callingProgram calls a function which stores 5 in its frame and returns
+const callingProgram = "109,100,21101,9,0,0,1105,1,10,99,109,2,21101,5,0,-1,109,-2,2105,1,0"
+
+func parse(t *testing.T, programString string) []int {
+	memory, err := program.Parse(programString)
+	require.NoError(t, err)
+	return memory
+}
+
+func TestProve(t *testing.T) {
+	f, err := prove(parse(t, callingProgram))
+	require.NoError(t, err)
+
+	assert.Len(t, f.instructions, 8)
+	assert.Equal(t, 100, f.minDynamic)
+	assert.Empty(t, f.patched)
+	assert.True(t, f.rewritable(2))
+}
+
+func TestProvePositionAccesses(t *testing.T) {
+	f, err := prove(parse(t, "1,9,10,11,4,11,99,0,0,5,7,0"))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[int]bool{9: true, 10: true, 11: true}, f.reads)
+	assert.Equal(t, map[int]bool{11: true}, f.writes)
+	assert.Equal(t, math.MaxInt, f.minDynamic)
+	assert.True(t, f.constant(9))
+	assert.True(t, f.constant(100))
+	assert.False(t, f.constant(11))
+	assert.False(t, f.constant(-1))
+}
+
+func TestProvePatchedValues(t *testing.T) {
+	// the instruction at 0 stores the value written by the instruction at 4
+	f, err := prove(parse(t, "1101,5,10,5,104,0,99"))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[int]bool{4: true}, f.patched)
+	assert.True(t, f.rewritable(0))
+	assert.False(t, f.rewritable(4))
+}
+
+func TestProveReadCode(t *testing.T) {
+	// the instruction at 4 outputs the first parameter of the instruction at 0
+	f, err := prove(parse(t, "1101,5,10,20,4,1,99"))
+	require.NoError(t, err)
+
+	assert.False(t, f.rewritable(0))
+	assert.True(t, f.rewritable(4))
+}
+
+func TestProveOverlappingInstructions(t *testing.T) {
+	// the jump at 0 may go to 6, which is the last parameter of the instruction at 3
+	f, err := prove(parse(t, "1005,20,6,1101,1,1,104,99,99"))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[int]bool{3: true, 6: true, 7: true}, f.overlapping)
+	assert.False(t, f.rewritable(3))
+	assert.True(t, f.rewritable(0))
+}
+
+func TestProveInvalidInstruction(t *testing.T) {
+	// the program fails when the jump at 0 reaches 3, which it never modifies
+	f, err := prove(parse(t, "1105,1,3,98"))
+	require.NoError(t, err)
+
+	assert.Empty(t, f.unproven)
+	assert.True(t, f.rewritable(0))
+}
+
+func TestProveUnproven(t *testing.T) {
+	testCases := map[string]struct {
+		program    string
+		reason     string
+		minDynamic int
+	}{
+		"modified instruction": {
+			program: "1101,1,1,4,99",
+			reason:  "the program modifies the instruction at 4",
+		},
+		"modified jump": {
+			program: "1101,7,0,5,1105,1,8,99,99",
+			reason:  "the program modifies the parameters of the instruction at 4",
+		},
+		"computed position": {
+			program: "1101,9,0,5,4,0,99,0,0,42",
+			reason:  "the program computes the positions accessed by the instruction at 4",
+		},
+		"indirect jump": {
+			program: "105,1,4,99,3",
+			reason:  "jump with a target not known statically at 0",
+		},
+		"modified invalid instruction": {
+			program: "1101,1,1100,4,0,1,1,9,99,0",
+			reason:  "control flows to an instruction modified by the program at 4",
+		},
+		"relative base not known": {
+			program: "9,3,99,5",
+			reason:  "relative base adjusted by a value not known statically at 0",
+		},
+		"relative access to instructions": {
+			program:    "21101,1,1,1,99",
+			reason:     "relative mode accesses may reach the instructions from position 1",
+			minDynamic: 1,
+		},
+		"unbalanced return": {
+			program: "109,100,21101,9,0,0,1105,1,10,99,109,1,2105,1,-1",
+			reason:  "function 10 returns without restoring its relative base",
+		},
+		"decreasing recursion": {
+			program: "109,100,21101,9,0,0,1105,1,10,99,109,-1,21101,19,0,0,1105,1,10,109,1,2105,1,0",
+			reason:  "recursive calls decrease the relative base",
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			f, err := prove(parse(t, test.program))
+			require.NoError(t, err)
+			assert.Equal(t, []string{test.reason}, f.unproven)
+			assert.Equal(t, test.minDynamic, f.minDynamic)
+			assert.False(t, f.rewritable(0))
+		})
+	}
+}
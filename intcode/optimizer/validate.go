@@ -0,0 +1,141 @@
+package optimizer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+// maxSteps is the maximum number of instructions executed by each run of a program when validating
+const maxSteps = 1 << 26
+
+// errNotHalting is returned when the original program does not halt within the maximum number of instructions
+var errNotHalting = errors.New("original program did not halt")
+
+// errMissingInput stops a program which expects more inputs than given
+var errMissingInput = errors.New("program expects more inputs than given")
+
+// Comparison compares the runs of the original and the optimized programs
+type Comparison struct {
+	// OriginalSteps and OptimizedSteps are the instructions executed by all the runs of each program
+	OriginalSteps  int
+	OptimizedSteps int
+}
+
+// run is the outcome of running a program
+type run struct {
+	outputs []int
+	err     error
+	cells   map[int]int
+	steps   int
+}
+
+// Validate runs the original program and its optimization with each of runs as inputs and checks
+// that they behave the same: they write the same outputs, stop with the same error or waiting for
+// the same input, and hold the same memory once they stop except for the rewritten instructions.
+func (r *Result) Validate(original []int, runs [][]int) (Comparison, error) {
+	return r.validate(original, runs, maxSteps)
+}
+
+// validate validates the optimization running each program for at most steps instructions
+func (r *Result) validate(original []int, runs [][]int, steps int) (Comparison, error) {
+	var comparison Comparison
+	for i, inputs := range runs {
+		expected := execute(original, inputs, steps)
+		if expected.steps == steps {
+			return comparison, fmt.Errorf("run %d: %w within %d instructions", i+1, errNotHalting, steps)
+		}
+		actual := execute(r.Memory, inputs, steps)
+
+		if err := r.compare(expected, actual); err != nil {
+			return comparison, fmt.Errorf("run %d: %w", i+1, err)
+		}
+		comparison.OriginalSteps += expected.steps
+		comparison.OptimizedSteps += actual.steps
+	}
+	return comparison, nil
+}
+
+// compare returns an error describing the first difference between the expected and the actual runs
+func (r *Result) compare(expected, actual run) error {
+	for i := 0; i < len(expected.outputs) || i < len(actual.outputs); i++ {
+		switch {
+		case i >= len(actual.outputs):
+			return fmt.Errorf("output %d: expected %d, got none", i, expected.outputs[i])
+		case i >= len(expected.outputs):
+			return fmt.Errorf("output %d: unexpected %d", i, actual.outputs[i])
+		case expected.outputs[i] != actual.outputs[i]:
+			return fmt.Errorf("output %d: expected %d, got %d", i, expected.outputs[i], actual.outputs[i])
+		}
+	}
+
+	if fmt.Sprint(expected.err) != fmt.Sprint(actual.err) {
+		return fmt.Errorf("expected error %v, got %v", expected.err, actual.err)
+	}
+
+	rewritten := make(map[int]bool)
+	for address, size := range r.sizes {
+		for cell := address; cell < address+size; cell++ {
+			rewritten[cell] = true
+		}
+	}
+	for _, cell := range positions(expected.cells, actual.cells) {
+		e, a := expected.cells[cell], actual.cells[cell]
+		if e != a && !rewritten[cell] {
+			return fmt.Errorf("memory[%d]: expected %d, got %d", cell, e, a)
+		}
+	}
+	return nil
+}
+
+// execute runs the program stored in memory with inputs until it halts, fails, expects more inputs
+// than given or executes maxSteps instructions
+func execute(memory []int, inputs []int, maxSteps int) run {
+	var r run
+	missingInput := false
+
+	onInput := func() int {
+		if len(inputs) == 0 {
+			missingInput = true
+			return 0
+		}
+		input := inputs[0]
+		inputs = inputs[1:]
+		return input
+	}
+	onOutput := func(output int) {
+		r.outputs = append(r.outputs, output)
+	}
+	program := intcode.NewIntcodeFromState(memory, 0, 0, onInput, onOutput)
+
+	for ; !program.Halted() && r.steps < maxSteps; r.steps++ {
+		if r.err = program.Step(); r.err != nil {
+			break
+		}
+		if missingInput {
+			r.err = errMissingInput
+			break
+		}
+	}
+	r.cells = program.Cells()
+	return r
+}
+
+// positions returns the sorted positions set in any of cells, as memory is compared cell by cell
+// without copying it densely
+func positions(cells ...map[int]int) []int {
+	seen := make(map[int]bool)
+	var result []int
+	for _, c := range cells {
+		for position := range c {
+			if !seen[position] {
+				seen[position] = true
+				result = append(result, position)
+			}
+		}
+	}
+	sort.Ints(result)
+	return result
+}
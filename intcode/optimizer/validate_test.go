@@ -0,0 +1,60 @@
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	memory := parse(t, deadStoresProgram)
+	result, err := Optimize(memory)
+	require.NoError(t, err)
+
+	comparison, err := result.Validate(memory, [][]int{nil, nil})
+	require.NoError(t, err)
+	assert.Equal(t, Comparison{OriginalSteps: 14, OptimizedSteps: 12}, comparison)
+}
+
+func TestValidateInputs(t *testing.T) {
+	// outputs whether its input equals the constant cell 16
+	memory := parse(t, "3,17,8,17,16,18,4,18,99,0,0,0,0,0,0,0,8")
+	result, err := Optimize(memory)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, result.Rewritten)
+
+	comparison, err := result.Validate(memory, [][]int{{8}, {7}, nil})
+	require.NoError(t, err)
+	assert.Equal(t, Comparison{OriginalSteps: 8, OptimizedSteps: 8}, comparison)
+}
+
+func TestValidateDifferentOutputs(t *testing.T) {
+	memory := parse(t, deadStoresProgram)
+	result, err := Optimize(memory)
+	require.NoError(t, err)
+
+	result.Memory[17] = 5
+	_, err = result.Validate(memory, [][]int{nil})
+	assert.EqualError(t, err, "run 1: output 0: expected 3, got 5")
+}
+
+func TestValidateDifferentMemory(t *testing.T) {
+	memory := parse(t, deadStoresProgram)
+	result, err := Optimize(memory)
+	require.NoError(t, err)
+
+	// the folded outputs hide that the store at 8 writes a different value
+	result.Memory[9] = 5
+	_, err = result.Validate(memory, [][]int{nil})
+	assert.EqualError(t, err, "run 1: memory[9]: expected 3, got 5")
+}
+
+func TestValidateNotHalting(t *testing.T) {
+	memory := parse(t, "1105,1,0")
+	result, err := Optimize(memory)
+	require.NoError(t, err)
+
+	_, err = result.validate(memory, [][]int{nil}, 100)
+	assert.EqualError(t, err, "run 1: original program did not halt within 100 instructions")
+}
@@ -0,0 +1,150 @@
+package patch
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Parse parses the patches of r, which is read from the file filename.
+//
+// A patch file holds patches, each one starting with a line "patch NAME" followed by lines
+// changing or expecting memory cells:
+//   - ADDRESS: VALUES stores the comma separated values from ADDRESS
+//   - ADDRESS: INSTRUCTION stores the instruction assembled from the syntax of the disassembler,
+//     such as "120: jnz 1, 130"
+//   - expect ADDRESS: VALUES requires memory to hold the values from ADDRESS before patching
+//
+// Empty lines and text following a # are ignored.
+func Parse(r io.Reader, filename string) ([]Patch, error) {
+	var patches []Patch
+	names := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if name, ok := cutKeyword(line, "patch"); ok {
+			if name == "" || strings.ContainsAny(name, " \t") {
+				return nil, fmt.Errorf("%s:%d: invalid patch name %q", filename, n, name)
+			}
+			if names[name] {
+				return nil, fmt.Errorf("%s:%d: duplicated patch %s", filename, n, name)
+			}
+			names[name] = true
+			patches = append(patches, Patch{Name: name})
+			continue
+		}
+
+		if len(patches) == 0 {
+			return nil, fmt.Errorf("%s:%d: expected a patch before its cells", filename, n)
+		}
+		err := patches[len(patches)-1].parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filename, n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", filename, err)
+	}
+
+	return patches, nil
+}
+
+// parseLine parses a line of the cells of the patch
+func (p *Patch) parseLine(line string) error {
+	rest, expect := cutKeyword(line, "expect")
+	if expect {
+		line = rest
+	}
+
+	prefix, text, ok := strings.Cut(line, ":")
+	if !ok {
+		return fmt.Errorf("expected an address and its values separated by a colon")
+	}
+	address, err := strconv.Atoi(strings.TrimSpace(prefix))
+	if err != nil || address < 0 {
+		return fmt.Errorf("invalid address %s", strings.TrimSpace(prefix))
+	}
+
+	values, err := parseValues(text)
+	if expect {
+		if err != nil {
+			return err
+		}
+		p.Expect = append(p.Expect, Cells{Address: address, Values: values})
+		return nil
+	}
+
+	if err != nil {
+		assembled, asmErr := Assembled(p.Name, address, text)
+		if asmErr != nil {
+			return fmt.Errorf("expected values or an instruction: %w", asmErr)
+		}
+		values = assembled.Set[0].Values
+	}
+	p.Set = append(p.Set, Cells{Address: address, Values: values})
+	return nil
+}
+
+// cutKeyword returns the rest of line if it starts with keyword followed by whitespace
+func cutKeyword(line, keyword string) (string, bool) {
+	first, rest, _ := strings.Cut(line, " ")
+	if first != keyword {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// parseValues parses a comma separated list of values
+func parseValues(s string) ([]int, error) {
+	tokens := strings.Split(s, ",")
+	values := make([]int, len(tokens))
+	for i, token := range tokens {
+		value, err := strconv.Atoi(strings.TrimSpace(token))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s", strings.TrimSpace(token))
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// ParseFile parses the patches of filename
+func ParseFile(filename string) ([]Patch, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not open file %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	return Parse(f, filename)
+}
+
+// Select returns the patches with the given names, in the order of names
+func Select(patches []Patch, names ...string) ([]Patch, error) {
+	byName := make(map[string]Patch, len(patches))
+	for _, p := range patches {
+		byName[p.Name] = p
+	}
+
+	selected := make([]Patch, len(names))
+	for i, name := range names {
+		p, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown patch %s", name)
+		}
+		selected[i] = p
+	}
+	return selected, nil
+}
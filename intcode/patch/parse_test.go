@@ -0,0 +1,111 @@
+package patch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	source := `
+# Day 13: play without inserting quarters
+patch free-play
+expect 0: 1
+0: 2
+
+patch jump   # skips the instructions from 4
+4: jnz 1, 12
+10: data 7, 8
+12: 3, -4
+`
+
+	patches, err := Parse(strings.NewReader(source), "test.patch")
+	require.NoError(t, err)
+
+	expected := []Patch{
+		{
+			Name:   "free-play",
+			Expect: []Cells{{Address: 0, Values: []int{1}}},
+			Set:    []Cells{{Address: 0, Values: []int{2}}},
+		},
+		{
+			Name: "jump",
+			Set: []Cells{
+				{Address: 4, Values: []int{1105, 1, 12}},
+				{Address: 10, Values: []int{7, 8}},
+				{Address: 12, Values: []int{3, -4}},
+			},
+		},
+	}
+	assert.Equal(t, expected, patches)
+}
+
+func TestParseInvalid(t *testing.T) {
+	testCases := map[string]struct {
+		source   string
+		expected string
+	}{
+		"no patch": {
+			source:   "0: 2",
+			expected: "test.patch:1: expected a patch before its cells",
+		},
+		"no name": {
+			source:   "patch",
+			expected: `test.patch:1: invalid patch name ""`,
+		},
+		"duplicated": {
+			source:   "patch a\npatch a",
+			expected: "test.patch:2: duplicated patch a",
+		},
+		"no colon": {
+			source:   "patch a\n0 2",
+			expected: "test.patch:2: expected an address and its values separated by a colon",
+		},
+		"invalid address": {
+			source:   "patch a\n-1: 2",
+			expected: "test.patch:2: invalid address -1",
+		},
+		"invalid expected value": {
+			source:   "patch a\nexpect 0: add 1, 2, [3]",
+			expected: "test.patch:2: invalid value add 1",
+		},
+		"invalid instruction": {
+			source:   "patch a\n0: add 1, 2",
+			expected: "test.patch:2: expected values or an instruction: patch a: line 1: add expects 3 operands, got 2",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(testCase.source), "test.patch")
+			assert.EqualError(t, err, testCase.expected)
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.patch")
+	require.NoError(t, os.WriteFile(filename, []byte("patch a\n0: 2\n"), 0o600))
+
+	patches, err := ParseFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, []Patch{Values("a", 0, 2)}, patches)
+
+	_, err = ParseFile(filepath.Join(t.TempDir(), "missing.patch"))
+	assert.Error(t, err)
+}
+
+func TestSelect(t *testing.T) {
+	patches := []Patch{Values("a", 0, 1), Values("b", 0, 2)}
+
+	selected, err := Select(patches, "b", "a")
+	require.NoError(t, err)
+	assert.Equal(t, []Patch{Values("b", 0, 2), Values("a", 0, 1)}, selected)
+
+	_, err = Select(patches, "c")
+	assert.EqualError(t, err, "unknown patch c")
+}
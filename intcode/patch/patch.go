@@ -0,0 +1,164 @@
+package patch
+
+import (
+	"fmt"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/asm"
+)
+
+// Cells are consecutive memory cells starting at Address
+type Cells struct {
+	Address int
+	Values  []int
+}
+
+// end returns the position following the last cell
+func (c Cells) end() int {
+	return c.Address + len(c.Values)
+}
+
+// Patch is a named change of the memory of a program, such as a cheat or a fix
+type Patch struct {
+	Name string
+	// Expect are the values that memory must hold for the patch to be applied, which guards
+	// against patching another version of the program
+	Expect []Cells
+	// Set are the values stored in memory by the patch, in order
+	Set []Cells
+}
+
+// Values returns a patch which stores values from address
+func Values(name string, address int, values ...int) Patch {
+	return Patch{
+		Name: name,
+		Set:  []Cells{{Address: address, Values: values}},
+	}
+}
+
+// Assembled returns a patch which replaces the instructions from address with the ones
+// assembled from source, written in the syntax of the disassembler. The assembled code should
+// end at the boundary of an instruction, otherwise the rest of the replaced instruction is
+// executed as an instruction of its own.
+func Assembled(name string, address int, source string) (Patch, error) {
+	values, err := asm.Assemble(source)
+	if err != nil {
+		return Patch{}, fmt.Errorf("patch %s: %w", name, err)
+	}
+	if len(values) == 0 {
+		return Patch{}, fmt.Errorf("patch %s: no instructions to assemble", name)
+	}
+	return Values(name, address, values...), nil
+}
+
+// Validate checks that the cells expected and set by the patch exist in memory, as patches
+// only change the values of a program but never extend it, and that the expected values hold
+func (p Patch) Validate(memory []int) error {
+	return p.validate(len(memory), func(position int) int {
+		return memory[position]
+	})
+}
+
+// validate checks the patch against a memory of size values, which are read with fetch
+func (p Patch) validate(size int, fetch func(position int) int) error {
+	for _, cells := range p.Expect {
+		if err := p.check(cells, size); err != nil {
+			return err
+		}
+		for i, value := range cells.Values {
+			if actual := fetch(cells.Address + i); actual != value {
+				return fmt.Errorf("patch %s: expected %d at %d, got %d", p.Name, value, cells.Address+i, actual)
+			}
+		}
+	}
+
+	for _, cells := range p.Set {
+		if err := p.check(cells, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check checks that cells exist in a memory of size values
+func (p Patch) check(cells Cells, size int) error {
+	if cells.Address < 0 || cells.end() > size {
+		return fmt.Errorf("patch %s: cells %d to %d are out of the program of size %d",
+			p.Name, cells.Address, cells.end()-1, size)
+	}
+	return nil
+}
+
+// apply stores the values set by the patch with store
+func (p Patch) apply(store func(position, value int) error) error {
+	for _, cells := range p.Set {
+		for i, value := range cells.Values {
+			if err := store(cells.Address+i, value); err != nil {
+				return fmt.Errorf("patch %s: %w", p.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Apply returns a copy of memory with patches applied in order, each of them being validated
+// against the memory patched by the previous ones
+func Apply(memory []int, patches ...Patch) ([]int, error) {
+	patched := append([]int(nil), memory...)
+	for _, p := range patches {
+		if err := p.Validate(patched); err != nil {
+			return nil, err
+		}
+		_ = p.apply(func(position, value int) error {
+			patched[position] = value
+			return nil
+		})
+	}
+	return patched, nil
+}
+
+// ApplyImage returns an image holding the memory of image with patches applied, whose
+// machines are configured with the same options
+func ApplyImage(image *intcode.Image, patches ...Patch) (*intcode.Image, error) {
+	patched, err := Apply(image.Memory(), patches...)
+	if err != nil {
+		return nil, err
+	}
+	return image.WithMemory(patched), nil
+}
+
+// ApplyRunning applies patches to the memory of a program during its execution, such as while
+// it waits for an input. The patches are validated against its current memory before any of them
+// is applied. The stores are not seen by the observers of the program, so they are not undone
+// when stepping back.
+func ApplyRunning(i *intcode.Intcode, patches ...Patch) error {
+	// The memory of the program is only read at the patched cells, as it may be too large to copy
+	size := i.MemorySize()
+	patched := make(map[int]int)
+	fetch := func(position int) int {
+		if value, ok := patched[position]; ok {
+			return value
+		}
+		value, _ := i.Peek(position)
+		return value
+	}
+	for _, p := range patches {
+		if err := p.validate(size, fetch); err != nil {
+			return err
+		}
+		_ = p.apply(func(position, value int) error {
+			patched[position] = value
+			return nil
+		})
+	}
+
+	for _, p := range patches {
+		err := p.apply(func(position, value int) error {
+			return i.Poke(position, patched[position])
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+// quartersProgram outputs the number of quarters stored at 0 and waits for an input
+const quartersProgram = "1,4,7,99,4,0,3,0,99"
+
+func TestApply(t *testing.T) {
+	memory := []int{1, 2, 3, 4}
+	p := Patch{
+		Name:   "test",
+		Expect: []Cells{{Address: 1, Values: []int{2, 3}}},
+		Set:    []Cells{{Address: 0, Values: []int{5}}, {Address: 2, Values: []int{6, 7}}},
+	}
+
+	patched, err := Apply(memory, p)
+	require.NoError(t, err)
+	assert.Equal(t, []int{5, 2, 6, 7}, patched)
+	assert.Equal(t, []int{1, 2, 3, 4}, memory, "the memory must not be modified")
+}
+
+func TestApplyInOrder(t *testing.T) {
+	first := Values("first", 0, 5)
+	second := Patch{
+		Name:   "second",
+		Expect: []Cells{{Address: 0, Values: []int{5}}},
+		Set:    []Cells{{Address: 0, Values: []int{6}}},
+	}
+
+	patched, err := Apply([]int{1}, first, second)
+	require.NoError(t, err)
+	assert.Equal(t, []int{6}, patched)
+
+	_, err = Apply([]int{1}, second, first)
+	assert.EqualError(t, err, "patch second: expected 5 at 0, got 1")
+}
+
+func TestValidate(t *testing.T) {
+	memory := []int{1, 2, 3}
+
+	testCases := map[string]struct {
+		patch    Patch
+		expected string
+	}{
+		"beyond the end": {
+			patch:    Values("end", 2, 7, 8),
+			expected: "patch end: cells 2 to 3 are out of the program of size 3",
+		},
+		"negative address": {
+			patch:    Values("negative", -1, 7),
+			expected: "patch negative: cells -1 to -1 are out of the program of size 3",
+		},
+		"expected beyond the end": {
+			patch:    Patch{Name: "expect", Expect: []Cells{{Address: 3, Values: []int{0}}}},
+			expected: "patch expect: cells 3 to 3 are out of the program of size 3",
+		},
+		"unexpected value": {
+			patch:    Patch{Name: "expect", Expect: []Cells{{Address: 0, Values: []int{1, 3}}}},
+			expected: "patch expect: expected 3 at 1, got 2",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.EqualError(t, testCase.patch.Validate(memory), testCase.expected)
+		})
+	}
+}
+
+func TestAssembled(t *testing.T) {
+	p, err := Assembled("jump", 4, "jnz 1, 8")
+	require.NoError(t, err)
+	assert.Equal(t, Values("jump", 4, 1105, 1, 8), p)
+
+	_, err = Assembled("invalid", 4, "jump 8")
+	assert.EqualError(t, err, "patch invalid: line 1: unknown mnemonic jump")
+
+	_, err = Assembled("empty", 4, "# nothing")
+	assert.EqualError(t, err, "patch empty: no instructions to assemble")
+}
+
+func TestApplyImage(t *testing.T) {
+	image, err := intcode.NewImage(quartersProgram)
+	require.NoError(t, err)
+
+	patched, err := ApplyImage(image, Values("free-play", 0, 2))
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4, 7, 99, 4, 0, 3, 0, 99}, patched.Memory())
+	assert.Equal(t, []int{1, 4, 7, 99, 4, 0, 3, 0, 99}, image.Memory(), "the image must not be modified")
+
+	_, err = ApplyImage(image, Values("end", 9, 1))
+	assert.Error(t, err)
+}
+
+func TestApplyRunning(t *testing.T) {
+	var outputs []int
+	onOutput := func(output int) { outputs = append(outputs, output) }
+	program, err := intcode.NewIntcodeProgram(quartersProgram, func() int { return 0 }, onOutput)
+	require.NoError(t, err)
+
+	// skip the first instruction, which would overwrite the instruction at 7
+	require.NoError(t, ApplyRunning(program, Values("skip", 0, 1105, 1, 4)))
+	require.NoError(t, program.Step())
+	require.NoError(t, program.Step())
+	assert.Equal(t, []int{1105}, outputs)
+
+	err = ApplyRunning(program, Values("valid", 0, 2), Values("invalid", 20, 1))
+	assert.EqualError(t, err, "patch invalid: cells 20 to 20 are out of the program of size 9")
+	assert.Equal(t, 1105, program.Memory()[0], "no patch must be applied if one of them is invalid")
+}
+
+func TestApplyRunningAtHighAddress(t *testing.T) {
+	program, err := intcode.NewIntcodeProgram("1101,1,1,100000000000000,99", intcode.MustNotInput, intcode.MustNotOutput)
+	require.NoError(t, err)
+	require.NoError(t, program.Step())
+
+	patch := Patch{
+		Name:   "high",
+		Expect: []Cells{{Address: 100000000000000, Values: []int{2}}},
+		Set:    []Cells{{Address: 100000000000000, Values: []int{3}}},
+	}
+	require.NoError(t, ApplyRunning(program, patch))
+	value, err := program.Peek(100000000000000)
+	require.NoError(t, err)
+	assert.Equal(t, 3, value)
+}
@@ -0,0 +1,180 @@
+package profiler
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/asm"
+)
+
+// Field numbers of the messages defined in
+// https://github.com/google/pprof/blob/main/proto/profile.proto
+const (
+	profileSampleType  = 1
+	profileSample      = 2
+	profileLocation    = 4
+	profileFunction    = 5
+	profileStringTable = 6
+	profilePeriodType  = 11
+	profilePeriod      = 12
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID      = 1
+	locationAddress = 3
+	locationLine    = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID        = 1
+	functionName      = 2
+	functionFilename  = 4
+	functionStartLine = 5
+)
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// pprofFilename is the file name given to the Intcode program in the profile
+const pprofFilename = "intcode"
+
+// WritePprof writes the instructions executed per address as a gzipped pprof profile that
+// can be visualized with go tool pprof. Each address is represented as a function named
+// after its disassembly, memory being the initial memory of the profiled program.
+func (p *Profiler) WritePprof(w io.Writer, memory []int) error {
+	text := make(map[int]string)
+	for _, line := range asm.DisassembleWith(p.set, memory) {
+		text[line.Address] = strings.TrimSpace(line.String())
+	}
+
+	addresses := make([]int, 0, len(p.Instructions))
+	for address := range p.Instructions {
+		addresses = append(addresses, address)
+	}
+	sort.Ints(addresses)
+
+	table := newStringTable()
+	var profile protoBuffer
+
+	var valueType protoBuffer
+	valueType.int64Field(valueTypeType, table.index("instructions"))
+	valueType.int64Field(valueTypeUnit, table.index("count"))
+	profile.messageField(profileSampleType, &valueType)
+	profile.messageField(profilePeriodType, &valueType)
+	profile.int64Field(profilePeriod, 1)
+
+	for i, address := range addresses {
+		id := uint64(i + 1)
+
+		name, ok := text[address]
+		if !ok {
+			name = fmt.Sprintf("%d: (modified code)", address)
+		}
+
+		var function protoBuffer
+		function.uint64Field(functionID, id)
+		function.int64Field(functionName, table.index(name))
+		function.int64Field(functionFilename, table.index(pprofFilename))
+		function.int64Field(functionStartLine, int64(address))
+		profile.messageField(profileFunction, &function)
+
+		var line protoBuffer
+		line.uint64Field(lineFunctionID, id)
+		line.int64Field(lineLine, int64(address))
+
+		var location protoBuffer
+		location.uint64Field(locationID, id)
+		location.uint64Field(locationAddress, uint64(address))
+		location.messageField(locationLine, &line)
+		profile.messageField(profileLocation, &location)
+
+		var sample protoBuffer
+		sample.uint64Field(sampleLocationID, id)
+		sample.int64Field(sampleValue, int64(p.Instructions[address]))
+		profile.messageField(profileSample, &sample)
+	}
+
+	for _, s := range table.values {
+		profile.stringField(profileStringTable, s)
+	}
+
+	gz := gzip.NewWriter(w)
+	_, err := gz.Write(profile.bytes)
+	if err != nil {
+		return fmt.Errorf("could not write profile: %w", err)
+	}
+	return gz.Close()
+}
+
+// stringTable holds the strings referenced from a profile by their index
+type stringTable struct {
+	values  []string
+	indices map[string]int64
+}
+
+func newStringTable() *stringTable {
+	// the first string of the table must always be the empty string
+	return &stringTable{
+		values:  []string{""},
+		indices: map[string]int64{"": 0},
+	}
+}
+
+func (st *stringTable) index(s string) int64 {
+	if i, ok := st.indices[s]; ok {
+		return i
+	}
+	i := int64(len(st.values))
+	st.values = append(st.values, s)
+	st.indices[s] = i
+	return i
+}
+
+// protoBuffer encodes the fields of a protocol buffer message
+type protoBuffer struct {
+	bytes []byte
+}
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.bytes = append(b.bytes, byte(x)|0x80)
+		x >>= 7
+	}
+	b.bytes = append(b.bytes, byte(x))
+}
+
+func (b *protoBuffer) key(field, wireType int) {
+	b.varint(uint64(field<<3 | wireType))
+}
+
+func (b *protoBuffer) uint64Field(field int, x uint64) {
+	b.key(field, wireVarint)
+	b.varint(x)
+}
+
+func (b *protoBuffer) int64Field(field int, x int64) {
+	b.key(field, wireVarint)
+	b.varint(uint64(x))
+}
+
+func (b *protoBuffer) stringField(field int, s string) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(s)))
+	b.bytes = append(b.bytes, s...)
+}
+
+func (b *protoBuffer) messageField(field int, message *protoBuffer) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(message.bytes)))
+	b.bytes = append(b.bytes, message.bytes...)
+}
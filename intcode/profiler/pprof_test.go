@@ -0,0 +1,60 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+func TestWritePprof(t *testing.T) {
+	profiler := runProfiled(t, countingProgram)
+	memory, err := program.Parse(countingProgram)
+	require.NoError(t, err)
+
+	profile := writePprof(t, profiler, memory)
+	assert.Contains(t, string(profile), "instructions")
+	assert.Contains(t, string(profile), "4: add [100], 1, [100]")
+	assert.Contains(t, string(profile), pprofFilename)
+}
+
+func TestWritePprofWithInstructionSet(t *testing.T) {
+	profiler := runProfiledWith(t, printSet(t), printProgram)
+	memory, err := program.Parse(printProgram)
+	require.NoError(t, err)
+
+	profile := writePprof(t, profiler, memory)
+	assert.Contains(t, string(profile), "4: print [7]")
+	assert.NotContains(t, string(profile), "modified code")
+}
+
+// writePprof returns the uncompressed pprof profile written by profiler
+func writePprof(t *testing.T, profiler *Profiler, memory []int) []byte {
+	var buffer bytes.Buffer
+	require.NoError(t, profiler.WritePprof(&buffer, memory))
+
+	gz, err := gzip.NewReader(&buffer)
+	require.NoError(t, err)
+	profile, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	return profile
+}
+
+func TestProtoBuffer(t *testing.T) {
+	var message protoBuffer
+	message.uint64Field(1, 150)
+	assert.Equal(t, []byte{0x08, 0x96, 0x01}, message.bytes)
+
+	var parent protoBuffer
+	parent.messageField(3, &message)
+	assert.Equal(t, []byte{0x1a, 0x03, 0x08, 0x96, 0x01}, parent.bytes)
+
+	var s protoBuffer
+	s.stringField(2, "testing")
+	assert.Equal(t, append([]byte{0x12, 0x07}, "testing"...), s.bytes)
+}
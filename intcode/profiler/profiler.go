@@ -0,0 +1,102 @@
+package profiler
+
+import (
+	"time"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// Profiler is an intcode.Observer that profiles the execution of an Intcode program
+type Profiler struct {
+	// Instructions counts the executed instructions per address
+	Instructions map[int]int
+	// Opcodes counts the executed instructions per opcode
+	Opcodes map[int]int
+	// Reads counts the memory reads per address, excluding the ones done to fetch instructions
+	Reads map[int]int
+	// Writes counts the memory writes per address
+	Writes map[int]int
+
+	// Inputs is the number of inputs read
+	Inputs int
+	// InputWait is the total time spent waiting for inputs
+	InputWait time.Duration
+	// Outputs is the number of outputs written
+	Outputs int
+	// OutputWait is the total time spent writing outputs
+	OutputWait time.Duration
+
+	// set is the instruction set of the profiled program
+	set *instruction.Set
+	// fetches holds the positions fetched by the instruction that is being executed
+	fetches []int
+}
+
+var _ intcode.Observer = &Profiler{}
+
+// New returns a new Profiler of programs that execute the standard instruction set
+func New() *Profiler {
+	return NewWith(instruction.Standard())
+}
+
+// NewWith returns a new Profiler of programs that execute the instructions of set
+func NewWith(set *instruction.Set) *Profiler {
+	return &Profiler{
+		Instructions: make(map[int]int),
+		Opcodes:      make(map[int]int),
+		Reads:        make(map[int]int),
+		Writes:       make(map[int]int),
+		set:          set,
+	}
+}
+
+// OnFetch records a memory read
+func (p *Profiler) OnFetch(position, value int) {
+	p.fetches = append(p.fetches, position)
+}
+
+// OnStore records a memory write
+func (p *Profiler) OnStore(position, previous, value int) {
+	p.Writes[position]++
+}
+
+// OnInput records the time spent waiting for an input
+func (p *Profiler) OnInput(value int, wait time.Duration) {
+	p.Inputs++
+	p.InputWait += wait
+}
+
+// OnOutput records the time spent writing an output
+func (p *Profiler) OnOutput(value int, wait time.Duration) {
+	p.Outputs++
+	p.OutputWait += wait
+}
+
+// OnInstruction records an executed instruction and classifies the memory reads it did
+func (p *Profiler) OnInstruction(event intcode.InstructionEvent) {
+	p.Instructions[event.Address]++
+	p.Opcodes[event.Opcode]++
+
+	size := 1
+	if definition, ok := p.set.Lookup(event.Opcode); ok {
+		size = definition.Size()
+	}
+
+	for _, position := range p.fetches {
+		if position >= event.Address && position < event.Address+size {
+			continue
+		}
+		p.Reads[position]++
+	}
+	p.fetches = p.fetches[:0]
+}
+
+// Total returns the total number of executed instructions
+func (p *Profiler) Total() int {
+	total := 0
+	for _, count := range p.Instructions {
+		total += count
+	}
+	return total
+}
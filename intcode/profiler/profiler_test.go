@@ -0,0 +1,78 @@
+package profiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// countingProgram counts from 0 to 3 and outputs the result
+const countingProgram = "1101,0,0,100,1001,100,1,100,1007,100,3,101,1005,101,4,4,100,99"
+
+func runProfiled(t *testing.T, program string) *Profiler {
+	profiler := New()
+	intcodeProgram, err := intcode.NewIntcodeProgram(
+		program, intcode.MustNotInput, func(int) {}, intcode.WithObserver(profiler),
+	)
+	require.NoError(t, err)
+	require.NoError(t, intcodeProgram.Run())
+	return profiler
+}
+
+func TestProfiler(t *testing.T) {
+	profiler := runProfiled(t, countingProgram)
+
+	assert.Equal(t, 12, profiler.Total())
+	assert.Equal(t, map[int]int{0: 1, 4: 3, 8: 3, 12: 3, 15: 1, 17: 1}, profiler.Instructions)
+	assert.Equal(t, map[int]int{1: 4, 7: 3, 5: 3, 4: 1, 99: 1}, profiler.Opcodes)
+	assert.Equal(t, map[int]int{100: 7, 101: 3}, profiler.Reads)
+	assert.Equal(t, map[int]int{100: 4, 101: 3}, profiler.Writes)
+	assert.Equal(t, 0, profiler.Inputs)
+	assert.Equal(t, 1, profiler.Outputs)
+}
+
+// printProgram stores 5 in 7 and prints it with the custom instruction of printSet
+const printProgram = "1101,2,3,7,50,7,99,0"
+
+// printSet returns the standard instruction set with a print instruction which reads its parameter
+func printSet(t *testing.T) *instruction.Set {
+	set := instruction.NewSet()
+	err := set.Register(
+		instruction.Definition{Opcode: 50, Mnemonic: "print", Parameters: []instruction.ParameterKind{instruction.Read}},
+		func(operands *instruction.Operands) error {
+			_, err := operands.Read(0)
+			return err
+		},
+	)
+	require.NoError(t, err)
+	return set
+}
+
+// runProfiledWith runs program with the instructions of set and returns its profile
+func runProfiledWith(t *testing.T, set *instruction.Set, program string) *Profiler {
+	profiler := NewWith(set)
+	intcodeProgram, err := intcode.NewIntcodeProgram(
+		program, intcode.MustNotInput, intcode.MustNotOutput,
+		intcode.WithInstructionSet(set), intcode.WithObserver(profiler),
+	)
+	require.NoError(t, err)
+	require.NoError(t, intcodeProgram.Run())
+	return profiler
+}
+
+func TestProfilerWithInstructionSet(t *testing.T) {
+	profiler := runProfiledWith(t, printSet(t), printProgram)
+
+	// the parameter of print is a read of position 7, not a fetch of the instruction
+	assert.Equal(t, map[int]int{7: 1}, profiler.Reads)
+
+	var sb strings.Builder
+	require.NoError(t, profiler.Report(&sb, []int{1101, 2, 3, 7, 50, 7, 99, 0}))
+	assert.Contains(t, sb.String(), "print\n")
+	assert.Contains(t, sb.String(), "4: print [7]")
+}
@@ -0,0 +1,152 @@
+package profiler
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/asm"
+)
+
+const (
+	// hotSpots is the number of addresses listed as hot spots in the report
+	hotSpots = 20
+	// heatMapWidth is the number of memory positions represented in each row of a heat map
+	heatMapWidth = 64
+)
+
+// heatLevels are the characters used to render heat maps, from coldest to hottest
+var heatLevels = []rune(" .:-=+*#%@")
+
+// Report writes a human readable report of the profile. The executed instructions are
+// correlated with the disassembly listing of memory, which should be the initial memory
+// of the profiled program.
+func (p *Profiler) Report(w io.Writer, memory []int) error {
+	var sb strings.Builder
+	total := p.Total()
+
+	fmt.Fprintf(&sb, "Instructions executed: %d\n", total)
+	fmt.Fprintf(&sb, "Inputs read: %d (waited %v)\n", p.Inputs, p.InputWait)
+	fmt.Fprintf(&sb, "Outputs written: %d (waited %v)\n", p.Outputs, p.OutputWait)
+
+	sb.WriteString("\nOpcodes:\n")
+	for _, opcode := range sortedByCount(p.Opcodes) {
+		mnemonic := fmt.Sprintf("op%d", opcode)
+		if definition, ok := p.set.Lookup(opcode); ok {
+			mnemonic = definition.Mnemonic
+		}
+		count := p.Opcodes[opcode]
+		fmt.Fprintf(&sb, "%12d %6.2f%%  %s\n", count, percentage(count, total), mnemonic)
+	}
+
+	lines := asm.DisassembleWith(p.set, memory)
+	text := make(map[int]string, len(lines))
+	for _, line := range lines {
+		text[line.Address] = line.String()
+	}
+
+	sb.WriteString("\nHot spots:\n")
+	addresses := sortedByCount(p.Instructions)
+	if len(addresses) > hotSpots {
+		addresses = addresses[:hotSpots]
+	}
+	for _, address := range addresses {
+		count := p.Instructions[address]
+		fmt.Fprintf(&sb, "%12d %6.2f%%  %s\n", count, percentage(count, total), lineText(text, address))
+	}
+
+	sb.WriteString("\nAnnotated disassembly:\n")
+	for _, line := range lines {
+		if count, ok := p.Instructions[line.Address]; ok {
+			fmt.Fprintf(&sb, "%12d  %s\n", count, line)
+		} else {
+			fmt.Fprintf(&sb, "%12s  %s\n", "", line)
+		}
+	}
+
+	sb.WriteString("\nMemory reads:\n")
+	sb.WriteString(HeatMap(p.Reads))
+	sb.WriteString("\nMemory writes:\n")
+	sb.WriteString(HeatMap(p.Writes))
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// HeatMap renders the number of accesses per memory position as a heat map.
+// Each row represents heatMapWidth consecutive positions, only rows with accesses are shown,
+// and the hotter a position is the denser its character.
+func HeatMap(accesses map[int]int) string {
+	maxCount := 0
+	rows := make(map[int]bool)
+	for position, count := range accesses {
+		if count > maxCount {
+			maxCount = count
+		}
+		rows[position/heatMapWidth] = true
+	}
+	if maxCount == 0 {
+		return "  (none)\n"
+	}
+
+	var sortedRows []int
+	for row := range rows {
+		sortedRows = append(sortedRows, row)
+	}
+	sort.Ints(sortedRows)
+
+	var sb strings.Builder
+	for _, row := range sortedRows {
+		fmt.Fprintf(&sb, "%6d |", row*heatMapWidth)
+		for column := 0; column < heatMapWidth; column++ {
+			sb.WriteRune(heatLevel(accesses[row*heatMapWidth+column], maxCount))
+		}
+		sb.WriteString("|\n")
+	}
+	return sb.String()
+}
+
+// heatLevel returns the character for count using a logarithmic scale up to maxCount
+func heatLevel(count, maxCount int) rune {
+	if count == 0 {
+		return heatLevels[0]
+	}
+	hottest := len(heatLevels) - 1
+	if maxCount == 1 {
+		return heatLevels[hottest]
+	}
+	level := 1 + int(math.Log(float64(count))/math.Log(float64(maxCount))*float64(hottest-1))
+	return heatLevels[level]
+}
+
+func lineText(text map[int]string, address int) string {
+	if t, ok := text[address]; ok {
+		return t
+	}
+	// the instruction was not part of the initial disassembly, the code must have been modified
+	return fmt.Sprintf("%5d: (modified code)", address)
+}
+
+// sortedByCount returns the keys of counts sorted by decreasing count and then by key
+func sortedByCount(counts map[int]int) []int {
+	keys := make([]int, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func percentage(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(count) / float64(total)
+}
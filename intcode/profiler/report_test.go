@@ -0,0 +1,37 @@
+package profiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+func TestReport(t *testing.T) {
+	profiler := runProfiled(t, countingProgram)
+	memory, err := program.Parse(countingProgram)
+	require.NoError(t, err)
+
+	var sb strings.Builder
+	require.NoError(t, profiler.Report(&sb, memory))
+	report := sb.String()
+
+	assert.Contains(t, report, "Instructions executed: 12\n")
+	assert.Contains(t, report, "           4  33.33%  add\n")
+	assert.Contains(t, report, "Hot spots:\n           3  25.00%      4: add [100], 1, [100]\n")
+	assert.Contains(t, report, "           3      8: lt [100], 3, [101]\n")
+	assert.Contains(t, report, "           1      0: add 0, 0, [100]\n")
+}
+
+func TestHeatMap(t *testing.T) {
+	assert.Equal(t, "  (none)\n", HeatMap(map[int]int{}))
+
+	heatMap := HeatMap(map[int]int{0: 1, 2: 100, 130: 10})
+	expected := "" +
+		"     0 |. @" + strings.Repeat(" ", heatMapWidth-3) + "|\n" +
+		"   128 |  +" + strings.Repeat(" ", heatMapWidth-3) + "|\n"
+	assert.Equal(t, expected, heatMap)
+}
@@ -4,8 +4,21 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// Observer is notified of the memory accesses and the I/O performed by a program
+type Observer interface {
+	// OnFetch is called whenever value is fetched from position
+	OnFetch(position, value int)
+	// OnStore is called whenever value is stored at position, which previously held previous
+	OnStore(position, previous, value int)
+	// OnInput is called whenever value is read as an input, wait being the time spent waiting for it
+	OnInput(value int, wait time.Duration)
+	// OnOutput is called whenever value is written as an output, wait being the time spent writing it
+	OnOutput(value int, wait time.Duration)
+}
+
 // Program represents an Intcode program
 type Program struct {
 	// InstructionPointer is the current position of the instruction pointer
@@ -14,11 +27,14 @@ type Program struct {
 	Halted bool
 	// RelativeBase is the current position of the relative base
 	RelativeBase int
+	// DetectOverflow indicates if arithmetic instructions must fail instead of wrapping around
+	DetectOverflow bool
 
 	onInput  func() int
 	onOutput func(output int)
 
-	memory map[int]int
+	memory    map[int]int
+	observers []Observer
 }
 
 // NewProgram creates a new program from the program string
@@ -39,10 +55,11 @@ func NewProgram(
 	}, nil
 }
 
-func newMemory(programString string) (map[int]int, error) {
+// Parse parses the program string into the initial values of the memory
+func Parse(programString string) ([]int, error) {
 	tokens := strings.Split(programString, ",")
 
-	memory := make(map[int]int, len(tokens))
+	values := make([]int, len(tokens))
 	for i, token := range tokens {
 
 		value, err := strconv.Atoi(token)
@@ -50,19 +67,78 @@ func newMemory(programString string) (map[int]int, error) {
 			return nil, fmt.Errorf("invalid value %s: %w", token, err)
 		}
 
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// NewProgramFromMemory creates a new program whose memory holds a copy of the given values
+func NewProgramFromMemory(
+	values []int,
+	onInput func() int,
+	onOutput func(output int),
+) *Program {
+	memory := make(map[int]int, len(values))
+	for i, value := range values {
+		memory[i] = value
+	}
+
+	return &Program{
+		memory:   memory,
+		onInput:  onInput,
+		onOutput: onOutput,
+	}
+}
+
+// NewProgramFromCells creates a new program whose memory holds a copy of the sparse cells
+func NewProgramFromCells(
+	cells map[int]int,
+	onInput func() int,
+	onOutput func(output int),
+) *Program {
+	memory := make(map[int]int, len(cells))
+	for position, value := range cells {
+		memory[position] = value
+	}
+
+	return &Program{
+		memory:   memory,
+		onInput:  onInput,
+		onOutput: onOutput,
+	}
+}
+
+func newMemory(programString string) (map[int]int, error) {
+	values, err := Parse(programString)
+	if err != nil {
+		return nil, err
+	}
+
+	memory := make(map[int]int, len(values))
+	for i, value := range values {
 		memory[i] = value
 	}
 
 	return memory, nil
 }
 
+// AddObserver adds an observer that will be notified of the memory accesses and the I/O
+func (p *Program) AddObserver(observer Observer) {
+	p.observers = append(p.observers, observer)
+}
+
 // Fetch fetches Value at given position
 func (p *Program) Fetch(position int) (int, error) {
 	if position < 0 {
 		return 0, fmt.Errorf("fetch error: invalid memory position: %d", position)
 	}
 
-	return p.memory[position], nil
+	value := p.memory[position]
+	for _, observer := range p.observers {
+		observer.OnFetch(position, value)
+	}
+	return value, nil
 }
 
 // Store stores Value at given position
@@ -71,16 +147,134 @@ func (p *Program) Store(position int, value int) error {
 		return fmt.Errorf("store error: invalid memory position: %d", position)
 	}
 
+	previous := p.memory[position]
 	p.memory[position] = value
+	for _, observer := range p.observers {
+		observer.OnStore(position, previous, value)
+	}
 	return nil
 }
 
+// Peek returns the value at position without notifying the observers
+func (p *Program) Peek(position int) (int, error) {
+	if position < 0 {
+		return 0, fmt.Errorf("peek error: invalid memory position: %d", position)
+	}
+	return p.memory[position], nil
+}
+
+// Memory returns a dense copy of the memory up to the highest position that has been set. Its
+// size depends on that position and not on the number of cells used, so Cells or Peek must be
+// used instead on programs that may access very large positions.
+func (p *Program) Memory() []int {
+	memory := make([]int, p.Size())
+	for position, value := range p.memory {
+		memory[position] = value
+	}
+	return memory
+}
+
+// Size returns the size of the memory returned by Memory, which is one more than the highest
+// position that has been set, without copying it
+func (p *Program) Size() int {
+	size := 0
+	for position := range p.memory {
+		if position >= size {
+			size = position + 1
+		}
+	}
+	return size
+}
+
+// Cells returns a sparse copy of the memory, holding the value of every position that has been set
+func (p *Program) Cells() map[int]int {
+	cells := make(map[int]int, len(p.memory))
+	for position, value := range p.memory {
+		cells[position] = value
+	}
+	return cells
+}
+
+// Poke stores value at position without notifying the observers
+func (p *Program) Poke(position int, value int) error {
+	if position < 0 {
+		return fmt.Errorf("poke error: invalid memory position: %d", position)
+	}
+	p.memory[position] = value
+	return nil
+}
+
+// Fork returns a copy of the program in its current state which calls onInput and onOutput,
+// without the observers of the program
+func (p *Program) Fork(onInput func() int, onOutput func(output int)) *Program {
+	memory := make(map[int]int, len(p.memory))
+	for position, value := range p.memory {
+		memory[position] = value
+	}
+
+	return &Program{
+		InstructionPointer: p.InstructionPointer,
+		Halted:             p.Halted,
+		RelativeBase:       p.RelativeBase,
+		DetectOverflow:     p.DetectOverflow,
+		onInput:            onInput,
+		onOutput:           onOutput,
+		memory:             memory,
+	}
+}
+
+// Reset resets the program to a fresh one whose memory holds a copy of values, keeping its
+// callbacks, observers and overflow detection
+func (p *Program) Reset(values []int) {
+	for position := range p.memory {
+		delete(p.memory, position)
+	}
+	for i, value := range values {
+		p.memory[i] = value
+	}
+
+	p.InstructionPointer = 0
+	p.Halted = false
+	p.RelativeBase = 0
+}
+
+// SetIO makes the program call onInput and onOutput
+func (p *Program) SetIO(onInput func() int, onOutput func(output int)) {
+	p.onInput = onInput
+	p.onOutput = onOutput
+}
+
+// Restore stores value at position without notifying the observers, it is used to undo stores
+func (p *Program) Restore(position int, value int) {
+	p.memory[position] = value
+}
+
 // ReadInput reads an input value from onInput function
 func (p *Program) ReadInput() int {
-	return p.onInput()
+	if len(p.observers) == 0 {
+		return p.onInput()
+	}
+
+	start := time.Now()
+	value := p.onInput()
+	wait := time.Since(start)
+	for _, observer := range p.observers {
+		observer.OnInput(value, wait)
+	}
+	return value
 }
 
 // WriteOutput writes an output value to onOutput function
 func (p *Program) WriteOutput(output int) {
+	if len(p.observers) == 0 {
+		p.onOutput(output)
+		return
+	}
+
+	start := time.Now()
 	p.onOutput(output)
+	wait := time.Since(start)
+	for _, observer := range p.observers {
+		observer.OnOutput(output, wait)
+	}
 }
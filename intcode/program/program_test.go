@@ -1,9 +1,12 @@
 package program
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewMemory(t *testing.T) {
@@ -74,3 +77,181 @@ func TestNewMemory(t *testing.T) {
 		})
 	}
 }
+
+func TestParse(t *testing.T) {
+	values, err := Parse("1,-2,3")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, -2, 3}, values)
+
+	_, err = Parse("1,,3")
+	assert.Error(t, err)
+}
+
+type recordingObserver struct {
+	events []string
+}
+
+func (r *recordingObserver) OnFetch(position, value int) {
+	r.events = append(r.events, fmt.Sprintf("fetch %d %d", position, value))
+}
+
+func (r *recordingObserver) OnStore(position, previous, value int) {
+	r.events = append(r.events, fmt.Sprintf("store %d %d %d", position, previous, value))
+}
+
+func (r *recordingObserver) OnInput(value int, wait time.Duration) {
+	r.events = append(r.events, fmt.Sprintf("input %d", value))
+}
+
+func (r *recordingObserver) OnOutput(value int, wait time.Duration) {
+	r.events = append(r.events, fmt.Sprintf("output %d", value))
+}
+
+func TestObserver(t *testing.T) {
+	program, err := NewProgram("1,2", func() int { return 7 }, func(int) {})
+	require.NoError(t, err)
+
+	observer := &recordingObserver{}
+	program.AddObserver(observer)
+
+	_, err = program.Fetch(1)
+	require.NoError(t, err)
+	require.NoError(t, program.Store(1, 5))
+	assert.Equal(t, 7, program.ReadInput())
+	program.WriteOutput(3)
+
+	expected := []string{"fetch 1 2", "store 1 2 5", "input 7", "output 3"}
+	assert.Equal(t, expected, observer.events)
+}
+
+func TestRestore(t *testing.T) {
+	program, err := NewProgram("1,2", nil, nil)
+	require.NoError(t, err)
+
+	observer := &recordingObserver{}
+	program.AddObserver(observer)
+
+	program.Restore(1, 5)
+	assert.Empty(t, observer.events)
+
+	value, err := program.Fetch(1)
+	require.NoError(t, err)
+	assert.Equal(t, 5, value)
+}
+
+func TestPeek(t *testing.T) {
+	program, err := NewProgram("1,2", nil, nil)
+	require.NoError(t, err)
+
+	observer := &recordingObserver{}
+	program.AddObserver(observer)
+
+	value, err := program.Peek(1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+	assert.Empty(t, observer.events)
+
+	_, err = program.Peek(-1)
+	assert.Error(t, err)
+}
+
+func TestPoke(t *testing.T) {
+	program, err := NewProgram("1,2", nil, nil)
+	require.NoError(t, err)
+
+	observer := &recordingObserver{}
+	program.AddObserver(observer)
+
+	require.NoError(t, program.Poke(3, 7))
+	assert.Equal(t, []int{1, 2, 0, 7}, program.Memory())
+	assert.Empty(t, observer.events)
+
+	assert.Error(t, program.Poke(-1, 7))
+}
+
+func TestFork(t *testing.T) {
+	program, err := NewProgram("1,2", nil, nil)
+	require.NoError(t, err)
+	program.InstructionPointer = 1
+	program.RelativeBase = 4
+	program.AddObserver(&recordingObserver{})
+
+	var outputs []int
+	forked := program.Fork(nil, func(output int) { outputs = append(outputs, output) })
+	require.NoError(t, forked.Store(0, 9))
+	forked.WriteOutput(5)
+
+	assert.Equal(t, 1, forked.InstructionPointer)
+	assert.Equal(t, 4, forked.RelativeBase)
+	assert.Empty(t, forked.observers)
+	assert.Equal(t, []int{5}, outputs)
+	assert.Equal(t, []int{9, 2}, forked.Memory())
+	assert.Equal(t, []int{1, 2}, program.Memory(), "the forked program must not share memory")
+}
+
+func TestReset(t *testing.T) {
+	program, err := NewProgram("1,2", nil, nil)
+	require.NoError(t, err)
+	program.InstructionPointer = 1
+	program.RelativeBase = 4
+	program.Halted = true
+	program.DetectOverflow = true
+	program.AddObserver(&recordingObserver{})
+	require.NoError(t, program.Store(5, 8))
+
+	program.Reset([]int{3, 4, 5})
+
+	assert.Equal(t, 0, program.InstructionPointer)
+	assert.Equal(t, 0, program.RelativeBase)
+	assert.False(t, program.Halted)
+	assert.True(t, program.DetectOverflow)
+	assert.Len(t, program.observers, 1)
+	assert.Equal(t, []int{3, 4, 5}, program.Memory())
+}
+
+func TestSetIO(t *testing.T) {
+	program, err := NewProgram("1,2", nil, nil)
+	require.NoError(t, err)
+
+	var outputs []int
+	program.SetIO(func() int { return 7 }, func(output int) { outputs = append(outputs, output) })
+
+	assert.Equal(t, 7, program.ReadInput())
+	program.WriteOutput(5)
+	assert.Equal(t, []int{5}, outputs)
+}
+
+func TestMemory(t *testing.T) {
+	program, err := NewProgram("1,2,3", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, program.Store(5, 8))
+
+	memory := program.Memory()
+	assert.Equal(t, []int{1, 2, 3, 0, 0, 8}, memory)
+
+	memory[0] = 9
+	value, err := program.Peek(0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+}
+
+func TestCells(t *testing.T) {
+	program, err := NewProgram("1,2,3", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, program.Store(1<<50, 8))
+
+	assert.Equal(t, 1<<50+1, program.Size())
+	cells := program.Cells()
+	assert.Equal(t, map[int]int{0: 1, 1: 2, 2: 3, 1 << 50: 8}, cells)
+
+	cells[0] = 9
+	value, err := program.Peek(0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	restored := NewProgramFromCells(cells, nil, nil)
+	value, err = restored.Peek(1 << 50)
+	require.NoError(t, err)
+	assert.Equal(t, 8, value)
+	assert.Equal(t, 1<<50+1, restored.Size())
+}
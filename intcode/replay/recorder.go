@@ -0,0 +1,42 @@
+package replay
+
+import (
+	"time"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+// Recorder is an intcode.Observer that records the session of an Intcode program
+type Recorder struct {
+	intcode.NopObserver
+
+	instructions int
+	session      Session
+}
+
+var _ intcode.Observer = &Recorder{}
+
+// NewRecorder returns a new Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// OnInput records an input event
+func (r *Recorder) OnInput(value int, wait time.Duration) {
+	r.session = append(r.session, Event{Instruction: r.instructions, Kind: Input, Value: value})
+}
+
+// OnOutput records an output event
+func (r *Recorder) OnOutput(value int, wait time.Duration) {
+	r.session = append(r.session, Event{Instruction: r.instructions, Kind: Output, Value: value})
+}
+
+// OnInstruction counts the executed instructions
+func (r *Recorder) OnInstruction(event intcode.InstructionEvent) {
+	r.instructions++
+}
+
+// Session returns the events recorded so far
+func (r *Recorder) Session() Session {
+	return r.session
+}
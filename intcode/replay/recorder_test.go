@@ -0,0 +1,36 @@
+package replay
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+// doubler reads a value and writes its double, twice
+var doubler = []int{3, 17, 1002, 17, 2, 18, 4, 18, 3, 17, 1002, 17, 2, 18, 4, 18, 99, 0, 0}
+
+func TestRecorder(t *testing.T) {
+	recorder := NewRecorder()
+	inputs := []int{3, 5}
+	onInput := func() int {
+		input := inputs[0]
+		inputs = inputs[1:]
+		return input
+	}
+
+	intcodeProgram := intcode.NewIntcodeFromState(
+		doubler, 0, 0, onInput, func(int) {}, intcode.WithObserver(recorder),
+	)
+	require.NoError(t, intcodeProgram.Run())
+
+	expected := Session{
+		{Instruction: 0, Kind: Input, Value: 3},
+		{Instruction: 2, Kind: Output, Value: 6},
+		{Instruction: 3, Kind: Input, Value: 5},
+		{Instruction: 5, Kind: Output, Value: 10},
+	}
+	assert.Equal(t, expected, recorder.Session())
+}
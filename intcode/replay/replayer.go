@@ -0,0 +1,101 @@
+package replay
+
+import (
+	"fmt"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+// Divergence is the first difference between a run of a program and the session it replays
+type Divergence struct {
+	// Expected is the event of the session, nil if the program performed more events than recorded
+	Expected *Event
+	// Actual is the event of the program, nil if it halted before performing all the recorded events
+	Actual *Event
+}
+
+func (d *Divergence) Error() string {
+	switch {
+	case d.Expected == nil:
+		return fmt.Sprintf("unexpected %s after the end of the session", d.Actual)
+	case d.Actual == nil:
+		return fmt.Sprintf("program halted, expected %s", d.Expected)
+	default:
+		return fmt.Sprintf("expected %s, got %s", d.Expected, d.Actual)
+	}
+}
+
+// Replay runs the Intcode program stored in memory feeding it the inputs of session. It returns a *Divergence
+// as soon as the program performs an event that differs from the next one of the session,
+// either by its kind, its value or the instruction count at which it happens.
+func Replay(memory []int, session Session, options ...intcode.Option) error {
+	r := &replayer{session: session}
+
+	options = append(options, intcode.WithObserver(r))
+	intcodeProgram := intcode.NewIntcodeFromState(memory, 0, 0, r.onInput, r.onOutput, options...)
+	r.stop = intcodeProgram.Stop
+
+	err := intcodeProgram.Run()
+	if r.divergence != nil {
+		return r.divergence
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.next < len(session) {
+		return &Divergence{Expected: &session[r.next]}
+	}
+	return nil
+}
+
+// replayer feeds a program with the inputs of a session and checks its events against it
+type replayer struct {
+	intcode.NopObserver
+
+	session      Session
+	next         int
+	instructions int
+	divergence   *Divergence
+	stop         func()
+}
+
+func (r *replayer) onInput() int {
+	event := Event{Instruction: r.instructions, Kind: Input}
+	if r.next < len(r.session) && r.session[r.next].Kind == Input {
+		event.Value = r.session[r.next].Value
+	}
+
+	r.check(event)
+	return event.Value
+}
+
+func (r *replayer) onOutput(output int) {
+	r.check(Event{Instruction: r.instructions, Kind: Output, Value: output})
+}
+
+// check compares actual with the next event of the session, stopping the program on the first divergence
+func (r *replayer) check(actual Event) {
+	if r.divergence != nil {
+		return
+	}
+
+	if r.next >= len(r.session) {
+		r.divergence = &Divergence{Actual: &actual}
+		r.stop()
+		return
+	}
+
+	expected := r.session[r.next]
+	if actual != expected {
+		r.divergence = &Divergence{Expected: &expected, Actual: &actual}
+		r.stop()
+		return
+	}
+	r.next++
+}
+
+// OnInstruction counts the executed instructions
+func (r *replayer) OnInstruction(event intcode.InstructionEvent) {
+	r.instructions++
+}
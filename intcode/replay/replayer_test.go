@@ -0,0 +1,100 @@
+package replay
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplay(t *testing.T) {
+	session := Session{
+		{Instruction: 0, Kind: Input, Value: 3},
+		{Instruction: 2, Kind: Output, Value: 6},
+		{Instruction: 3, Kind: Input, Value: 5},
+		{Instruction: 5, Kind: Output, Value: 10},
+	}
+
+	assert.NoError(t, Replay(doubler, session))
+}
+
+func TestReplayDivergence(t *testing.T) {
+	testCases := map[string]struct {
+		session  Session
+		expected *Divergence
+		message  string
+	}{
+		"different value": {
+			session: Session{
+				{Instruction: 0, Kind: Input, Value: 3},
+				{Instruction: 2, Kind: Output, Value: 7},
+			},
+			expected: &Divergence{
+				Expected: &Event{Instruction: 2, Kind: Output, Value: 7},
+				Actual:   &Event{Instruction: 2, Kind: Output, Value: 6},
+			},
+			message: "expected output 7 at instruction 2, got output 6 at instruction 2",
+		},
+		"different kind": {
+			session: Session{
+				{Instruction: 0, Kind: Output, Value: 3},
+			},
+			expected: &Divergence{
+				Expected: &Event{Instruction: 0, Kind: Output, Value: 3},
+				Actual:   &Event{Instruction: 0, Kind: Input, Value: 0},
+			},
+			message: "expected output 3 at instruction 0, got input 0 at instruction 0",
+		},
+		"different instruction": {
+			session: Session{
+				{Instruction: 0, Kind: Input, Value: 3},
+				{Instruction: 1, Kind: Output, Value: 6},
+			},
+			expected: &Divergence{
+				Expected: &Event{Instruction: 1, Kind: Output, Value: 6},
+				Actual:   &Event{Instruction: 2, Kind: Output, Value: 6},
+			},
+			message: "expected output 6 at instruction 1, got output 6 at instruction 2",
+		},
+		"session too short": {
+			session: Session{
+				{Instruction: 0, Kind: Input, Value: 3},
+				{Instruction: 2, Kind: Output, Value: 6},
+			},
+			expected: &Divergence{
+				Actual: &Event{Instruction: 3, Kind: Input, Value: 0},
+			},
+			message: "unexpected input 0 at instruction 3 after the end of the session",
+		},
+		"session too long": {
+			session: Session{
+				{Instruction: 0, Kind: Input, Value: 3},
+				{Instruction: 2, Kind: Output, Value: 6},
+				{Instruction: 3, Kind: Input, Value: 5},
+				{Instruction: 5, Kind: Output, Value: 10},
+				{Instruction: 6, Kind: Output, Value: 0},
+			},
+			expected: &Divergence{
+				Expected: &Event{Instruction: 6, Kind: Output, Value: 0},
+			},
+			message: "program halted, expected output 0 at instruction 6",
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := Replay(doubler, test.session)
+			require.Error(t, err)
+
+			var divergence *Divergence
+			require.True(t, errors.As(err, &divergence), "error must be a divergence: %v", err)
+			assert.Equal(t, test.expected, divergence)
+			assert.EqualError(t, err, test.message)
+		})
+	}
+}
+
+func TestReplayInvalidProgram(t *testing.T) {
+	assert.Error(t, Replay([]int{98}, nil))
+}
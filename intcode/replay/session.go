@@ -0,0 +1,119 @@
+package replay
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of an event of a session
+type Kind int
+
+const (
+	// Input events are values read by the program
+	Input Kind = iota
+	// Output events are values written by the program
+	Output
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Input:
+		return "input"
+	case Output:
+		return "output"
+	default:
+		return fmt.Sprintf("kind(%d)", int(k))
+	}
+}
+
+func parseKind(s string) (Kind, error) {
+	switch s {
+	case "input":
+		return Input, nil
+	case "output":
+		return Output, nil
+	default:
+		return 0, fmt.Errorf("unknown event kind %s", s)
+	}
+}
+
+// Event is an input or an output performed by a program
+type Event struct {
+	// Instruction is the number of instructions executed before the one that performed the event
+	Instruction int
+	Kind        Kind
+	Value       int
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%s %d at instruction %d", e.Kind, e.Value, e.Instruction)
+}
+
+// Session is the sequence of events performed by a run of a program
+type Session []Event
+
+// Write writes the session to w, one event per line
+func (s Session) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, event := range s {
+		_, err := fmt.Fprintf(bw, "%d %s %d\n", event.Instruction, event.Kind, event.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadSession reads a session written by Session.Write from r
+func ReadSession(r io.Reader) (Session, error) {
+	var session Session
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		event, err := parseEvent(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("invalid event at line %d: %w", line, err)
+		}
+		session = append(session, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func parseEvent(s string) (Event, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 3 {
+		return Event{}, fmt.Errorf("expected 3 fields, got %d", len(fields))
+	}
+
+	instruction, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid instruction count %s: %w", fields[0], err)
+	}
+
+	kind, err := parseKind(fields[1])
+	if err != nil {
+		return Event{}, err
+	}
+
+	value, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid value %s: %w", fields[2], err)
+	}
+
+	return Event{
+		Instruction: instruction,
+		Kind:        kind,
+		Value:       value,
+	}, nil
+}
@@ -0,0 +1,52 @@
+package replay
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionWriteAndRead(t *testing.T) {
+	session := Session{
+		{Instruction: 0, Kind: Input, Value: 7},
+		{Instruction: 2, Kind: Output, Value: -14},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, session.Write(&b))
+	assert.Equal(t, "0 input 7\n2 output -14\n", b.String())
+
+	actual, err := ReadSession(&b)
+	require.NoError(t, err)
+	assert.Equal(t, session, actual)
+}
+
+func TestReadSessionSkipsBlankLines(t *testing.T) {
+	session, err := ReadSession(strings.NewReader("\n0 input 1\n\n"))
+	require.NoError(t, err)
+	assert.Equal(t, Session{{Instruction: 0, Kind: Input, Value: 1}}, session)
+}
+
+func TestReadSessionInvalid(t *testing.T) {
+	testCases := map[string]string{
+		"missing field":       "0 input",
+		"invalid instruction": "a input 1",
+		"invalid kind":        "0 read 1",
+		"invalid value":       "0 output a",
+	}
+
+	for name, input := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadSession(strings.NewReader("0 input 1\n" + input))
+			assert.EqualError(t, err, "invalid event at line 2: "+errorOf(input))
+		})
+	}
+}
+
+func errorOf(line string) string {
+	_, err := parseEvent(line)
+	return err.Error()
+}
@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// quantum is the maximum number of instructions that a machine executes before the next one is scheduled
+const quantum = 1000
+
+// Machine is an Intcode program run by a scheduler, which reads its inputs from a queue
+type Machine struct {
+	// Name identifies the machine in errors
+	Name string
+
+	intcode *intcode.Intcode
+	// queue holds the inputs that have been sent to the machine but not read yet
+	queue []int
+	// onOutput is called with each output of the machine, nil to keep them in outputs
+	onOutput func(output int)
+	// outputs holds the outputs of the machine that have not been received yet
+	outputs []int
+}
+
+// Send appends value to the inputs of the machine
+func (m *Machine) Send(value int) {
+	m.queue = append(m.queue, value)
+}
+
+// OnOutput makes the machine call onOutput with each of its outputs instead of keeping them
+// to be received. Passing the Send method of another machine pipes the outputs into it.
+func (m *Machine) OnOutput(onOutput func(output int)) {
+	m.onOutput = onOutput
+}
+
+// Receive returns the oldest output of the machine which has not been received yet,
+// and false if there is none
+func (m *Machine) Receive() (int, bool) {
+	if len(m.outputs) == 0 {
+		return 0, false
+	}
+	output := m.outputs[0]
+	m.outputs = m.outputs[1:]
+	return output, true
+}
+
+// Halted indicates if the machine has been halted
+func (m *Machine) Halted() bool {
+	return m.intcode.Halted()
+}
+
+// Blocked indicates if the machine is waiting for an input that has not been sent yet
+func (m *Machine) Blocked() bool {
+	if m.intcode.Halted() || len(m.queue) > 0 {
+		return false
+	}
+	n, err := m.intcode.Peek(m.intcode.InstructionPointer())
+	return err == nil && n%100 == instruction.InputOpcode
+}
+
+// Intcode returns the Intcode program run by the machine, which can be inspected between runs
+func (m *Machine) Intcode() *intcode.Intcode {
+	return m.intcode
+}
+
+func (m *Machine) read() int {
+	if len(m.queue) == 0 {
+		panic(fmt.Sprintf("machine %s read from an empty queue", m.Name))
+	}
+	value := m.queue[0]
+	m.queue = m.queue[1:]
+	return value
+}
+
+func (m *Machine) write(output int) {
+	if m.onOutput != nil {
+		m.onOutput(output)
+		return
+	}
+	m.outputs = append(m.outputs, output)
+}
+
+// run runs the machine until it blocks, halts or executes a quantum of instructions,
+// and returns the number of instructions executed
+func (m *Machine) run() (int, error) {
+	steps := 0
+	for ; steps < quantum && !m.Halted() && !m.Blocked(); steps++ {
+		err := m.intcode.Step()
+		if err != nil {
+			return steps, fmt.Errorf("machine %s failed: %w", m.Name, err)
+		}
+	}
+	return steps, nil
+}
+
+// Scheduler runs any number of Intcode machines on a single goroutine. Machines are scheduled
+// in round-robin order of creation, which makes every run deterministic: each one runs until
+// it waits for an input that has not been sent, halts or executes a quantum of instructions.
+//
+// A machine is blocked when the instruction it is about to execute is an input and its queue
+// is empty, so machines must use the standard input opcode even with a custom instruction set.
+type Scheduler struct {
+	machines []*Machine
+	// steps is the number of instructions executed by all the machines
+	steps int
+}
+
+// New returns a scheduler without machines
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Spawn adds a machine to the scheduler which runs a fork of program in its current state,
+// sending it inputs as its first values
+func (s *Scheduler) Spawn(name string, program *intcode.Intcode, inputs ...int) *Machine {
+	m := &Machine{Name: name}
+	m.intcode = program.Fork(m.read, m.write)
+	m.queue = append(m.queue, inputs...)
+
+	s.machines = append(s.machines, m)
+	return m
+}
+
+// Machines returns the machines of the scheduler in order of creation
+func (s *Scheduler) Machines() []*Machine {
+	return s.machines
+}
+
+// Steps returns the number of instructions executed by all the machines of the scheduler
+func (s *Scheduler) Steps() int {
+	return s.steps
+}
+
+// Run runs the machines until all of them are halted or blocked, which is how a simulation
+// ends or waits for the caller to send more inputs. It fails as soon as a machine fails.
+func (s *Scheduler) Run() error {
+	for {
+		progress := false
+		for _, m := range s.machines {
+			steps, err := m.run()
+			s.steps += steps
+			if err != nil {
+				return err
+			}
+			if steps > 0 {
+				progress = true
+			}
+		}
+		if !progress {
+			return nil
+		}
+	}
+}
+
+// Halted indicates if all the machines of the scheduler have been halted
+func (s *Scheduler) Halted() bool {
+	for _, m := range s.machines {
+		if !m.Halted() {
+			return false
+		}
+	}
+	return true
+}
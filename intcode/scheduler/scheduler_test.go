@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+const (
+	// doubleProgram outputs twice its input
+	doubleProgram = "3,9,102,2,9,9,4,9,99,0"
+	// echoProgram outputs every input that it reads, forever
+	echoProgram = "3,7,4,7,1105,1,0,0"
+	// incrementProgram outputs its inputs plus one until it outputs 10 or more
+	incrementProgram = "3,20,1001,20,1,20,4,20,1007,20,10,21,1005,21,0,99"
+)
+
+func newProgram(t *testing.T, programString string) *intcode.Intcode {
+	program, err := intcode.NewIntcodeProgram(programString, intcode.MustNotInput, intcode.MustNotOutput)
+	require.NoError(t, err)
+	return program
+}
+
+func TestRunPipe(t *testing.T) {
+	program := newProgram(t, doubleProgram)
+
+	s := New()
+	a := s.Spawn("a", program, 5)
+	b := s.Spawn("b", program)
+	a.OnOutput(b.Send)
+
+	require.NoError(t, s.Run())
+	assert.True(t, s.Halted())
+	assert.Equal(t, 8, s.Steps())
+
+	output, ok := b.Receive()
+	assert.True(t, ok)
+	assert.Equal(t, 20, output)
+
+	_, ok = a.Receive()
+	assert.False(t, ok)
+	assert.Equal(t, []*Machine{a, b}, s.Machines())
+}
+
+func TestRunBlocked(t *testing.T) {
+	s := New()
+	m := s.Spawn("echo", newProgram(t, echoProgram))
+
+	require.NoError(t, s.Run())
+	assert.True(t, m.Blocked())
+	assert.False(t, s.Halted())
+	assert.Equal(t, 0, s.Steps())
+
+	for _, input := range []int{3, 1, 4} {
+		m.Send(input)
+		require.NoError(t, s.Run())
+		assert.True(t, m.Blocked())
+
+		output, ok := m.Receive()
+		assert.True(t, ok)
+		assert.Equal(t, input, output)
+	}
+	assert.Equal(t, 9, s.Steps())
+}
+
+func TestRunFeedbackLoop(t *testing.T) {
+	program := newProgram(t, incrementProgram)
+
+	s := New()
+	a := s.Spawn("a", program, 0)
+	b := s.Spawn("b", program)
+
+	var outputs []int
+	a.OnOutput(func(output int) {
+		outputs = append(outputs, output)
+		b.Send(output)
+	})
+	b.OnOutput(func(output int) {
+		outputs = append(outputs, output)
+		a.Send(output)
+	})
+
+	require.NoError(t, s.Run())
+	assert.True(t, s.Halted())
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, outputs)
+}
+
+func TestRunSpawnsForks(t *testing.T) {
+	program := newProgram(t, doubleProgram)
+
+	s := New()
+	a := s.Spawn("a", program, 1)
+	b := s.Spawn("b", program, 2)
+	require.NoError(t, s.Run())
+
+	value, err := a.Intcode().Peek(9)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	value, err = b.Intcode().Peek(9)
+	require.NoError(t, err)
+	assert.Equal(t, 4, value)
+
+	value, err = program.Peek(9)
+	require.NoError(t, err)
+	assert.Equal(t, 0, value)
+}
+
+func TestRunError(t *testing.T) {
+	s := New()
+	s.Spawn("echo", newProgram(t, echoProgram), 1)
+	s.Spawn("broken", newProgram(t, "98"))
+
+	err := s.Run()
+	assert.EqualError(t, err, "machine broken failed: error parsing instruction: unknown opcode 98")
+}
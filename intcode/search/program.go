@@ -0,0 +1,110 @@
+package search
+
+import (
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+// maxSteps is the maximum number of instructions executed by a program for an assignment
+const maxSteps = 1 << 20
+
+// Outcome is the state of a program once it has halted
+type Outcome struct {
+	// Outputs are the outputs written by the program
+	Outputs []int
+
+	machine *intcode.Intcode
+}
+
+// Memory returns the value at position once the program has halted, or 0 if the position is invalid
+func (o *Outcome) Memory(position int) int {
+	value, _ := o.machine.Peek(position)
+	return value
+}
+
+// Objective scores the outcome of a program, returning false if it does not match
+type Objective func(outcome *Outcome) (score int, ok bool)
+
+// MemoryEquals matches the outcomes holding target at position
+func MemoryEquals(position, target int) Objective {
+	return func(outcome *Outcome) (int, bool) {
+		return 0, outcome.Memory(position) == target
+	}
+}
+
+// OutputEquals matches the outcomes whose output at index, counting from 0, is target
+func OutputEquals(index, target int) Objective {
+	return func(outcome *Outcome) (int, bool) {
+		return 0, index < len(outcome.Outputs) && outcome.Outputs[index] == target
+	}
+}
+
+// LastOutput matches the outcomes with outputs, scoring them with their last output
+func LastOutput() Objective {
+	return func(outcome *Outcome) (int, bool) {
+		if len(outcome.Outputs) == 0 {
+			return 0, false
+		}
+		return outcome.Outputs[len(outcome.Outputs)-1], true
+	}
+}
+
+// Program returns an evaluator which runs the program stored in memory with the values of an
+// assignment for parameters, and scores its outcome with objective. Each assignment runs on its
+// own machine configured with options, taken from a pool of machines which are reset between
+// assignments instead of being created again. Assignments for which the program fails, reads
+// more inputs than parameters or does not halt within a bounded number of instructions do not match.
+func Program(memory []int, parameters []Parameter, objective Objective, options ...intcode.Option) Evaluator {
+	pool := intcode.NewPool(intcode.NewImageFromMemory(memory, options...))
+
+	return func(assignment Assignment) (int, bool, error) {
+		var inputs []int
+		for _, parameter := range parameters {
+			if parameter.Input {
+				inputs = append(inputs, assignment[parameter.Name])
+			}
+		}
+
+		outcome := &Outcome{}
+		exhausted := false
+		onInput := func() int {
+			if len(inputs) == 0 {
+				exhausted = true
+				return 0
+			}
+			input := inputs[0]
+			inputs = inputs[1:]
+			return input
+		}
+		onOutput := func(output int) {
+			outcome.Outputs = append(outcome.Outputs, output)
+		}
+
+		machine := pool.Get(onInput, onOutput)
+		defer pool.Put(machine)
+
+		for _, parameter := range parameters {
+			if parameter.Input {
+				continue
+			}
+			if err := machine.Poke(parameter.Position, assignment[parameter.Name]); err != nil {
+				return 0, false, err
+			}
+		}
+
+		for steps := 0; !machine.Halted(); steps++ {
+			if steps == maxSteps || exhausted {
+				return 0, false, nil
+			}
+			if err := machine.Step(); err != nil {
+				return 0, false, nil
+			}
+		}
+		if exhausted {
+			return 0, false, nil
+		}
+
+		outcome.machine = machine
+		score, ok := objective(outcome)
+		return score, ok, nil
+	}
+}
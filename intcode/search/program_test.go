@@ -0,0 +1,78 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+func TestProgram(t *testing.T) {
+	// mem[0] = mem[noun] + mem[verb], as in day 2
+	memory := []int{1, 0, 0, 0, 99, 7, 12}
+	parameters := []Parameter{Cell("noun", 1, 0, 6), Cell("verb", 2, 0, 6)}
+
+	matches, err := All(Space{Parameters: parameters}, Program(memory, parameters, MemoryEquals(0, 19)))
+	require.NoError(t, err)
+	assert.Equal(t, []Match{
+		{Assignment: Assignment{"noun": 5, "verb": 6}},
+		{Assignment: Assignment{"noun": 6, "verb": 5}},
+	}, matches)
+}
+
+func TestProgramInputs(t *testing.T) {
+	// outputs the difference of its inputs, then their product
+	memory := []int{3, 100, 3, 101, 1002, 101, -1, 102, 1, 100, 102, 102, 4, 102, 2, 100, 101, 102, 4, 102, 99}
+	parameters := []Parameter{Input("x", 0, 9), Input("y", 0, 9)}
+	space := Space{Parameters: parameters}
+
+	first, err := First(space, Program(memory, parameters, OutputEquals(1, 12)))
+	require.NoError(t, err)
+	assert.Equal(t, Assignment{"x": 2, "y": 6}, first.Assignment)
+
+	best, err := Best(space, Program(memory, parameters, LastOutput()))
+	require.NoError(t, err)
+	assert.Equal(t, Match{Assignment: Assignment{"x": 9, "y": 9}, Score: 81}, best)
+}
+
+func TestProgramDoesNotMatch(t *testing.T) {
+	testCases := map[string]struct {
+		memory []int
+	}{
+		"fails":              {memory: []int{98}},
+		"reads more inputs":  {memory: []int{3, 0, 3, 0, 4, 0, 99}},
+		"does not halt":      {memory: []int{1105, 1, 0}},
+		"produces no output": {memory: []int{3, 0, 99}},
+	}
+
+	parameters := []Parameter{Input("x", 0, 1)}
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			matches, err := All(Space{Parameters: parameters}, Program(test.memory, parameters, LastOutput()))
+			require.NoError(t, err)
+			assert.Empty(t, matches)
+		})
+	}
+}
+
+func TestProgramOptions(t *testing.T) {
+	// multiplies its input by 2^62, which overflows when the input is 2
+	memory := []int{3, 9, 1002, 9, 4611686018427387904, 10, 4, 10, 99}
+	parameters := []Parameter{Input("x", 0, 2)}
+
+	matches, err := All(Space{Parameters: parameters}, Program(memory, parameters, LastOutput(), intcode.WithOverflowDetection()))
+	require.NoError(t, err)
+	assert.Equal(t, []Match{
+		{Assignment: Assignment{"x": 0}, Score: 0},
+		{Assignment: Assignment{"x": 1}, Score: 1 << 62},
+	}, matches)
+}
+
+func TestProgramInvalidPosition(t *testing.T) {
+	parameters := []Parameter{Cell("x", -1, 0, 1)}
+
+	_, err := All(Space{Parameters: parameters}, Program([]int{99}, parameters, LastOutput()))
+	assert.Error(t, err)
+}
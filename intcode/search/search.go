@@ -0,0 +1,243 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrNoMatch is returned when no assignment matches
+var ErrNoMatch = errors.New("no assignment matches")
+
+// Parameter is a value of a program which is searched: a memory position set before the
+// program runs or an input, taking the integer values from Min to Max
+type Parameter struct {
+	Name     string
+	Min, Max int
+	// Position is the memory position initialised with the parameter, if it is not an input
+	Position int
+	// Input indicates that the parameter is read as an input instead of being stored in memory
+	Input bool
+}
+
+// Cell returns a parameter stored in memory at position before the program runs
+func Cell(name string, position, min, max int) Parameter {
+	return Parameter{Name: name, Min: min, Max: max, Position: position}
+}
+
+// Input returns a parameter read as an input, the input parameters being read in order
+func Input(name string, min, max int) Parameter {
+	return Parameter{Name: name, Min: min, Max: max, Input: true}
+}
+
+// Assignment holds a value for each parameter, by name
+type Assignment map[string]int
+
+// Space is the set of assignments of values to parameters which is searched. Assignments are
+// enumerated in order, the value of the first parameter varying the slowest.
+type Space struct {
+	Parameters []Parameter
+	// Distinct restricts the assignments to those where all parameters have distinct values
+	Distinct bool
+}
+
+// Evaluator evaluates an assignment, returning its score and whether it matches. An error
+// aborts the search.
+type Evaluator func(assignment Assignment) (score int, ok bool, err error)
+
+// Match is an assignment which matches with its score
+type Match struct {
+	Assignment Assignment
+	Score      int
+}
+
+// All returns all the assignments of space which match, in enumeration order
+func All(space Space, evaluate Evaluator) ([]Match, error) {
+	var matches []indexedMatch
+	err := space.search(evaluate, func(match indexedMatch) {
+		matches = append(matches, match)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].index < matches[j].index })
+	all := make([]Match, len(matches))
+	for i, match := range matches {
+		all[i] = match.Match
+	}
+	return all, nil
+}
+
+// First returns the first assignment of space which matches in enumeration order
+func First(space Space, evaluate Evaluator) (Match, error) {
+	var first *indexedMatch
+	err := space.search(evaluate, func(match indexedMatch) {
+		if first == nil || match.index < first.index {
+			first = &match
+		}
+	}, func(index int) bool {
+		return first != nil && index > first.index
+	})
+	if err != nil {
+		return Match{}, err
+	}
+	if first == nil {
+		return Match{}, ErrNoMatch
+	}
+	return first.Match, nil
+}
+
+// Best returns the assignment of space which matches with the highest score, the first one
+// in enumeration order if there are several
+func Best(space Space, evaluate Evaluator) (Match, error) {
+	var best *indexedMatch
+	err := space.search(evaluate, func(match indexedMatch) {
+		if best == nil || match.Score > best.Score || (match.Score == best.Score && match.index < best.index) {
+			best = &match
+		}
+	}, nil)
+	if err != nil {
+		return Match{}, err
+	}
+	if best == nil {
+		return Match{}, ErrNoMatch
+	}
+	return best.Match, nil
+}
+
+// indexedMatch is a match with the index of its assignment in enumeration order
+type indexedMatch struct {
+	Match
+	index int
+}
+
+// job is an assignment to evaluate with its index in enumeration order
+type job struct {
+	index      int
+	assignment Assignment
+}
+
+// search evaluates the assignments of s in parallel, calling keep with each match. The
+// assignments for which skip returns true are not evaluated, skip being nil to evaluate them all.
+// keep and skip are called with a lock held.
+func (s Space) search(evaluate Evaluator, keep func(match indexedMatch), skip func(index int) bool) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	var mutex sync.Mutex
+	skipped := func(index int) bool {
+		if skip == nil {
+			return false
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		return skip(index)
+	}
+
+	group, ctx := errgroup.WithContext(context.Background())
+	jobs := make(chan job)
+
+	group.Go(func() error {
+		defer close(jobs)
+		index := 0
+		s.enumerate(make(Assignment, len(s.Parameters)), 0, func(assignment Assignment) bool {
+			if skipped(index) {
+				return false
+			}
+			select {
+			case jobs <- job{index: index, assignment: assignment.copy()}:
+				index++
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		return nil
+	})
+
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		group.Go(func() error {
+			for j := range jobs {
+				if skipped(j.index) {
+					continue
+				}
+
+				score, ok, err := evaluate(j.assignment)
+				if err != nil {
+					return fmt.Errorf("could not evaluate assignment %v: %w", j.assignment, err)
+				}
+				if ok {
+					mutex.Lock()
+					keep(indexedMatch{Match: Match{Assignment: j.assignment, Score: score}, index: j.index})
+					mutex.Unlock()
+				}
+			}
+			return nil
+		})
+	}
+
+	return group.Wait()
+}
+
+// validate checks that the parameters have distinct names and non empty ranges
+func (s Space) validate() error {
+	names := make(map[string]bool, len(s.Parameters))
+	for _, parameter := range s.Parameters {
+		if names[parameter.Name] {
+			return fmt.Errorf("duplicated parameter %s", parameter.Name)
+		}
+		names[parameter.Name] = true
+
+		if parameter.Min > parameter.Max {
+			return fmt.Errorf("empty range for parameter %s: [%d, %d]", parameter.Name, parameter.Min, parameter.Max)
+		}
+	}
+	return nil
+}
+
+// enumerate calls visit with each assignment of the parameters from i onwards, until visit
+// returns false, and returns false if it was stopped
+func (s Space) enumerate(assignment Assignment, i int, visit func(assignment Assignment) bool) bool {
+	if i == len(s.Parameters) {
+		return visit(assignment)
+	}
+
+	parameter := s.Parameters[i]
+	// the loop stops before value overflows when Max is the largest int
+	for value := parameter.Min; ; value++ {
+		if !s.Distinct || !s.used(assignment, i, value) {
+			assignment[parameter.Name] = value
+			if !s.enumerate(assignment, i+1, visit) {
+				return false
+			}
+		}
+		if value == parameter.Max {
+			return true
+		}
+	}
+}
+
+// used returns true if value is assigned to one of the first n parameters
+func (s Space) used(assignment Assignment, n, value int) bool {
+	for _, parameter := range s.Parameters[:n] {
+		if assignment[parameter.Name] == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Assignment) copy() Assignment {
+	copied := make(Assignment, len(a))
+	for name, value := range a {
+		copied[name] = value
+	}
+	return copied
+}
@@ -0,0 +1,128 @@
+package search
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sum scores the assignments of x and y with their sum, matching the even sums
+func sum(assignment Assignment) (int, bool, error) {
+	score := assignment["x"] + assignment["y"]
+	return score, score%2 == 0, nil
+}
+
+func TestAll(t *testing.T) {
+	space := Space{Parameters: []Parameter{Input("x", 0, 2), Input("y", 1, 2)}}
+
+	matches, err := All(space, sum)
+	require.NoError(t, err)
+	assert.Equal(t, []Match{
+		{Assignment: Assignment{"x": 0, "y": 2}, Score: 2},
+		{Assignment: Assignment{"x": 1, "y": 1}, Score: 2},
+		{Assignment: Assignment{"x": 2, "y": 2}, Score: 4},
+	}, matches)
+}
+
+func TestAllDistinct(t *testing.T) {
+	space := Space{Parameters: []Parameter{Input("a", 0, 2), Input("b", 0, 2), Input("c", 0, 2)}, Distinct: true}
+
+	matches, err := All(space, func(Assignment) (int, bool, error) { return 0, true, nil })
+	require.NoError(t, err)
+
+	var permutations []Assignment
+	for _, match := range matches {
+		permutations = append(permutations, match.Assignment)
+	}
+	assert.Equal(t, []Assignment{
+		{"a": 0, "b": 1, "c": 2},
+		{"a": 0, "b": 2, "c": 1},
+		{"a": 1, "b": 0, "c": 2},
+		{"a": 1, "b": 2, "c": 0},
+		{"a": 2, "b": 0, "c": 1},
+		{"a": 2, "b": 1, "c": 0},
+	}, permutations)
+}
+
+func TestFirst(t *testing.T) {
+	space := Space{Parameters: []Parameter{Input("x", 0, 999), Input("y", 0, 999)}}
+
+	first, err := First(space, func(assignment Assignment) (int, bool, error) {
+		return 0, assignment["x"]*assignment["y"] == 391, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Assignment{"x": 1, "y": 391}, first.Assignment)
+}
+
+func TestBest(t *testing.T) {
+	space := Space{Parameters: []Parameter{Input("x", -3, 3), Input("y", -3, 3)}}
+
+	best, err := Best(space, func(assignment Assignment) (int, bool, error) {
+		x, y := assignment["x"], assignment["y"]
+		return -(x-1)*(x-1) - (y+2)*(y+2), true, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Match{Assignment: Assignment{"x": 1, "y": -2}, Score: 0}, best)
+}
+
+func TestNoMatch(t *testing.T) {
+	space := Space{Parameters: []Parameter{Input("x", 1, 1), Input("y", 2, 2)}}
+
+	_, err := First(space, sum)
+	assert.True(t, errors.Is(err, ErrNoMatch))
+
+	_, err = Best(space, sum)
+	assert.True(t, errors.Is(err, ErrNoMatch))
+
+	matches, err := All(space, sum)
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestEvaluatorError(t *testing.T) {
+	errBoom := errors.New("boom")
+	space := Space{Parameters: []Parameter{Input("x", 0, 1000)}}
+
+	_, err := All(space, func(assignment Assignment) (int, bool, error) {
+		if assignment["x"] == 500 {
+			return 0, false, errBoom
+		}
+		return 0, true, nil
+	})
+	assert.True(t, errors.Is(err, errBoom))
+	assert.EqualError(t, err, "could not evaluate assignment map[x:500]: boom")
+}
+
+func TestLargestValue(t *testing.T) {
+	space := Space{Parameters: []Parameter{Input("x", math.MaxInt-1, math.MaxInt)}}
+
+	matches, err := All(space, func(Assignment) (int, bool, error) { return 0, true, nil })
+	require.NoError(t, err)
+	assert.Len(t, matches, 2)
+}
+
+func TestInvalidSpace(t *testing.T) {
+	testCases := map[string]struct {
+		space Space
+		err   string
+	}{
+		"duplicated parameter": {
+			space: Space{Parameters: []Parameter{Input("x", 0, 1), Cell("x", 3, 0, 1)}},
+			err:   "duplicated parameter x",
+		},
+		"empty range": {
+			space: Space{Parameters: []Parameter{Input("x", 2, 1)}},
+			err:   "empty range for parameter x: [2, 1]",
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := All(test.space, sum)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/format"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/metrics"
+)
+
+// Limits bounds the resources used by the clients of a server
+type Limits struct {
+	// MaxPrograms and MaxSessions are the maximum numbers of programs and sessions held at the same time
+	MaxPrograms int
+	MaxSessions int
+	// MaxSteps is the maximum number of instructions executed by a request
+	MaxSteps int
+	// Budget is the maximum number of instructions executed by the program of a session
+	Budget int
+	// MaxBodySize is the maximum size in bytes of the body of a request
+	MaxBodySize int64
+	// MaxMemory is the maximum number of memory values returned by a request, including the
+	// memory of a snapshot
+	MaxMemory int
+}
+
+// DefaultLimits are the limits of a server suitable for local tools
+var DefaultLimits = Limits{
+	MaxPrograms: 64,
+	MaxSessions: 256,
+	MaxSteps:    1 << 20,
+	Budget:      1 << 30,
+	MaxBodySize: 1 << 22,
+	MaxMemory:   1 << 16,
+}
+
+// httpError is an error returned to the client with its HTTP status code
+type httpError struct {
+	code int
+	err  error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func errorf(code int, format string, a ...interface{}) error {
+	return &httpError{code: code, err: fmt.Errorf(format, a...)}
+}
+
+// Server is an HTTP server that runs Intcode programs on behalf of its clients. Clients upload
+// programs and create sessions that run them, pushing inputs and reading outputs through JSON
+// requests:
+//
+//	POST   /programs                  uploads a program: {"program": "1,0,0,0,99"}
+//	DELETE /programs/{id}             deletes a program, its sessions keep running
+//	POST   /sessions                  creates a session: {"program": "1", "input": [5]}, or restores
+//	                                  one: {"snapshot": {...}}
+//	GET    /sessions/{id}             returns the state of a session
+//	DELETE /sessions/{id}             deletes a session
+//	POST   /sessions/{id}/input       pushes inputs: {"values": [1, 2]}
+//	GET    /sessions/{id}/output      returns the outputs from the index given by the from query parameter
+//	POST   /sessions/{id}/run         runs until the program halts, fails or waits for an input: {"steps": 100}
+//	POST   /sessions/{id}/step        executes one instruction
+//	GET    /sessions/{id}/memory      returns the values of memory from start, length values at most
+//	GET    /sessions/{id}/snapshot    returns the state of the program, to restore it in a new session
+//	GET    /metrics                   returns the metrics of the sessions in the Prometheus text format,
+//	                                  labelled by session identifier
+//
+// Errors are returned as {"error": "..."} with an HTTP error status code.
+type Server struct {
+	limits Limits
+	mux    *http.ServeMux
+
+	// mutex guards the programs and the sessions, each session being guarded by its own mutex
+	mutex    sync.Mutex
+	programs map[string]*intcode.Image
+	sessions map[string]*session
+	// exporter exports the metrics of the sessions
+	exporter *metrics.Exporter
+	// lastID is the last identifier given to a program or a session
+	lastID int
+}
+
+// NewServer returns a server whose clients are bounded by limits
+func NewServer(limits Limits) *Server {
+	s := &Server{
+		limits:   limits,
+		mux:      http.NewServeMux(),
+		programs: make(map[string]*intcode.Image),
+		sessions: make(map[string]*session),
+		exporter: metrics.NewExporter(),
+	}
+
+	s.mux.HandleFunc("/programs", s.handle(s.programsHandler))
+	s.mux.HandleFunc("/programs/", s.handle(s.programHandler))
+	s.mux.HandleFunc("/sessions", s.handle(s.sessionsHandler))
+	s.mux.HandleFunc("/sessions/", s.handle(s.sessionHandler))
+	s.mux.Handle("/metrics", s.exporter)
+	return s
+}
+
+// ServeHTTP serves a request of a client
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handle returns an HTTP handler which writes the body returned by h as JSON, or its error
+func (s *Server) handle(h func(r *http.Request) (int, interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, s.limits.MaxBodySize)
+
+		code, body, err := h(r)
+		if err != nil {
+			code = http.StatusInternalServerError
+			var httpErr *httpError
+			if errors.As(err, &httpErr) {
+				code = httpErr.code
+			}
+			body = errorBody{Error: err.Error()}
+		}
+
+		if body == nil {
+			w.WriteHeader(code)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
+
+// decode decodes the JSON body of r into v
+func decode(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+// nextID returns a new identifier, it must be called with the lock held
+func (s *Server) nextID() string {
+	s.lastID++
+	return strconv.Itoa(s.lastID)
+}
+
+func methodNotAllowed(r *http.Request) error {
+	return errorf(http.StatusMethodNotAllowed, "method %s not allowed on %s", r.Method, r.URL.Path)
+}
+
+func (s *Server) programsHandler(r *http.Request) (int, interface{}, error) {
+	if r.Method != http.MethodPost {
+		return 0, nil, methodNotAllowed(r)
+	}
+
+	var req uploadRequest
+	if err := decode(r, &req); err != nil {
+		return 0, nil, err
+	}
+	p, err := format.ReadText(strings.NewReader(req.Program))
+	if err != nil {
+		return 0, nil, errorf(http.StatusBadRequest, "invalid program: %v", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.programs) >= s.limits.MaxPrograms {
+		return 0, nil, errorf(http.StatusTooManyRequests, "limit of %d programs reached", s.limits.MaxPrograms)
+	}
+
+	id := s.nextID()
+	s.programs[id] = intcode.NewImageFromMemory(p.Memory)
+	return http.StatusCreated, programBody{ID: id, Size: len(p.Memory)}, nil
+}
+
+func (s *Server) programHandler(r *http.Request) (int, interface{}, error) {
+	id := strings.TrimPrefix(r.URL.Path, "/programs/")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	image, ok := s.programs[id]
+	if !ok {
+		return 0, nil, errorf(http.StatusNotFound, "unknown program %s", id)
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		return http.StatusOK, programBody{ID: id, Size: len(image.Memory())}, nil
+	case http.MethodDelete:
+		delete(s.programs, id)
+		return http.StatusNoContent, nil, nil
+	default:
+		return 0, nil, methodNotAllowed(r)
+	}
+}
+
+func (s *Server) sessionsHandler(r *http.Request) (int, interface{}, error) {
+	if r.Method != http.MethodPost {
+		return 0, nil, methodNotAllowed(r)
+	}
+
+	var req createSessionRequest
+	if err := decode(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.sessions) >= s.limits.MaxSessions {
+		return 0, nil, errorf(http.StatusTooManyRequests, "limit of %d sessions reached", s.limits.MaxSessions)
+	}
+
+	var created *session
+	switch {
+	case req.Program != "" && req.Snapshot != nil:
+		return 0, nil, errorf(http.StatusBadRequest, "expected a program or a snapshot, got both")
+	case req.Snapshot != nil:
+		snapshot := req.Snapshot
+		if snapshot.InstructionPointer < 0 {
+			return 0, nil, errorf(http.StatusBadRequest, "invalid instruction pointer %d", snapshot.InstructionPointer)
+		}
+		inputs := append(append([]int(nil), snapshot.Inputs...), req.Input...)
+		created = newSession(s.nextID(), "", s.limits.Budget, inputs,
+			func(onInput func() int, onOutput func(output int)) *intcode.Intcode {
+				return intcode.NewIntcodeFromState(
+					snapshot.Memory, snapshot.InstructionPointer, snapshot.RelativeBase, onInput, onOutput,
+					intcode.WithMetrics(),
+				)
+			})
+	case req.Program != "":
+		image, ok := s.programs[req.Program]
+		if !ok {
+			return 0, nil, errorf(http.StatusNotFound, "unknown program %s", req.Program)
+		}
+		created = newSession(s.nextID(), req.Program, s.limits.Budget, req.Input,
+			func(onInput func() int, onOutput func(output int)) *intcode.Intcode {
+				return image.New(onInput, onOutput, intcode.WithMetrics())
+			})
+	default:
+		return 0, nil, errorf(http.StatusBadRequest, "expected a program or a snapshot")
+	}
+
+	s.sessions[created.id] = created
+	// identifiers are never reused, so the session cannot be exported already
+	_ = s.exporter.Add(created.id, created.intcode)
+	return http.StatusCreated, created.state(), nil
+}
+
+func (s *Server) sessionHandler(r *http.Request) (int, interface{}, error) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/sessions/"), "/")
+
+	s.mutex.Lock()
+	session, ok := s.sessions[id]
+	if ok && action == "" && r.Method == http.MethodDelete {
+		delete(s.sessions, id)
+		s.exporter.Remove(id)
+	}
+	s.mutex.Unlock()
+	if !ok {
+		return 0, nil, errorf(http.StatusNotFound, "unknown session %s", id)
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		return http.StatusOK, session.state(), nil
+	case action == "" && r.Method == http.MethodDelete:
+		return http.StatusNoContent, nil, nil
+	case action == "input" && r.Method == http.MethodPost:
+		return s.pushInput(r, session)
+	case action == "output" && r.Method == http.MethodGet:
+		return s.readOutput(r, session)
+	case action == "run" && r.Method == http.MethodPost:
+		var req runRequest
+		if err := decode(r, &req); err != nil {
+			return 0, nil, err
+		}
+		return s.run(session, req.Steps)
+	case action == "step" && r.Method == http.MethodPost:
+		return s.run(session, 1)
+	case action == "memory" && r.Method == http.MethodGet:
+		return s.readMemory(r, session)
+	case action == "snapshot" && r.Method == http.MethodGet:
+		snapshot, err := session.snapshot(s.limits.MaxMemory)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, snapshot, nil
+	case action == "" || action == "input" || action == "output" || action == "run" ||
+		action == "step" || action == "memory" || action == "snapshot":
+		return 0, nil, methodNotAllowed(r)
+	default:
+		return 0, nil, errorf(http.StatusNotFound, "unknown action %s", action)
+	}
+}
+
+func (s *Server) pushInput(r *http.Request, session *session) (int, interface{}, error) {
+	var req inputRequest
+	if err := decode(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	session.inputs = append(session.inputs, req.Values...)
+	return http.StatusOK, session.state(), nil
+}
+
+func (s *Server) readOutput(r *http.Request, session *session) (int, interface{}, error) {
+	from, err := queryInt(r, "from", 0)
+	if err != nil {
+		return 0, nil, err
+	}
+	if from > len(session.outputs) {
+		from = len(session.outputs)
+	}
+
+	values := append([]int{}, session.outputs[from:]...)
+	return http.StatusOK, outputBody{Values: values, Next: len(session.outputs)}, nil
+}
+
+// run runs the program of session for steps instructions, or the maximum allowed if steps is 0
+func (s *Server) run(session *session, steps int) (int, interface{}, error) {
+	if steps < 0 {
+		return 0, nil, errorf(http.StatusBadRequest, "invalid number of steps %d", steps)
+	}
+	if steps == 0 || steps > s.limits.MaxSteps {
+		steps = s.limits.MaxSteps
+	}
+
+	switch session.status() {
+	case halted, failed:
+		return 0, nil, errorf(http.StatusConflict, "session %s has %s", session.id, session.status())
+	}
+
+	outputs := len(session.outputs)
+	executed := session.run(steps)
+	return http.StatusOK, runBody{
+		sessionState: session.state(),
+		Executed:     executed,
+		NewOutputs:   append([]int{}, session.outputs[outputs:]...),
+	}, nil
+}
+
+func (s *Server) readMemory(r *http.Request, session *session) (int, interface{}, error) {
+	start, err := queryInt(r, "start", 0)
+	if err != nil {
+		return 0, nil, err
+	}
+	length, err := queryInt(r, "length", s.limits.MaxMemory)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length > s.limits.MaxMemory {
+		length = s.limits.MaxMemory
+	}
+
+	// only the requested values are read, as memory may extend to very large positions
+	if size := session.intcode.MemorySize(); length > size-start {
+		length = size - start
+	}
+	if length < 0 {
+		length = 0
+	}
+	values, err := session.intcode.ReadMemory(start, length)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, memoryBody{Start: start, Values: values}, nil
+}
+
+// queryInt returns the value of the non negative integer query parameter name of r, or
+// defaultValue if it is not given
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(s)
+	if err != nil || value < 0 {
+		return 0, errorf(http.StatusBadRequest, "invalid %s %s", name, s)
+	}
+	return value, nil
+}
@@ -0,0 +1,315 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// doubler outputs twice each of its two inputs
+const doubler = "3,17,1002,17,2,18,4,18,3,17,1002,17,2,18,4,18,99,0,0"
+
+// client sends requests to a test server
+type client struct {
+	t      *testing.T
+	server *httptest.Server
+}
+
+func newClient(t *testing.T, limits Limits) *client {
+	server := httptest.NewServer(NewServer(limits))
+	t.Cleanup(server.Close)
+	return &client{t: t, server: server}
+}
+
+// do sends a request with body encoded in JSON, if it is not nil, and decodes the response into
+// response, if it is not nil. It returns the status code of the response and its error, if any.
+func (c *client) do(method, path string, body, response interface{}) (int, string) {
+	var reader bytes.Buffer
+	if body != nil {
+		require.NoError(c.t, json.NewEncoder(&reader).Encode(body))
+	}
+
+	req, err := http.NewRequest(method, c.server.URL+path, &reader)
+	require.NoError(c.t, err)
+	resp, err := c.server.Client().Do(req)
+	require.NoError(c.t, err)
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var e errorBody
+		require.NoError(c.t, json.NewDecoder(resp.Body).Decode(&e))
+		return resp.StatusCode, e.Error
+	}
+	if response != nil {
+		require.NoError(c.t, json.NewDecoder(resp.Body).Decode(response))
+	}
+	return resp.StatusCode, ""
+}
+
+// upload uploads programString and returns its identifier
+func (c *client) upload(programString string) string {
+	var program programBody
+	code, _ := c.do(http.MethodPost, "/programs", uploadRequest{Program: programString}, &program)
+	require.Equal(c.t, http.StatusCreated, code)
+	return program.ID
+}
+
+// create creates a session and returns its state
+func (c *client) create(req createSessionRequest) sessionState {
+	var state sessionState
+	code, err := c.do(http.MethodPost, "/sessions", req, &state)
+	require.Equal(c.t, http.StatusCreated, code, err)
+	return state
+}
+
+func TestServerSession(t *testing.T) {
+	c := newClient(t, DefaultLimits)
+	program := c.upload(doubler)
+
+	var uploaded programBody
+	code, _ := c.do(http.MethodGet, "/programs/"+program, nil, &uploaded)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, programBody{ID: program, Size: 19}, uploaded)
+
+	state := c.create(createSessionRequest{Program: program, Input: []int{3}})
+	assert.Equal(t, ready, state.Status)
+	path := "/sessions/" + state.ID
+
+	var run runBody
+	code, _ = c.do(http.MethodPost, path+"/run", runRequest{}, &run)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, waiting, run.Status)
+	assert.Equal(t, 3, run.Executed)
+	assert.Equal(t, []int{6}, run.NewOutputs)
+	assert.Equal(t, 8, run.InstructionPointer)
+
+	code, _ = c.do(http.MethodPost, path+"/input", inputRequest{Values: []int{5}}, &state)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, ready, state.Status)
+	assert.Equal(t, 1, state.PendingInputs)
+
+	code, _ = c.do(http.MethodPost, path+"/step", nil, &run)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, run.Executed)
+	assert.Equal(t, 10, run.InstructionPointer)
+
+	code, _ = c.do(http.MethodPost, path+"/run", runRequest{Steps: 100}, &run)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, halted, run.Status)
+	assert.Equal(t, 3, run.Executed)
+	assert.Equal(t, 7, run.Steps)
+	assert.Equal(t, []int{10}, run.NewOutputs)
+
+	var output outputBody
+	code, _ = c.do(http.MethodGet, path+"/output", nil, &output)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, outputBody{Values: []int{6, 10}, Next: 2}, output)
+
+	c.do(http.MethodGet, path+"/output?from=1", nil, &output)
+	assert.Equal(t, outputBody{Values: []int{10}, Next: 2}, output)
+
+	var memory memoryBody
+	code, _ = c.do(http.MethodGet, path+"/memory?start=16&length=5", nil, &memory)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, memoryBody{Start: 16, Values: []int{99, 5, 10}}, memory)
+
+	code, err := c.do(http.MethodPost, path+"/step", nil, nil)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, fmt.Sprintf("session %s has halted", state.ID), err)
+
+	code, _ = c.do(http.MethodDelete, path, nil, nil)
+	assert.Equal(t, http.StatusNoContent, code)
+	code, _ = c.do(http.MethodGet, path, nil, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+// metrics returns the metrics served by the server
+func (c *client) metrics() string {
+	resp, err := c.server.Client().Get(c.server.URL + "/metrics")
+	require.NoError(c.t, err)
+	defer resp.Body.Close()
+	require.Equal(c.t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(c.t, err)
+	return string(body)
+}
+
+func TestServerMetrics(t *testing.T) {
+	c := newClient(t, DefaultLimits)
+	program := c.upload(doubler)
+	first := c.create(createSessionRequest{Program: program, Input: []int{3, 5}})
+	second := c.create(createSessionRequest{Program: program})
+
+	code, _ := c.do(http.MethodPost, "/sessions/"+first.ID+"/run", runRequest{}, nil)
+	require.Equal(t, http.StatusOK, code)
+
+	metrics := c.metrics()
+	assert.Contains(t, metrics, fmt.Sprintf("intcode_instructions_total{machine=%q} 7\n", first.ID))
+	assert.Contains(t, metrics, fmt.Sprintf("intcode_inputs_total{machine=%q} 2\n", first.ID))
+	assert.Contains(t, metrics, fmt.Sprintf("intcode_outputs_total{machine=%q} 2\n", first.ID))
+	assert.Contains(t, metrics, fmt.Sprintf("intcode_instructions_total{machine=%q} 0\n", second.ID))
+
+	code, _ = c.do(http.MethodDelete, "/sessions/"+first.ID, nil, nil)
+	require.Equal(t, http.StatusNoContent, code)
+	metrics = c.metrics()
+	assert.False(t, strings.Contains(metrics, fmt.Sprintf("{machine=%q}", first.ID)))
+	assert.Contains(t, metrics, fmt.Sprintf("{machine=%q}", second.ID))
+}
+
+func TestServerSnapshot(t *testing.T) {
+	c := newClient(t, DefaultLimits)
+	state := c.create(createSessionRequest{Program: c.upload(doubler), Input: []int{3, 4}})
+	path := "/sessions/" + state.ID
+
+	c.do(http.MethodPost, path+"/run", runRequest{Steps: 3}, nil)
+
+	var snap snapshot
+	code, _ := c.do(http.MethodGet, path+"/snapshot", nil, &snap)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 8, snap.InstructionPointer)
+	assert.Equal(t, []int{4}, snap.Inputs)
+	assert.Equal(t, []int{3, 6}, snap.Memory[17:])
+
+	restored := c.create(createSessionRequest{Snapshot: &snap})
+	assert.Empty(t, restored.Program)
+	assert.Equal(t, 8, restored.InstructionPointer)
+
+	var run runBody
+	c.do(http.MethodPost, "/sessions/"+restored.ID+"/run", runRequest{}, &run)
+	assert.Equal(t, halted, run.Status)
+	assert.Equal(t, []int{8}, run.NewOutputs)
+
+	// the original session is not affected by the restored one
+	c.do(http.MethodGet, path, nil, &state)
+	assert.Equal(t, 8, state.InstructionPointer)
+	assert.Equal(t, 1, state.Outputs)
+}
+
+func TestServerHighAddress(t *testing.T) {
+	c := newClient(t, DefaultLimits)
+	state := c.create(createSessionRequest{Program: c.upload("1101,1,1,100000000000000,99")})
+	path := "/sessions/" + state.ID
+
+	var run runBody
+	c.do(http.MethodPost, path+"/run", runRequest{}, &run)
+	assert.Equal(t, halted, run.Status)
+
+	var memory memoryBody
+	code, _ := c.do(http.MethodGet, path+"/memory?start=99999999999999&length=5", nil, &memory)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, memoryBody{Start: 99999999999999, Values: []int{0, 2}}, memory)
+
+	code, _ = c.do(http.MethodGet, path+"/memory?start=100000000000001", nil, &memory)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, memory.Values)
+
+	code, _ = c.do(http.MethodGet, path+"/snapshot", nil, nil)
+	assert.Equal(t, http.StatusConflict, code)
+}
+
+func TestServerLimits(t *testing.T) {
+	limits := DefaultLimits
+	limits.MaxPrograms = 1
+	limits.MaxSessions = 1
+	limits.MaxSteps = 2
+	limits.Budget = 5
+	c := newClient(t, limits)
+
+	program := c.upload("1105,1,0")
+	code, err := c.do(http.MethodPost, "/programs", uploadRequest{Program: "99"}, nil)
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	assert.Equal(t, "limit of 1 programs reached", err)
+
+	state := c.create(createSessionRequest{Program: program})
+	code, err = c.do(http.MethodPost, "/sessions", createSessionRequest{Program: program}, nil)
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	assert.Equal(t, "limit of 1 sessions reached", err)
+
+	path := "/sessions/" + state.ID
+	var run runBody
+	for _, executed := range []int{2, 2, 1} {
+		c.do(http.MethodPost, path+"/run", runRequest{Steps: 10}, &run)
+		assert.Equal(t, executed, run.Executed)
+	}
+	assert.Equal(t, failed, run.Status)
+	assert.Equal(t, 0, run.Budget)
+	assert.Equal(t, "execution budget of 5 instructions exhausted", run.Error)
+
+	code, _ = c.do(http.MethodDelete, "/programs/"+program, nil, nil)
+	assert.Equal(t, http.StatusNoContent, code)
+	c.upload("99")
+}
+
+func TestServerErrors(t *testing.T) {
+	c := newClient(t, DefaultLimits)
+	program := c.upload("98")
+	state := c.create(createSessionRequest{Program: program})
+
+	var run runBody
+	c.do(http.MethodPost, "/sessions/"+state.ID+"/run", runRequest{}, &run)
+	assert.Equal(t, failed, run.Status)
+	assert.Equal(t, "error parsing instruction: unknown opcode 98", run.Error)
+
+	testCases := map[string]struct {
+		method, path string
+		body         interface{}
+		code         int
+		err          string
+	}{
+		"invalid program": {
+			http.MethodPost, "/programs", uploadRequest{Program: "1,a"}, http.StatusBadRequest,
+			"invalid program: line 1: invalid value a",
+		},
+		"unknown field": {
+			http.MethodPost, "/programs", map[string]string{"code": "99"}, http.StatusBadRequest,
+			`invalid request body: json: unknown field "code"`,
+		},
+		"unknown program": {
+			http.MethodPost, "/sessions", createSessionRequest{Program: "9"}, http.StatusNotFound,
+			"unknown program 9",
+		},
+		"no program": {
+			http.MethodPost, "/sessions", createSessionRequest{}, http.StatusBadRequest,
+			"expected a program or a snapshot",
+		},
+		"unknown session": {
+			http.MethodGet, "/sessions/9", nil, http.StatusNotFound, "unknown session 9",
+		},
+		"unknown action": {
+			http.MethodGet, "/sessions/" + state.ID + "/jump", nil, http.StatusNotFound, "unknown action jump",
+		},
+		"method not allowed": {
+			http.MethodGet, "/sessions/" + state.ID + "/run", nil, http.StatusMethodNotAllowed,
+			"method GET not allowed on /sessions/" + state.ID + "/run",
+		},
+		"failed session": {
+			http.MethodPost, "/sessions/" + state.ID + "/run", runRequest{}, http.StatusConflict,
+			"session " + state.ID + " has failed",
+		},
+		"invalid steps": {
+			http.MethodPost, "/sessions/" + state.ID + "/run", runRequest{Steps: -1}, http.StatusBadRequest,
+			"invalid number of steps -1",
+		},
+		"invalid query": {
+			http.MethodGet, "/sessions/" + state.ID + "/memory?start=a", nil, http.StatusBadRequest,
+			"invalid start a",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			code, err := c.do(testCase.method, testCase.path, testCase.body, nil)
+			assert.Equal(t, testCase.code, code)
+			assert.Equal(t, testCase.err, err)
+		})
+	}
+}
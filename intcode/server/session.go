@@ -0,0 +1,162 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// status is the status of the program of a session
+type status string
+
+const (
+	// ready indicates that the program can execute its next instruction
+	ready status = "ready"
+	// waiting indicates that the next instruction of the program reads an input that has not been pushed
+	waiting status = "waiting"
+	// halted indicates that the program has halted
+	halted status = "halted"
+	// failed indicates that the program has failed or exhausted its execution budget
+	failed status = "failed"
+)
+
+// session runs a program on behalf of a client, which pushes its inputs and reads its outputs
+type session struct {
+	// mutex guards the session, which may receive concurrent requests
+	mutex sync.Mutex
+
+	id string
+	// program is the identifier of the program run by the session, empty if it was restored from a snapshot
+	program string
+	intcode *intcode.Intcode
+
+	// inputs holds the inputs that have been pushed but not read yet
+	inputs []int
+	// outputs holds all the outputs written by the program
+	outputs []int
+	// steps is the number of instructions executed by the program
+	steps int
+	// budget is the maximum number of instructions that the program can execute
+	budget int
+	// err is the error that made the program fail, if any
+	err error
+}
+
+// newSession returns a session whose program is created by newIntcode, which is given
+// the callbacks of the session
+func newSession(
+	id, program string,
+	budget int,
+	inputs []int,
+	newIntcode func(onInput func() int, onOutput func(output int)) *intcode.Intcode,
+) *session {
+	s := &session{
+		id:      id,
+		program: program,
+		budget:  budget,
+		inputs:  append([]int(nil), inputs...),
+	}
+	s.intcode = newIntcode(s.read, s.write)
+	return s
+}
+
+func (s *session) read() int {
+	// run never executes an input instruction while the queue is empty
+	value := s.inputs[0]
+	s.inputs = s.inputs[1:]
+	return value
+}
+
+func (s *session) write(output int) {
+	s.outputs = append(s.outputs, output)
+}
+
+// status returns the status of the program of the session
+func (s *session) status() status {
+	switch {
+	case s.err != nil:
+		return failed
+	case s.intcode.Halted():
+		return halted
+	case s.blocked():
+		return waiting
+	default:
+		return ready
+	}
+}
+
+// blocked indicates if the next instruction of the program reads an input that has not been pushed
+func (s *session) blocked() bool {
+	if len(s.inputs) > 0 {
+		return false
+	}
+	n, err := s.intcode.Peek(s.intcode.InstructionPointer())
+	return err == nil && n%100 == instruction.InputOpcode
+}
+
+// run executes at most maxSteps instructions of the program, stopping before if it halts,
+// fails or waits for an input, and returns the number of instructions executed
+func (s *session) run(maxSteps int) int {
+	executed := 0
+	for ; executed < maxSteps && s.status() == ready; executed++ {
+		if s.steps == s.budget {
+			s.err = fmt.Errorf("execution budget of %d instructions exhausted", s.budget)
+			break
+		}
+		if err := s.intcode.Step(); err != nil {
+			s.err = err
+			break
+		}
+		s.steps++
+	}
+	return executed
+}
+
+// state returns the state of the session
+func (s *session) state() sessionState {
+	state := sessionState{
+		ID:                 s.id,
+		Program:            s.program,
+		Status:             s.status(),
+		InstructionPointer: s.intcode.InstructionPointer(),
+		RelativeBase:       s.intcode.RelativeBase(),
+		Steps:              s.steps,
+		Budget:             s.budget - s.steps,
+		PendingInputs:      len(s.inputs),
+		Outputs:            len(s.outputs),
+	}
+	if s.err != nil {
+		state.Error = s.err.Error()
+	}
+	return state
+}
+
+// snapshot returns the state of the program of the session, from which a new session can be
+// restored. It fails if the memory of the program holds more than maxMemory values.
+func (s *session) snapshot(maxMemory int) (snapshot, error) {
+	state := s.intcode.Snapshot()
+	size := 0
+	for position := range state.Cells {
+		if position >= size {
+			size = position + 1
+		}
+	}
+	if size > maxMemory {
+		return snapshot{}, errorf(http.StatusConflict,
+			"memory of %d values is too large for a snapshot of at most %d", size, maxMemory)
+	}
+
+	memory := make([]int, size)
+	for position, value := range state.Cells {
+		memory[position] = value
+	}
+	return snapshot{
+		Memory:             memory,
+		InstructionPointer: state.InstructionPointer,
+		RelativeBase:       state.RelativeBase,
+		Inputs:             append([]int{}, s.inputs...),
+	}, nil
+}
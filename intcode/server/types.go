@@ -0,0 +1,81 @@
+package server
+
+// The types below are the bodies of the requests and responses of the server, encoded in JSON
+
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+type uploadRequest struct {
+	// Program is the program in the text format
+	Program string `json:"program"`
+}
+
+type programBody struct {
+	ID string `json:"id"`
+	// Size is the number of values of the program
+	Size int `json:"size"`
+}
+
+type createSessionRequest struct {
+	// Program is the identifier of the program run by the session
+	Program string `json:"program,omitempty"`
+	// Snapshot is the state from which the session is restored, instead of running a program from its start
+	Snapshot *snapshot `json:"snapshot,omitempty"`
+	// Input are the first inputs of the program
+	Input []int `json:"input,omitempty"`
+}
+
+type sessionState struct {
+	ID                 string `json:"id"`
+	Program            string `json:"program,omitempty"`
+	Status             status `json:"status"`
+	InstructionPointer int    `json:"instructionPointer"`
+	RelativeBase       int    `json:"relativeBase"`
+	// Steps is the number of instructions executed and Budget the number that can still be executed
+	Steps  int `json:"steps"`
+	Budget int `json:"budget"`
+	// PendingInputs is the number of inputs pushed but not read yet
+	PendingInputs int `json:"pendingInputs"`
+	// Outputs is the number of outputs written by the program
+	Outputs int    `json:"outputs"`
+	Error   string `json:"error,omitempty"`
+}
+
+type inputRequest struct {
+	Values []int `json:"values"`
+}
+
+type runRequest struct {
+	// Steps is the maximum number of instructions to execute, 0 for the maximum allowed by the server
+	Steps int `json:"steps"`
+}
+
+type runBody struct {
+	sessionState
+	// Executed is the number of instructions executed by the request
+	Executed int `json:"executed"`
+	// NewOutputs are the outputs written by the request
+	NewOutputs []int `json:"newOutputs"`
+}
+
+type outputBody struct {
+	Values []int `json:"values"`
+	// Next is the index of the next output, to be given as from when reading again
+	Next int `json:"next"`
+}
+
+type memoryBody struct {
+	Start  int   `json:"start"`
+	Values []int `json:"values"`
+}
+
+// snapshot is the state of the program of a session. A halted program is restored before its
+// halt instruction.
+type snapshot struct {
+	Memory             []int `json:"memory"`
+	InstructionPointer int   `json:"instructionPointer"`
+	RelativeBase       int   `json:"relativeBase"`
+	// Inputs are the inputs pushed but not read yet
+	Inputs []int `json:"inputs"`
+}
@@ -0,0 +1,103 @@
+package snapshot
+
+import (
+	"sort"
+)
+
+// Change is a memory cell whose value differs between two snapshots
+type Change struct {
+	Position      int
+	Before, After int
+}
+
+// Diff returns the cells whose value differs between the snapshots before and after, by position
+func Diff(before, after Snapshot) []Change {
+	var changes []Change
+	for position := range before.Cells {
+		if b, a := before.At(position), after.At(position); b != a {
+			changes = append(changes, Change{Position: position, Before: b, After: a})
+		}
+	}
+	for position, a := range after.Cells {
+		if _, ok := before.Cells[position]; !ok && a != 0 {
+			changes = append(changes, Change{Position: position, After: a})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Position < changes[j].Position })
+	return changes
+}
+
+// Heat returns how many times each cell changes between consecutive snapshots, by position.
+// The cells that never change are not included.
+func Heat(snapshots []Snapshot) map[int]int {
+	heat := make(map[int]int)
+	for i := 1; i < len(snapshots); i++ {
+		for _, change := range Diff(snapshots[i-1], snapshots[i]) {
+			heat[change.Position]++
+		}
+	}
+	return heat
+}
+
+// Correlation is a memory cell whose value matches the observed values of some snapshots
+type Correlation struct {
+	Position int
+	// Matches is the number of snapshots whose cell holds their observed value, out of Observed
+	Matches  int
+	Observed int
+}
+
+// Observation returns the value observed at a snapshot, such as one of its outputs, and false
+// if nothing is observed at it
+type Observation func(snapshot Snapshot) (int, bool)
+
+// Output observes the output at index, counting from 0, among the outputs written since the previous
+// snapshot, at the snapshots whose outputs hold the value at where. A negative where observes
+// all the snapshots with enough outputs.
+//
+// For instance, with a snapshot for each triple written by day13, Output(0, 2, 4) observes the
+// horizontal position of the ball, whose tile is 4.
+func Output(index, where, value int) Observation {
+	return func(snapshot Snapshot) (int, bool) {
+		if index >= len(snapshot.Outputs) {
+			return 0, false
+		}
+		if where >= 0 && (where >= len(snapshot.Outputs) || snapshot.Outputs[where] != value) {
+			return 0, false
+		}
+		return snapshot.Outputs[index], true
+	}
+}
+
+// Correlate searches the cells of memory which hold the value observed at the snapshots, and
+// returns the ones which match at least once, those with the most matches first. A cell that
+// matches every observation likely stores the observed value, such as the position of the ball
+// of day13.
+func Correlate(snapshots []Snapshot, observe Observation) []Correlation {
+	matches := make(map[int]int)
+	observed := 0
+	for _, snapshot := range snapshots {
+		value, ok := observe(snapshot)
+		if !ok {
+			continue
+		}
+		observed++
+		for position, cell := range snapshot.Cells {
+			if cell == value {
+				matches[position]++
+			}
+		}
+	}
+
+	correlations := make([]Correlation, 0, len(matches))
+	for position, n := range matches {
+		correlations = append(correlations, Correlation{Position: position, Matches: n, Observed: observed})
+	}
+	sort.Slice(correlations, func(i, j int) bool {
+		if correlations[i].Matches != correlations[j].Matches {
+			return correlations[i].Matches > correlations[j].Matches
+		}
+		return correlations[i].Position < correlations[j].Position
+	})
+	return correlations
+}
@@ -0,0 +1,60 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	before := Snapshot{Cells: map[int]int{0: 1, 1: 2, 2: 3}}
+	after := Snapshot{Cells: map[int]int{0: 1, 1: 5, 2: 3, 3: 0, 4: 7}}
+
+	expected := []Change{
+		{Position: 1, Before: 2, After: 5},
+		{Position: 4, Before: 0, After: 7},
+	}
+	assert.Equal(t, expected, Diff(before, after))
+	assert.Empty(t, Diff(after, after))
+}
+
+func TestHeat(t *testing.T) {
+	snapshots := record(t, ballProgram, EveryOutputs(3))
+	assert.Equal(t, map[int]int{30: 3, 31: 2}, Heat(snapshots))
+	assert.Equal(t, []int{30, 31}, Changing(snapshots))
+}
+
+func TestCorrelate(t *testing.T) {
+	snapshots := record(t, ballProgram, EveryOutputs(3))
+
+	correlations := Correlate(snapshots, Output(0, 2, 4))
+	require.NotEmpty(t, correlations)
+	assert.Equal(t, Correlation{Position: 30, Matches: 3, Observed: 3}, correlations[0])
+	for _, correlation := range correlations[1:] {
+		assert.Less(t, correlation.Matches, 3)
+	}
+
+	assert.Empty(t, Correlate(snapshots, Output(0, 2, 3)))
+}
+
+func TestOutput(t *testing.T) {
+	snapshot := Snapshot{Outputs: []int{5, 6, 4}}
+
+	value, ok := Output(1, -1, 0)(snapshot)
+	assert.True(t, ok)
+	assert.Equal(t, 6, value)
+
+	value, ok = Output(0, 2, 4)(snapshot)
+	assert.True(t, ok)
+	assert.Equal(t, 5, value)
+
+	_, ok = Output(0, 2, 3)(snapshot)
+	assert.False(t, ok)
+
+	_, ok = Output(3, -1, 0)(snapshot)
+	assert.False(t, ok)
+
+	_, ok = Output(0, 5, 0)(snapshot)
+	assert.False(t, ok)
+}
@@ -0,0 +1,72 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Changing returns the positions of the cells which change between consecutive snapshots, in order
+func Changing(snapshots []Snapshot) []int {
+	heat := Heat(snapshots)
+	positions := make([]int, 0, len(heat))
+	for position := range heat {
+		positions = append(positions, position)
+	}
+	sort.Ints(positions)
+	return positions
+}
+
+// Table renders the values of the cells at positions in each snapshot, one row per snapshot
+// with the step and the address at which it was taken and its outputs. Values which changed
+// since the previous snapshot are marked with a *.
+func Table(snapshots []Snapshot, positions []int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%8s %6s |", "step", "addr")
+	for _, position := range positions {
+		fmt.Fprintf(&sb, " %8s", "["+strconv.Itoa(position)+"]")
+	}
+	sb.WriteString(" | outputs\n")
+
+	for i, snapshot := range snapshots {
+		address := "-"
+		if snapshot.Address >= 0 {
+			address = strconv.Itoa(snapshot.Address)
+		}
+		fmt.Fprintf(&sb, "%8d %6s |", snapshot.Step, address)
+
+		for _, position := range positions {
+			mark := " "
+			if i > 0 && snapshots[i-1].At(position) != snapshot.At(position) {
+				mark = "*"
+			}
+			fmt.Fprintf(&sb, " %7d%s", snapshot.At(position), mark)
+		}
+
+		sb.WriteString(" |")
+		for j, output := range snapshot.Outputs {
+			if j == 0 {
+				sb.WriteString(" ")
+			} else {
+				sb.WriteString(",")
+			}
+			sb.WriteString(strconv.Itoa(output))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// DiffString renders the changes between two snapshots, one cell per line
+func DiffString(changes []Change) string {
+	if len(changes) == 0 {
+		return "  (no changes)\n"
+	}
+
+	var sb strings.Builder
+	for _, change := range changes {
+		fmt.Fprintf(&sb, "%6d: %d -> %d\n", change.Position, change.Before, change.After)
+	}
+	return sb.String()
+}
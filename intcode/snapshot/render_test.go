@@ -0,0 +1,26 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTable(t *testing.T) {
+	snapshots := record(t, ballProgram, EveryOutputs(3))
+
+	expected := "" +
+		"    step   addr |     [30]     [31] | outputs\n" +
+		"       0      - |       0        0  |\n" +
+		"       4      8 |       0        0  | 0,0,4\n" +
+		"      10      8 |       1*       1* | 1,0,4\n" +
+		"      16      8 |       2*       1  | 2,0,4\n" +
+		"      20     21 |       3*       0* |\n"
+	assert.Equal(t, expected, Table(snapshots, Changing(snapshots)))
+}
+
+func TestDiffString(t *testing.T) {
+	changes := []Change{{Position: 1, Before: 2, After: 5}, {Position: 40, Before: 0, After: -7}}
+	assert.Equal(t, "     1: 2 -> 5\n    40: 0 -> -7\n", DiffString(changes))
+	assert.Equal(t, "  (no changes)\n", DiffString(nil))
+}
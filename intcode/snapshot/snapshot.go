@@ -0,0 +1,132 @@
+package snapshot
+
+import (
+	"time"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+// Snapshot is the memory of a program at a point of its execution
+type Snapshot struct {
+	// Step is the number of instructions executed when the snapshot was taken
+	Step int
+	// Address is the address of the last instruction executed, -1 if none was executed
+	Address int
+	// Outputs are the outputs written since the previous snapshot
+	Outputs []int
+	// Cells are the values of the positions of memory that have been set, which are kept
+	// sparse as programs may store values at very large positions
+	Cells map[int]int
+}
+
+// At returns the value at position of the memory of the snapshot, which is 0 if it was never set
+func (s Snapshot) At(position int) int {
+	return s.Cells[position]
+}
+
+// Trigger decides whether a snapshot is taken once the instruction of event has been executed,
+// given the outputs written since the previous snapshot
+type Trigger func(event intcode.InstructionEvent, outputs []int) bool
+
+// EveryOutputs takes a snapshot each time the program writes n outputs, such as each
+// triple written by the arcade cabinet of day13
+func EveryOutputs(n int) Trigger {
+	return func(event intcode.InstructionEvent, outputs []int) bool {
+		return len(outputs) >= n
+	}
+}
+
+// AtAddresses takes a snapshot each time one of the instructions at addresses is executed
+func AtAddresses(addresses ...int) Trigger {
+	set := make(map[int]bool, len(addresses))
+	for _, address := range addresses {
+		set[address] = true
+	}
+	return func(event intcode.InstructionEvent, outputs []int) bool {
+		return set[event.Address]
+	}
+}
+
+// Any takes a snapshot when any of triggers does
+func Any(triggers ...Trigger) Trigger {
+	return func(event intcode.InstructionEvent, outputs []int) bool {
+		for _, trigger := range triggers {
+			if trigger(event, outputs) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Recorder is an observer that takes snapshots of the memory of a program while it runs.
+// It keeps its own copy of the memory, updated with the stores of the program, so that the
+// stores done with Poke are not seen.
+type Recorder struct {
+	intcode.NopObserver
+
+	trigger Trigger
+	cells   map[int]int
+	steps   int
+	// address is the address of the last instruction executed, -1 if none was executed
+	address int
+	// outputs are the outputs written since the previous snapshot
+	outputs []int
+	// Snapshots are the snapshots taken, the first one being the initial memory of the program
+	Snapshots []Snapshot
+}
+
+// NewRecorder returns a recorder of a program whose memory is initially memory, which
+// takes a snapshot each time trigger decides so
+func NewRecorder(memory []int, trigger Trigger) *Recorder {
+	r := &Recorder{
+		trigger: trigger,
+		cells:   make(map[int]int, len(memory)),
+		address: -1,
+	}
+	for position, value := range memory {
+		r.cells[position] = value
+	}
+	r.Snapshots = []Snapshot{{Address: -1, Cells: r.Cells()}}
+	return r
+}
+
+// Cells returns a copy of the values of the positions of the current memory of the program
+// that have been set
+func (r *Recorder) Cells() map[int]int {
+	cells := make(map[int]int, len(r.cells))
+	for position, value := range r.cells {
+		cells[position] = value
+	}
+	return cells
+}
+
+// Take takes a snapshot of the current memory, such as when the program halts or waits for an input
+func (r *Recorder) Take() {
+	r.Snapshots = append(r.Snapshots, Snapshot{
+		Step:    r.steps,
+		Address: r.address,
+		Outputs: r.outputs,
+		Cells:   r.Cells(),
+	})
+	r.outputs = nil
+}
+
+// OnStore updates the copy of the memory
+func (r *Recorder) OnStore(position, previous, value int) {
+	r.cells[position] = value
+}
+
+// OnOutput keeps the outputs written since the previous snapshot
+func (r *Recorder) OnOutput(value int, wait time.Duration) {
+	r.outputs = append(r.outputs, value)
+}
+
+// OnInstruction takes a snapshot if the trigger decides so
+func (r *Recorder) OnInstruction(event intcode.InstructionEvent) {
+	r.steps++
+	r.address = event.Address
+	if r.trigger(event, r.outputs) {
+		r.Take()
+	}
+}
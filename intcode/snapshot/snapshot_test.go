@@ -0,0 +1,82 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+)
+
+// ballProgram stores the position of a ball at 30 and outputs it as the triple (x, 0, 4)
+// for x from 0 to 2, keeping at 31 whether it has to move again
+const ballProgram = "1101,0,0,30,4,30,104,0,104,4,1001,30,1,30,1007,30,3,31,1005,31,4,99"
+
+// record runs programString and returns the snapshots taken by trigger and once it halts
+func record(t *testing.T, programString string, trigger Trigger) []Snapshot {
+	memory, err := program.Parse(programString)
+	require.NoError(t, err)
+
+	recorder := NewRecorder(memory, trigger)
+	intcodeProgram := intcode.NewIntcodeFromState(
+		memory, 0, 0, intcode.MustNotInput, func(int) {}, intcode.WithObserver(recorder),
+	)
+	require.NoError(t, intcodeProgram.Run())
+	recorder.Take()
+
+	assert.Equal(t, intcodeProgram.Cells(), recorder.Cells())
+	return recorder.Snapshots
+}
+
+func TestRecorderEveryOutputs(t *testing.T) {
+	snapshots := record(t, ballProgram, EveryOutputs(3))
+	require.Len(t, snapshots, 5)
+
+	steps := make([]int, len(snapshots))
+	addresses := make([]int, len(snapshots))
+	outputs := make([][]int, len(snapshots))
+	for i, snapshot := range snapshots {
+		steps[i] = snapshot.Step
+		addresses[i] = snapshot.Address
+		outputs[i] = snapshot.Outputs
+	}
+	assert.Equal(t, []int{0, 4, 10, 16, 20}, steps)
+	assert.Equal(t, []int{-1, 8, 8, 8, 21}, addresses)
+	assert.Equal(t, [][]int{nil, {0, 0, 4}, {1, 0, 4}, {2, 0, 4}, nil}, outputs)
+
+	assert.Len(t, snapshots[0].Cells, 22)
+	assert.Equal(t, 0, snapshots[0].At(30))
+	assert.Equal(t, 3, snapshots[4].At(30))
+}
+
+func TestRecorderAtAddresses(t *testing.T) {
+	snapshots := record(t, ballProgram, Any(AtAddresses(14), AtAddresses(0)))
+
+	var values []int
+	for _, snapshot := range snapshots[1 : len(snapshots)-1] {
+		values = append(values, snapshot.At(30))
+	}
+	assert.Equal(t, []int{0, 1, 2, 3}, values)
+}
+
+func TestRecorderPoke(t *testing.T) {
+	memory := []int{99, 5}
+	recorder := NewRecorder(memory, EveryOutputs(1))
+	intcodeProgram := intcode.NewIntcodeFromState(
+		memory, 0, 0, intcode.MustNotInput, intcode.MustNotOutput, intcode.WithObserver(recorder),
+	)
+	require.NoError(t, intcodeProgram.Poke(1, 7))
+	memory[1] = 6
+
+	assert.Equal(t, map[int]int{0: 99, 1: 5}, recorder.Cells(), "pokes and the given memory must not be seen")
+}
+
+func TestRecorderHighAddress(t *testing.T) {
+	snapshots := record(t, "1101,1,1,100000000000000,99", AtAddresses(0))
+	require.Len(t, snapshots, 3)
+
+	assert.Equal(t, 2, snapshots[1].At(100000000000000))
+	assert.Equal(t, []Change{{Position: 100000000000000, After: 2}}, Diff(snapshots[0], snapshots[1]))
+}
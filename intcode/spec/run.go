@@ -0,0 +1,91 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+// maxSteps is the maximum number of instructions executed by the program of a spec
+const maxSteps = 1 << 24
+
+// errMissingInput stops a program which expects more inputs than given by its spec
+var errMissingInput = errors.New("program expects more inputs than given")
+
+// Run runs the program of the spec and returns the differences between its behaviour and
+// the expected one, which are empty if the spec passes
+func (s Spec) Run() []string {
+	inputs := s.Inputs
+	var outputs []int
+	missingInput := false
+
+	onInput := func() int {
+		if len(inputs) == 0 {
+			missingInput = true
+			return 0
+		}
+		input := inputs[0]
+		inputs = inputs[1:]
+		return input
+	}
+	onOutput := func(output int) {
+		outputs = append(outputs, output)
+	}
+	program := intcode.NewIntcodeFromState(s.Memory, 0, 0, onInput, onOutput)
+
+	err := run(program, &missingInput)
+
+	var diffs []string
+	switch {
+	case s.Error == "" && err != nil:
+		diffs = append(diffs, fmt.Sprintf("unexpected error: %v", err))
+	case s.Error != "" && err == nil:
+		diffs = append(diffs, fmt.Sprintf("expected error containing %q, got none", s.Error))
+	case s.Error != "" && !strings.Contains(err.Error(), s.Error):
+		diffs = append(diffs, fmt.Sprintf("expected error containing %q, got: %v", s.Error, err))
+	}
+
+	diffs = append(diffs, diffOutputs(s.Outputs, outputs)...)
+	for _, cell := range s.Cells {
+		value, _ := program.Peek(cell.Position)
+		if value != cell.Value {
+			diffs = append(diffs, fmt.Sprintf("memory[%d]: expected %d, got %d", cell.Position, cell.Value, value))
+		}
+	}
+	return diffs
+}
+
+// run runs program until it halts, failing if it does not halt within maxSteps or if
+// missingInput is set because it expects more inputs than given
+func run(program *intcode.Intcode, missingInput *bool) error {
+	for steps := 0; !program.Halted(); steps++ {
+		if steps == maxSteps {
+			return fmt.Errorf("program did not halt within %d instructions", maxSteps)
+		}
+		if err := program.Step(); err != nil {
+			return err
+		}
+		if *missingInput {
+			return errMissingInput
+		}
+	}
+	return nil
+}
+
+// diffOutputs returns the differences between the expected and the actual outputs
+func diffOutputs(expected, actual []int) []string {
+	var diffs []string
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		switch {
+		case i >= len(actual):
+			diffs = append(diffs, fmt.Sprintf("output %d: expected %d, got none", i, expected[i]))
+		case i >= len(expected):
+			diffs = append(diffs, fmt.Sprintf("output %d: unexpected %d", i, actual[i]))
+		case expected[i] != actual[i]:
+			diffs = append(diffs, fmt.Sprintf("output %d: expected %d, got %d", i, expected[i], actual[i]))
+		}
+	}
+	return diffs
+}
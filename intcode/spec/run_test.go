@@ -0,0 +1,66 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	testCases := map[string]struct {
+		spec     Spec
+		expected []string
+	}{
+		"passes": {
+			spec: Spec{
+				Memory:  []int{3, 11, 3, 12, 1, 11, 12, 13, 4, 13, 99, 0, 0, 0},
+				Inputs:  []int{2, 3},
+				Outputs: []int{5},
+				Cells:   []Cell{{Position: 13, Value: 5}},
+			},
+		},
+		"wrong outputs": {
+			spec: Spec{
+				Memory:  []int{104, 1, 104, 2, 104, 3, 99},
+				Outputs: []int{1, 5},
+			},
+			expected: []string{"output 1: expected 5, got 2", "output 2: unexpected 3"},
+		},
+		"missing output": {
+			spec: Spec{
+				Memory:  []int{104, 1, 99},
+				Outputs: []int{1, 2},
+			},
+			expected: []string{"output 1: expected 2, got none"},
+		},
+		"wrong memory": {
+			spec: Spec{
+				Memory: []int{1, 0, 0, 0, 99},
+				Cells:  []Cell{{Position: 0, Value: 3}, {Position: 100, Value: 0}},
+			},
+			expected: []string{"memory[0]: expected 3, got 2"},
+		},
+		"unexpected error": {
+			spec:     Spec{Memory: []int{3, 0, 99}},
+			expected: []string{"unexpected error: program expects more inputs than given"},
+		},
+		"expected error": {
+			spec:     Spec{Memory: []int{99}, Error: "unknown opcode"},
+			expected: []string{`expected error containing "unknown opcode", got none`},
+		},
+		"wrong error": {
+			spec:     Spec{Memory: []int{98}, Error: "more inputs"},
+			expected: []string{`expected error containing "more inputs", got: error parsing instruction: unknown opcode 98`},
+		},
+		"does not halt": {
+			spec:     Spec{Memory: []int{1105, 1, 0}},
+			expected: []string{"unexpected error: program did not halt within 16777216 instructions"},
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.spec.Run())
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package spec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/format"
+)
+
+// Extension is the extension of spec files
+const Extension = ".spec"
+
+// separator is the line that separates the specs of a file
+const separator = "---"
+
+// Cell is a memory position with its expected value
+type Cell struct {
+	Position int
+	Value    int
+}
+
+// Spec describes the expected behaviour of a program run with some inputs
+type Spec struct {
+	// Name identifies the spec within its file
+	Name string
+	// File is the file where the spec is defined and Line the line where it starts
+	File string
+	Line int
+
+	// Memory is the initial memory of the program
+	Memory []int
+	// Inputs are the inputs given to the program, in order
+	Inputs []int
+	// Outputs are the expected outputs of the program, in order
+	Outputs []int
+	// Cells are the expected values of memory positions once the program halts
+	Cells []Cell
+	// Error is a text that the error of the program must contain, empty if the program must halt
+	Error string
+}
+
+// String returns the name of the spec prefixed by its file
+func (s Spec) String() string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(filepath.Base(s.File), Extension), s.Name)
+}
+
+// Parse parses the specs of r, which is read from the file filename.
+//
+// A spec file holds specs separated by lines "---". Each spec is a list of "key: value" lines:
+//   - name: the name of the spec, by default the line where it starts
+//   - program: the program in the text format, or program-file: a file holding the program,
+//     relative to the directory of the spec file
+//   - input: comma separated inputs given to the program
+//   - output: comma separated outputs expected from the program
+//   - memory: comma separated "position=value" pairs expected in memory once the program halts
+//   - error: text contained in the error expected from the program
+//
+// The program, input, output and memory keys can be repeated to split long values. Empty lines and lines
+// starting with # are ignored.
+func Parse(r io.Reader, filename string) ([]Spec, error) {
+	var specs []Spec
+	var current *builder
+
+	end := func() error {
+		if current == nil {
+			return nil
+		}
+		spec, err := current.build()
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", filename, current.spec.Line, err)
+		}
+		specs = append(specs, spec)
+		current = nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == separator {
+			if err := end(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if current == nil {
+			current = &builder{
+				spec: Spec{Name: fmt.Sprintf("line %d", n), File: filename, Line: n},
+				dir:  filepath.Dir(filename),
+			}
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected a key and a value separated by a colon", filename, n)
+		}
+
+		err := current.set(strings.TrimSpace(key), strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filename, n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", filename, err)
+	}
+
+	if err := end(); err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// builder builds a spec from the lines of a spec file
+type builder struct {
+	spec Spec
+	// dir is the directory of the spec file
+	dir string
+	// program are the lines of the program given in the spec file
+	program []string
+	// programFile is the file holding the program, if any
+	programFile string
+}
+
+// set sets the field of the spec given by key to value
+func (b *builder) set(key, value string) error {
+	switch key {
+	case "name":
+		b.spec.Name = value
+	case "program":
+		b.program = append(b.program, value)
+	case "program-file":
+		if b.programFile != "" {
+			return fmt.Errorf("spec has more than one program file")
+		}
+		b.programFile = value
+	case "input":
+		inputs, err := parseValues(value)
+		if err != nil {
+			return err
+		}
+		b.spec.Inputs = append(b.spec.Inputs, inputs...)
+	case "output":
+		outputs, err := parseValues(value)
+		if err != nil {
+			return err
+		}
+		b.spec.Outputs = append(b.spec.Outputs, outputs...)
+	case "memory":
+		cells, err := parseCells(value)
+		if err != nil {
+			return err
+		}
+		b.spec.Cells = append(b.spec.Cells, cells...)
+	case "error":
+		if value == "" {
+			return fmt.Errorf("expected error must not be empty")
+		}
+		b.spec.Error = value
+	default:
+		return fmt.Errorf("unknown key %s", key)
+	}
+	return nil
+}
+
+// build returns the spec once all its lines have been set
+func (b *builder) build() (Spec, error) {
+	var p *format.Program
+	var err error
+	switch {
+	case b.program != nil && b.programFile != "":
+		return Spec{}, fmt.Errorf("spec has both a program and a program file")
+	case b.program != nil:
+		p, err = format.ReadText(strings.NewReader(strings.Join(b.program, "\n")))
+	case b.programFile != "":
+		p, err = format.Load(filepath.Join(b.dir, b.programFile))
+	default:
+		return Spec{}, fmt.Errorf("spec has no program")
+	}
+	if err != nil {
+		return Spec{}, err
+	}
+
+	b.spec.Memory = p.Memory
+	return b.spec, nil
+}
+
+// parseValues parses a comma separated list of values
+func parseValues(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var values []int
+	for _, token := range strings.Split(s, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(token))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s: %w", token, err)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// parseCells parses a comma separated list of "position=value" pairs
+func parseCells(s string) ([]Cell, error) {
+	var cells []Cell
+	for _, token := range strings.Split(s, ",") {
+		position, value, ok := strings.Cut(token, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid memory cell %s: expected position=value", strings.TrimSpace(token))
+		}
+
+		var cell Cell
+		var err error
+		cell.Position, err = strconv.Atoi(strings.TrimSpace(position))
+		if err != nil || cell.Position < 0 {
+			return nil, fmt.Errorf("invalid memory position %s", strings.TrimSpace(position))
+		}
+		cell.Value, err = strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory value %s: %w", strings.TrimSpace(value), err)
+		}
+		cells = append(cells, cell)
+	}
+	return cells, nil
+}
+
+// ParseFile parses the specs of filename
+func ParseFile(filename string) ([]Spec, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not open file %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	return Parse(f, filename)
+}
+
+// ParseDir parses the specs of all the spec files in dir, in order of file name
+func ParseDir(dir string) ([]Spec, error) {
+	filenames, err := filepath.Glob(filepath.Join(dir, "*"+Extension))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(filenames)
+
+	var specs []Spec
+	for _, filename := range filenames {
+		fileSpecs, err := ParseFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, fileSpecs...)
+	}
+	return specs, nil
+}
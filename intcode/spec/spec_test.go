@@ -0,0 +1,115 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	text := "# adds two numbers\n" +
+		"name: add\n" +
+		"program: 3,11,3,12,1,11,12,13\n" +
+		"program: 4,13,99,0,0,0\n" +
+		"input: 2, 3\n" +
+		"output: 5\n" +
+		"memory: 11=2, 13=5\n" +
+		"---\n" +
+		"\n" +
+		"program: 98\n" +
+		"error: unknown opcode\n"
+
+	specs, err := Parse(strings.NewReader(text), "testdata/add.spec")
+	require.NoError(t, err)
+
+	expected := []Spec{
+		{
+			Name:    "add",
+			File:    "testdata/add.spec",
+			Line:    2,
+			Memory:  []int{3, 11, 3, 12, 1, 11, 12, 13, 4, 13, 99, 0, 0, 0},
+			Inputs:  []int{2, 3},
+			Outputs: []int{5},
+			Cells:   []Cell{{Position: 11, Value: 2}, {Position: 13, Value: 5}},
+		},
+		{
+			Name:   "line 10",
+			File:   "testdata/add.spec",
+			Line:   10,
+			Memory: []int{98},
+			Error:  "unknown opcode",
+		},
+	}
+	assert.Equal(t, expected, specs)
+	assert.Equal(t, "add/add", specs[0].String())
+}
+
+func TestParseProgramFile(t *testing.T) {
+	specs, err := Parse(strings.NewReader("program-file: ../../../day02/day02.txt\n"), "testdata/day02.spec")
+	require.NoError(t, err)
+
+	require.Len(t, specs, 1)
+	assert.Equal(t, []int{1, 0, 0, 3}, specs[0].Memory[:4])
+}
+
+func TestParseInvalid(t *testing.T) {
+	testCases := map[string]struct {
+		text string
+		err  string
+	}{
+		"missing colon": {
+			text: "program: 99\ninput 1\n",
+			err:  "test.spec:2: expected a key and a value separated by a colon",
+		},
+		"unknown key": {
+			text: "program: 99\ninputs: 1\n",
+			err:  "test.spec:2: unknown key inputs",
+		},
+		"invalid value": {
+			text: "program: 99\noutput: 1,x\n",
+			err:  `test.spec:2: invalid value x: strconv.Atoi: parsing "x": invalid syntax`,
+		},
+		"invalid memory cell": {
+			text: "program: 99\nmemory: 0\n",
+			err:  "test.spec:2: invalid memory cell 0: expected position=value",
+		},
+		"negative memory position": {
+			text: "program: 99\nmemory: -1=0\n",
+			err:  "test.spec:2: invalid memory position -1",
+		},
+		"empty error": {
+			text: "program: 99\nerror:\n",
+			err:  "test.spec:2: expected error must not be empty",
+		},
+		"no program": {
+			text: "program: 99\n---\n# no program\ninput: 1\n",
+			err:  "test.spec:4: spec has no program",
+		},
+		"program and program file": {
+			text: "program: 99\nprogram-file: day02.txt\n",
+			err:  "test.spec:1: spec has both a program and a program file",
+		},
+		"invalid program": {
+			text: "program: 1,,2\n",
+			err:  "test.spec:1: line 1: missing value between commas",
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(test.text), "test.spec")
+			require.Error(t, err)
+			assert.Equal(t, test.err, err.Error())
+		})
+	}
+}
+
+func TestParseDir(t *testing.T) {
+	specs, err := ParseDir("testdata")
+	require.NoError(t, err)
+
+	require.NotEmpty(t, specs)
+	assert.Equal(t, "day02/line 2", specs[0].String())
+}
@@ -0,0 +1,30 @@
+// Package spectest runs Intcode spec files as Go tests
+package spectest
+
+import (
+	"testing"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/spec"
+)
+
+// RunDir runs the specs of all the spec files in dir as subtests of t, reporting their differences
+func RunDir(t *testing.T, dir string) {
+	t.Helper()
+
+	specs, err := spec.ParseDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) == 0 {
+		t.Fatalf("no specs found in %s", dir)
+	}
+
+	for _, s := range specs {
+		s := s
+		t.Run(s.String(), func(t *testing.T) {
+			for _, diff := range s.Run() {
+				t.Errorf("%s:%d: %s", s.File, s.Line, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package spectest
+
+import "testing"
+
+func TestRunDir(t *testing.T) {
+	RunDir(t, "../testdata")
+}
@@ -0,0 +1,15 @@
+# examples of day 2, checking the memory once the program halts
+program: 1,9,10,3,2,3,11,0,99,30,40,50
+memory: 0=3500, 3=70
+---
+program: 1,0,0,0,99
+memory: 0=2
+---
+program: 2,3,0,3,99
+memory: 3=6
+---
+program: 2,4,4,5,99,0
+memory: 5=9801
+---
+program: 1,1,1,4,99,5,6,0,99
+memory: 0=30, 4=2
@@ -0,0 +1,28 @@
+# examples of day 5
+name: equal to 8 in position mode
+program: 3,9,8,9,10,9,4,9,99,-1,8
+input: 8
+output: 1
+---
+name: less than 8 in immediate mode
+program: 3,3,1107,-1,8,3,4,3,99
+input: 9
+output: 0
+---
+name: jump in position mode
+program: 3,12,6,12,15,1,13,14,13,4,13,99,-1,0,1,9
+input: 0
+output: 0
+---
+name: compare to 8
+program: 3,21,1008,21,8,20,1005,20,22,107,8,21,20,1006,20,31,
+program: 1106,0,36,98,0,0,1002,21,125,20,4,20,1105,1,46,104,
+program: 999,1105,1,46,1101,1000,1,20,4,20,1105,1,46,98,99
+input: 9
+output: 1001
+---
+name: diagnostic of the air conditioner
+program-file: ../../../day05/day05.txt
+input: 1
+output: 0, 0, 0, 0, 0, 0, 0, 0, 0
+output: 7839346
@@ -0,0 +1,17 @@
+# examples of day 9
+name: quine
+program: 109,1,204,-1,1001,100,1,100,1008,100,16,101,1006,101,0,99
+output: 109,1,204,-1,1001,100,1,100,1008,100,16,101,1006,101,0,99
+---
+name: sixteen digit number
+program: 1102,34915192,34915192,7,4,7,99,0
+output: 1219070632396864
+---
+name: large number
+program: 104,1125899906842624,99
+output: 1125899906842624
+---
+name: BOOST keycode
+program-file: ../../../day09/day09.txt
+input: 1
+output: 3765554916
@@ -0,0 +1,13 @@
+# programs which fail
+name: unknown opcode
+program: 98
+error: unknown opcode 98
+---
+name: missing input
+program: 3,0,99
+error: more inputs than given
+---
+name: outputs before failing
+program: 104,7,3,0,99
+output: 7
+error: more inputs than given
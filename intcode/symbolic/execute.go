@@ -0,0 +1,306 @@
+package symbolic
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/search"
+)
+
+const (
+	// maxPaths is the maximum number of paths explored by Execute
+	maxPaths = 64
+	// maxSteps is the maximum number of instructions executed by Execute across all paths
+	maxSteps = 1 << 20
+)
+
+// ErrUnsupported is returned when a program cannot be executed symbolically, because it
+// depends on the variables in a way that cannot be represented or explores too many paths
+var ErrUnsupported = errors.New("program cannot be executed symbolically")
+
+// Variable is an unknown of a program: a memory cell or an input within a range of values
+type Variable = search.Parameter
+
+// Cell returns a variable stored in memory at position before the program runs
+func Cell(name string, position, min, max int) Variable {
+	return search.Cell(name, position, min, max)
+}
+
+// Input returns a variable read as an input, the input variables being read in order
+func Input(name string, min, max int) Variable {
+	return search.Input(name, min, max)
+}
+
+// Constraint is a condition on the variables
+type Constraint struct {
+	Expr Expr
+	// NonZero indicates that Expr must be non zero, otherwise it must be zero
+	NonZero bool
+}
+
+func (c Constraint) String() string {
+	if c.NonZero {
+		return fmt.Sprintf("%s != 0", c.Expr)
+	}
+	return fmt.Sprintf("%s == 0", c.Expr)
+}
+
+// holds returns true if the constraint holds with the given values of the variables
+func (c Constraint) holds(values map[string]int) (bool, error) {
+	value, err := c.Expr.eval(values)
+	if err != nil {
+		return false, err
+	}
+	return (value != 0) == c.NonZero, nil
+}
+
+// Path is an execution path of a program, which is followed when all its constraints hold
+type Path struct {
+	Constraints []Constraint
+	// Outputs are the outputs produced along the path
+	Outputs []Expr
+	// Err is the error that ended the path, which is nil if the path ends with a halt
+	Err error
+
+	memory map[int]Expr
+}
+
+// Memory returns the value at position once the path has ended
+func (p *Path) Memory(position int) Expr {
+	if value, ok := p.memory[position]; ok {
+		return value
+	}
+	return Const(0)
+}
+
+func (p *Path) fork() *Path {
+	forked := &Path{
+		Constraints: append([]Constraint(nil), p.Constraints...),
+		Outputs:     append([]Expr(nil), p.Outputs...),
+		memory:      make(map[int]Expr, len(p.memory)),
+	}
+	for position, value := range p.memory {
+		forked.memory[position] = value
+	}
+	return forked
+}
+
+// operations are the expressions computed by the instructions which store a computation
+var operations = map[int]func(left, right Expr) Expr{
+	instruction.AddOpcode:      Add,
+	instruction.MultiplyOpcode: Mul,
+	instruction.LessThanOpcode: Less,
+	instruction.EqualsOpcode:   Equal,
+}
+
+// state is the state of the machine along a path
+type state struct {
+	path               *Path
+	instructionPointer int
+	relativeBase       int
+	// inputs are the input variables that have not been read yet
+	inputs []Variable
+}
+
+// Execute runs the program stored in memory, the cells of variables holding symbols instead of
+// values, and returns every path it can follow. It forks the execution at each jump which
+// depends on the variables, and fails with ErrUnsupported if an instruction, a written position
+// or a jump target depends on them.
+func Execute(memory []int, variables []Variable) ([]*Path, error) {
+	initial := &state{path: &Path{memory: make(map[int]Expr, len(memory))}}
+	for position, value := range memory {
+		initial.path.memory[position] = Const(value)
+	}
+	for _, variable := range variables {
+		if variable.Input {
+			initial.inputs = append(initial.inputs, variable)
+		} else {
+			initial.path.memory[variable.Position] = Symbol(variable.Name)
+		}
+	}
+
+	var paths []*Path
+	pending := []*state{initial}
+	for steps, created := 0, 1; len(pending) > 0; steps++ {
+		if steps == maxSteps {
+			return nil, fmt.Errorf("%w: it runs for more than %d instructions", ErrUnsupported, maxSteps)
+		}
+
+		s := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		forked, done, err := s.step()
+		if err != nil {
+			return nil, err
+		}
+
+		if done {
+			paths = append(paths, s.path)
+		} else {
+			pending = append(pending, s)
+		}
+		if forked != nil {
+			created++
+			if created > maxPaths {
+				return nil, fmt.Errorf("%w: it follows more than %d paths", ErrUnsupported, maxPaths)
+			}
+			pending = append(pending, forked)
+		}
+	}
+	return paths, nil
+}
+
+// step executes the instruction at the instruction pointer. It returns the state of the path
+// forked by a symbolic jump if any, and true if the path of s has ended.
+func (s *state) step() (*state, bool, error) {
+	ip := s.instructionPointer
+	n, ok := s.path.Memory(ip).(Const)
+	if !ok {
+		return nil, false, fmt.Errorf("%w: instruction at %d depends on the variables", ErrUnsupported, ip)
+	}
+
+	decoded, err := instruction.Decode(int(n))
+	if err != nil {
+		return s.fail(err)
+	}
+
+	switch decoded.Opcode {
+	case instruction.HaltOpcode:
+		return nil, true, nil
+
+	case instruction.AddOpcode, instruction.MultiplyOpcode,
+		instruction.LessThanOpcode, instruction.EqualsOpcode:
+		operands, err := s.read(decoded, 2)
+		if err != nil {
+			return s.fail(err)
+		}
+		return s.store(decoded, 2, operations[decoded.Opcode](operands[0], operands[1]))
+
+	case instruction.InputOpcode:
+		if len(s.inputs) == 0 {
+			return nil, false, fmt.Errorf("%w: instruction at %d reads more inputs than variables", ErrUnsupported, ip)
+		}
+		input := s.inputs[0]
+		s.inputs = s.inputs[1:]
+		return s.store(decoded, 0, Symbol(input.Name))
+
+	case instruction.OutputOpcode:
+		operands, err := s.read(decoded, 1)
+		if err != nil {
+			return s.fail(err)
+		}
+		s.path.Outputs = append(s.path.Outputs, operands[0])
+		s.instructionPointer += decoded.Size()
+		return nil, false, nil
+
+	case instruction.AdjustRelativeBaseOpcode:
+		operands, err := s.read(decoded, 1)
+		if err != nil {
+			return s.fail(err)
+		}
+		offset, ok := operands[0].(Const)
+		if !ok {
+			return nil, false, fmt.Errorf("%w: relative base adjusted at %d depends on the variables", ErrUnsupported, ip)
+		}
+		s.relativeBase += int(offset)
+		s.instructionPointer += decoded.Size()
+		return nil, false, nil
+
+	default:
+		return s.jump(decoded)
+	}
+}
+
+// jump executes a conditional jump, forking the path if its condition depends on the variables
+func (s *state) jump(decoded instruction.Decoded) (*state, bool, error) {
+	operands, err := s.read(decoded, 2)
+	if err != nil {
+		return s.fail(err)
+	}
+	condition, target := operands[0], operands[1]
+	jumpsIfNonZero := decoded.Opcode == instruction.JumpIfTrueOpcode
+
+	if value, ok := condition.(Const); ok {
+		if (value != 0) == jumpsIfNonZero {
+			return s.jumpTo(target)
+		}
+		s.instructionPointer += decoded.Size()
+		return nil, false, nil
+	}
+
+	forked := &state{
+		path:               s.path.fork(),
+		instructionPointer: s.instructionPointer + decoded.Size(),
+		relativeBase:       s.relativeBase,
+		inputs:             s.inputs,
+	}
+	forked.path.Constraints = append(forked.path.Constraints, Constraint{Expr: condition, NonZero: !jumpsIfNonZero})
+	s.path.Constraints = append(s.path.Constraints, Constraint{Expr: condition, NonZero: jumpsIfNonZero})
+
+	_, done, err := s.jumpTo(target)
+	return forked, done, err
+}
+
+func (s *state) jumpTo(target Expr) (*state, bool, error) {
+	position, ok := target.(Const)
+	if !ok {
+		return nil, false, fmt.Errorf("%w: jump target at %d depends on the variables", ErrUnsupported, s.instructionPointer)
+	}
+	if position < 0 {
+		return s.fail(fmt.Errorf("invalid jump target: %d", int(position)))
+	}
+	s.instructionPointer = int(position)
+	return nil, false, nil
+}
+
+// read returns the values of the first count parameters of the instruction
+func (s *state) read(decoded instruction.Decoded, count int) ([]Expr, error) {
+	values := make([]Expr, count)
+	for i := range values {
+		parameter := s.path.Memory(s.instructionPointer + 1 + i)
+		switch decoded.Modes[i] {
+		case instruction.Immediate:
+			values[i] = parameter
+			continue
+		case instruction.Relative:
+			parameter = Add(parameter, Const(s.relativeBase))
+		}
+
+		position, ok := parameter.(Const)
+		if !ok {
+			values[i] = load{position: parameter}
+			continue
+		}
+		if position < 0 {
+			return nil, fmt.Errorf("invalid memory position: %d", int(position))
+		}
+		values[i] = s.path.Memory(int(position))
+	}
+	return values, nil
+}
+
+// store stores value in the position given by the parameter i of the instruction
+func (s *state) store(decoded instruction.Decoded, i int, value Expr) (*state, bool, error) {
+	parameter := s.path.Memory(s.instructionPointer + 1 + i)
+	if decoded.Modes[i] == instruction.Relative {
+		parameter = Add(parameter, Const(s.relativeBase))
+	}
+
+	position, ok := parameter.(Const)
+	if !ok {
+		return nil, false, fmt.Errorf("%w: position written at %d depends on the variables", ErrUnsupported, s.instructionPointer)
+	}
+	if position < 0 {
+		return s.fail(fmt.Errorf("invalid memory position: %d", int(position)))
+	}
+
+	s.path.memory[int(position)] = value
+	s.instructionPointer += decoded.Size()
+	return nil, false, nil
+}
+
+// fail ends the path of s with err
+func (s *state) fail(err error) (*state, bool, error) {
+	s.path.Err = fmt.Errorf("instruction at %d: %w", s.instructionPointer, err)
+	return nil, true, nil
+}
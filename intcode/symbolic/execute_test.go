@@ -0,0 +1,99 @@
+package symbolic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteStraightLine(t *testing.T) {
+	// mem[0] = (noun + verb) * 3, then outputs it
+	memory := []int{1101, 0, 0, 0, 1002, 0, 3, 0, 4, 0, 99}
+
+	paths, err := Execute(memory, []Variable{Cell("noun", 1, 0, 99), Cell("verb", 2, 0, 99)})
+	require.NoError(t, err)
+	require.Len(t, paths, 1)
+
+	path := paths[0]
+	assert.NoError(t, path.Err)
+	assert.Empty(t, path.Constraints)
+	assert.Equal(t, "((noun + verb) * 3)", path.Memory(0).String())
+	assert.Equal(t, []Expr{path.Memory(0)}, path.Outputs)
+	assert.Equal(t, Const(99), path.Memory(10))
+	assert.Equal(t, Const(0), path.Memory(1000))
+}
+
+func TestExecuteBranches(t *testing.T) {
+	// outputs 1 if the input is less than 8 and its double otherwise
+	memory := []int{
+		3, 30, // in [30]
+		1007, 30, 8, 31, // lt [30], 8, [31]
+		1006, 31, 13, // jz [31], 13
+		104, 1, // out 1
+		99,              // halt
+		0,               // padding
+		1002, 30, 2, 30, // mul [30], 2, [30]
+		4, 30, // out [30]
+		99, // halt
+	}
+
+	paths, err := Execute(memory, []Variable{Input("x", 0, 20)})
+	require.NoError(t, err)
+	require.Len(t, paths, 2)
+
+	outputs := make(map[string]string)
+	for _, path := range paths {
+		require.NoError(t, path.Err)
+		require.Len(t, path.Constraints, 1)
+		require.Len(t, path.Outputs, 1)
+		outputs[path.Constraints[0].String()] = path.Outputs[0].String()
+	}
+	assert.Equal(t, map[string]string{
+		"(x < 8) != 0": "1",
+		"(x < 8) == 0": "(x * 2)",
+	}, outputs)
+}
+
+func TestExecuteFailingPath(t *testing.T) {
+	paths, err := Execute([]int{1, -1, 0, 0, 99}, nil)
+	require.NoError(t, err)
+	require.Len(t, paths, 1)
+	assert.EqualError(t, paths[0].Err, "instruction at 0: invalid memory position: -1")
+}
+
+func TestExecuteUnsupported(t *testing.T) {
+	testCases := map[string]struct {
+		memory    []int
+		variables []Variable
+	}{
+		"symbolic write position": {
+			memory:    []int{1101, 1, 1, 0, 99},
+			variables: []Variable{Cell("p", 3, 0, 9)},
+		},
+		"symbolic jump target": {
+			memory:    []int{1105, 1, 0, 99},
+			variables: []Variable{Cell("target", 2, 0, 9)},
+		},
+		"symbolic instruction": {
+			memory:    []int{0, 0, 0, 0, 99},
+			variables: []Variable{Cell("opcode", 0, 1, 2)},
+		},
+		"missing input": {
+			memory: []int{3, 0, 99},
+		},
+		"too many paths": {
+			// loops decrementing its input until it is zero
+			memory:    []int{3, 10, 1001, 10, -1, 10, 1005, 10, 2, 99, 0},
+			variables: []Variable{Input("n", 0, 1000)},
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Execute(test.memory, test.variables)
+			assert.True(t, errors.Is(err, ErrUnsupported), "error must be unsupported: %v", err)
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package symbolic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// errNotEvaluable is returned when evaluating an expression which depends on memory
+var errNotEvaluable = errors.New("expression reads memory at a symbolic position")
+
+// Expr is a symbolic expression of the values computed by a program
+type Expr interface {
+	fmt.Stringer
+
+	// eval evaluates the expression with the given values of the variables
+	eval(values map[string]int) (int, error)
+}
+
+// Const is a concrete value
+type Const int
+
+// Symbol is the value of a variable
+type Symbol string
+
+// binary is an operation between two expressions
+type binary struct {
+	operator    string
+	left, right Expr
+}
+
+// load is the value read from memory at a symbolic position
+type load struct {
+	position Expr
+}
+
+func (c Const) String() string {
+	return fmt.Sprint(int(c))
+}
+
+func (c Const) eval(map[string]int) (int, error) {
+	return int(c), nil
+}
+
+func (s Symbol) String() string {
+	return string(s)
+}
+
+func (s Symbol) eval(values map[string]int) (int, error) {
+	value, ok := values[string(s)]
+	if !ok {
+		return 0, fmt.Errorf("no value for variable %s", string(s))
+	}
+	return value, nil
+}
+
+func (b binary) String() string {
+	return fmt.Sprintf("(%s %s %s)", b.left, b.operator, b.right)
+}
+
+func (b binary) eval(values map[string]int) (int, error) {
+	left, err := b.left.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	right, err := b.right.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	return apply(b.operator, left, right), nil
+}
+
+func (l load) String() string {
+	return fmt.Sprintf("mem[%s]", l.position)
+}
+
+func (l load) eval(map[string]int) (int, error) {
+	return 0, errNotEvaluable
+}
+
+// apply applies operator to concrete values, wrapping around on overflow as Intcode does
+func apply(operator string, left, right int) int {
+	switch operator {
+	case "+":
+		return left + right
+	case "*":
+		return left * right
+	case "<":
+		return boolToInt(left < right)
+	default:
+		return boolToInt(left == right)
+	}
+}
+
+// Add returns the expression of left + right, folding constants
+func Add(left, right Expr) Expr {
+	return operate("+", left, right)
+}
+
+// Mul returns the expression of left * right, folding constants
+func Mul(left, right Expr) Expr {
+	return operate("*", left, right)
+}
+
+// Less returns the expression which is 1 if left < right and 0 otherwise, folding constants
+func Less(left, right Expr) Expr {
+	return operate("<", left, right)
+}
+
+// Equal returns the expression which is 1 if left == right and 0 otherwise, folding constants
+func Equal(left, right Expr) Expr {
+	return operate("==", left, right)
+}
+
+func operate(operator string, left, right Expr) Expr {
+	l, leftConst := left.(Const)
+	r, rightConst := right.(Const)
+	if leftConst && rightConst {
+		return Const(apply(operator, int(l), int(r)))
+	}
+
+	switch operator {
+	case "+":
+		if leftConst && l == 0 {
+			return right
+		}
+		if rightConst && r == 0 {
+			return left
+		}
+	case "*":
+		if (leftConst && l == 0) || (rightConst && r == 0) {
+			return Const(0)
+		}
+		if leftConst && l == 1 {
+			return right
+		}
+		if rightConst && r == 1 {
+			return left
+		}
+	}
+	return binary{operator: operator, left: left, right: right}
+}
+
+// Eval evaluates e with the given values of the variables. It fails if e reads memory
+// at a position which depends on the variables.
+func Eval(e Expr, values map[string]int) (int, error) {
+	return e.eval(values)
+}
+
+// Linear is an expression of the form Constant + sum of Coefficients[v] * v
+type Linear struct {
+	Coefficients map[string]int
+	Constant     int
+}
+
+// Linearize returns the linear form of e, or false if e is not linear in the variables
+func Linearize(e Expr) (Linear, bool) {
+	switch e := e.(type) {
+	case Const:
+		return Linear{Coefficients: map[string]int{}, Constant: int(e)}, true
+	case Symbol:
+		return Linear{Coefficients: map[string]int{string(e): 1}}, true
+	case binary:
+		left, ok := Linearize(e.left)
+		if !ok {
+			return Linear{}, false
+		}
+		right, ok := Linearize(e.right)
+		if !ok {
+			return Linear{}, false
+		}
+
+		switch e.operator {
+		case "+":
+			return left.plus(right), true
+		case "*":
+			if len(left.Coefficients) == 0 {
+				return right.times(left.Constant), true
+			}
+			if len(right.Coefficients) == 0 {
+				return left.times(right.Constant), true
+			}
+		}
+	}
+	return Linear{}, false
+}
+
+func (l Linear) plus(other Linear) Linear {
+	sum := Linear{Coefficients: make(map[string]int), Constant: l.Constant + other.Constant}
+	for _, terms := range []map[string]int{l.Coefficients, other.Coefficients} {
+		for variable, coefficient := range terms {
+			sum.Coefficients[variable] += coefficient
+			if sum.Coefficients[variable] == 0 {
+				delete(sum.Coefficients, variable)
+			}
+		}
+	}
+	return sum
+}
+
+func (l Linear) times(factor int) Linear {
+	product := Linear{Coefficients: make(map[string]int), Constant: l.Constant * factor}
+	if factor == 0 {
+		return product
+	}
+	for variable, coefficient := range l.Coefficients {
+		product.Coefficients[variable] = coefficient * factor
+	}
+	return product
+}
+
+func (l Linear) String() string {
+	variables := make([]string, 0, len(l.Coefficients))
+	for variable := range l.Coefficients {
+		variables = append(variables, variable)
+	}
+	sort.Strings(variables)
+
+	terms := make([]string, 0, len(variables)+1)
+	for _, variable := range variables {
+		terms = append(terms, fmt.Sprintf("%d*%s", l.Coefficients[variable], variable))
+	}
+	if l.Constant != 0 || len(terms) == 0 {
+		terms = append(terms, fmt.Sprint(l.Constant))
+	}
+	return strings.Join(terms, " + ")
+}
+
+// collectVariables adds the names of the variables of e to names
+func collectVariables(e Expr, names map[string]bool) {
+	switch e := e.(type) {
+	case Symbol:
+		names[string(e)] = true
+	case binary:
+		collectVariables(e.left, names)
+		collectVariables(e.right, names)
+	case load:
+		collectVariables(e.position, names)
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
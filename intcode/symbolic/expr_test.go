@@ -0,0 +1,62 @@
+package symbolic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFolding(t *testing.T) {
+	x := Symbol("x")
+
+	assert.Equal(t, Const(5), Add(Const(2), Const(3)))
+	assert.Equal(t, Const(1), Less(Const(2), Const(3)))
+	assert.Equal(t, x, Add(Const(0), x))
+	assert.Equal(t, x, Mul(x, Const(1)))
+	assert.Equal(t, Const(0), Mul(Const(0), x))
+	assert.Equal(t, "((x * 3) + 1)", Add(Mul(x, Const(3)), Const(1)).String())
+}
+
+func TestEval(t *testing.T) {
+	x, y := Symbol("x"), Symbol("y")
+	e := Equal(Add(Mul(x, y), Const(1)), Const(7))
+
+	value, err := Eval(e, map[string]int{"x": 2, "y": 3})
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	_, err = Eval(e, map[string]int{"x": 2})
+	assert.EqualError(t, err, "no value for variable y")
+
+	_, err = Eval(load{position: x}, map[string]int{"x": 2})
+	assert.True(t, errors.Is(err, errNotEvaluable))
+}
+
+func TestLinearize(t *testing.T) {
+	x, y := Symbol("x"), Symbol("y")
+
+	testCases := map[string]struct {
+		e        Expr
+		expected string
+		linear   bool
+	}{
+		"constant":      {e: Const(4), expected: "4", linear: true},
+		"scaled sum":    {e: Mul(Add(Mul(x, Const(3)), y), Const(2)), expected: "6*x + 2*y", linear: true},
+		"cancelled":     {e: Add(Add(x, Const(5)), Mul(x, Const(-1))), expected: "5", linear: true},
+		"product":       {e: Mul(x, y)},
+		"comparison":    {e: Add(Less(x, Const(3)), y)},
+		"symbolic load": {e: Add(load{position: x}, Const(1))},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			linear, ok := Linearize(test.e)
+			require.Equal(t, test.linear, ok)
+			if ok {
+				assert.Equal(t, test.expected, linear.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package symbolic
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/search"
+)
+
+// maxCombinations is the maximum number of combinations of values searched by Solve
+const maxCombinations = 1 << 24
+
+// ErrNoSolution is returned when no values of the variables produce the target
+var ErrNoSolution = errors.New("no values of the variables produce the target")
+
+// Result is a value computed by a program: the value at a memory position once it halts,
+// or one of its outputs
+type Result struct {
+	position int
+	output   int
+	isOutput bool
+}
+
+// MemoryResult returns the result held at position once the program halts
+func MemoryResult(position int) Result {
+	return Result{position: position}
+}
+
+// OutputResult returns the result written as the output at index, counting from 0
+func OutputResult(index int) Result {
+	return Result{output: index, isOutput: true}
+}
+
+// of returns the expression of the result along path, or false if path does not produce it
+func (r Result) of(path *Path) (Expr, bool) {
+	if !r.isOutput {
+		return path.Memory(r.position), true
+	}
+	if r.output >= len(path.Outputs) {
+		return nil, false
+	}
+	return path.Outputs[r.output], true
+}
+
+// objective returns the search objective matching the runs where the result is target
+func (r Result) objective(target int) search.Objective {
+	if r.isOutput {
+		return search.OutputEquals(r.output, target)
+	}
+	return search.MemoryEquals(r.position, target)
+}
+
+// Solve returns values of the variables, each within its range, for which the program stored
+// in memory halts with result equal to target, or ErrNoSolution if there are none.
+//
+// It executes the program symbolically and solves the linear equation of each path directly,
+// enumerating all variables but one. When the equation of a path is not linear it searches
+// the values of its variables, and when the program cannot be executed symbolically or its
+// result depends on memory at positions given by the variables it runs the program for each
+// combination of values.
+func Solve(memory []int, variables []Variable, result Result, target int) (map[string]int, error) {
+	if err := checkRanges(variables); err != nil {
+		return nil, err
+	}
+
+	paths, err := Execute(memory, variables)
+	if err != nil {
+		return bruteForce(memory, variables, result, target)
+	}
+
+	for _, path := range paths {
+		if path.Err != nil {
+			continue
+		}
+		e, ok := result.of(path)
+		if !ok {
+			continue
+		}
+
+		values, err := solvePath(path, e, variables, target)
+		if errors.Is(err, errNotEvaluable) {
+			return bruteForce(memory, variables, result, target)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if values != nil {
+			return values, nil
+		}
+	}
+	return nil, ErrNoSolution
+}
+
+// checkRanges checks that the ranges of variables are not empty
+func checkRanges(variables []Variable) error {
+	for _, variable := range variables {
+		if variable.Min > variable.Max {
+			return fmt.Errorf("empty range for variable %s: [%d, %d]", variable.Name, variable.Min, variable.Max)
+		}
+	}
+	return nil
+}
+
+// solvePath returns values of the variables for which path is followed and e is equal
+// to target, or nil if there are none
+func solvePath(path *Path, e Expr, variables []Variable, target int) (map[string]int, error) {
+	involved := make(map[string]bool)
+	collectVariables(e, involved)
+	for _, constraint := range path.Constraints {
+		collectVariables(constraint.Expr, involved)
+	}
+
+	values := make(map[string]int, len(variables))
+	var enumerated []Variable
+	for _, variable := range variables {
+		values[variable.Name] = variable.Min
+		if involved[variable.Name] {
+			enumerated = append(enumerated, variable)
+		}
+	}
+
+	solves := func() (bool, error) {
+		value, err := e.eval(values)
+		if err != nil || value != target {
+			return false, err
+		}
+		for _, constraint := range path.Constraints {
+			if holds, err := constraint.holds(values); err != nil || !holds {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	visit := solves
+	if linear, ok := Linearize(e); ok && len(linear.Coefficients) > 0 {
+		pivot := widest(enumerated, linear)
+		enumerated = without(enumerated, pivot.Name)
+		visit = func() (bool, error) {
+			rest := target - linear.Constant
+			for name, coefficient := range linear.Coefficients {
+				if name != pivot.Name {
+					rest -= coefficient * values[name]
+				}
+			}
+
+			coefficient := linear.Coefficients[pivot.Name]
+			if rest%coefficient != 0 {
+				return false, nil
+			}
+			value := rest / coefficient
+			if value < pivot.Min || value > pivot.Max {
+				return false, nil
+			}
+			values[pivot.Name] = value
+			return solves()
+		}
+	}
+
+	if combinations(enumerated) > maxCombinations {
+		return nil, fmt.Errorf("too many combinations of values to search")
+	}
+	found, err := enumerate(enumerated, values, visit)
+	if err != nil || !found {
+		return nil, err
+	}
+	return values, nil
+}
+
+// widest returns the variable of the linear expression with the widest range
+func widest(variables []Variable, linear Linear) Variable {
+	var pivot Variable
+	found := false
+	for _, variable := range variables {
+		if _, ok := linear.Coefficients[variable.Name]; !ok {
+			continue
+		}
+		if !found || variable.Max-variable.Min > pivot.Max-pivot.Min {
+			pivot, found = variable, true
+		}
+	}
+	return pivot
+}
+
+// without returns variables without the variable called name
+func without(variables []Variable, name string) []Variable {
+	var remaining []Variable
+	for _, variable := range variables {
+		if variable.Name != name {
+			remaining = append(remaining, variable)
+		}
+	}
+	return remaining
+}
+
+// combinations returns the number of combinations of values of variables, saturating
+// above maxCombinations
+func combinations(variables []Variable) int {
+	total := 1
+	for _, variable := range variables {
+		size := variable.Max - variable.Min + 1
+		if size <= 0 || size > maxCombinations || total*size > maxCombinations {
+			return maxCombinations + 1
+		}
+		total *= size
+	}
+	return total
+}
+
+// enumerate calls visit with each combination of values of variables set in values, the first
+// variable varying the slowest, until visit returns true
+func enumerate(variables []Variable, values map[string]int, visit func() (bool, error)) (bool, error) {
+	if len(variables) == 0 {
+		return visit()
+	}
+
+	variable := variables[0]
+	for value := variable.Min; value <= variable.Max; value++ {
+		values[variable.Name] = value
+		found, err := enumerate(variables[1:], values, visit)
+		if err != nil || found {
+			return found, err
+		}
+	}
+	return false, nil
+}
+
+// bruteForce returns values of the variables for which running the program stored in memory
+// produces target, trying every combination of values
+func bruteForce(memory []int, variables []Variable, result Result, target int) (map[string]int, error) {
+	if combinations(variables) > maxCombinations {
+		return nil, fmt.Errorf("too many combinations of values to search")
+	}
+
+	space := search.Space{Parameters: variables}
+	match, err := search.First(space, search.Program(memory, variables, result.objective(target)))
+	if errors.Is(err, search.ErrNoMatch) {
+		return nil, ErrNoSolution
+	}
+	if err != nil {
+		return nil, err
+	}
+	return match.Assignment, nil
+}
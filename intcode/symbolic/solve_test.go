@@ -0,0 +1,85 @@
+package symbolic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSolve(t *testing.T) {
+	testCases := map[string]struct {
+		memory    []int
+		variables []Variable
+		result    Result
+		target    int
+		expected  map[string]int
+	}{
+		"linear": {
+			// mem[0] = noun * 100 + verb
+			memory:    []int{1102, 0, 100, 0, 1001, 0, 0, 0, 99},
+			variables: []Variable{Cell("noun", 1, 0, 99), Cell("verb", 6, 0, 99)},
+			result:    MemoryResult(0),
+			target:    1234,
+			expected:  map[string]int{"noun": 12, "verb": 34},
+		},
+		"non-linear": {
+			// outputs x * y
+			memory:    []int{3, 100, 3, 101, 2, 100, 101, 102, 4, 102, 99},
+			variables: []Variable{Input("x", 2, 9), Input("y", 2, 9)},
+			result:    OutputResult(0),
+			target:    21,
+			expected:  map[string]int{"x": 3, "y": 7},
+		},
+		"branches": {
+			// outputs 1 if the input is less than 8 and its double otherwise
+			memory:    []int{3, 30, 1007, 30, 8, 31, 1006, 31, 13, 104, 1, 99, 0, 1002, 30, 2, 30, 4, 30, 99},
+			variables: []Variable{Input("x", 0, 20)},
+			result:    OutputResult(0),
+			target:    18,
+			expected:  map[string]int{"x": 9},
+		},
+		"symbolic positions": {
+			// mem[0] = mem[noun] + mem[verb], as in day 2
+			memory:    []int{1, 0, 0, 0, 99, 19690720},
+			variables: []Variable{Cell("noun", 1, 0, 5), Cell("verb", 2, 0, 5)},
+			result:    MemoryResult(0),
+			target:    19690720,
+			expected:  map[string]int{"noun": 3, "verb": 5},
+		},
+		"unsupported": {
+			// jumps to the position given by the input, which outputs it
+			memory:    []int{3, 4, 1105, 1, 0, 99, 104, 6, 99},
+			variables: []Variable{Input("target", 0, 8)},
+			result:    OutputResult(0),
+			target:    6,
+			expected:  map[string]int{"target": 6},
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			values, err := Solve(test.memory, test.variables, test.result, test.target)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, values)
+		})
+	}
+}
+
+func TestSolveNoSolution(t *testing.T) {
+	// mem[0] = noun * 2
+	memory := []int{1002, 0, 2, 0, 99}
+	variables := []Variable{Cell("noun", 1, 0, 99)}
+
+	_, err := Solve(memory, variables, MemoryResult(0), 7)
+	assert.True(t, errors.Is(err, ErrNoSolution))
+
+	_, err = Solve(memory, variables, OutputResult(0), 8)
+	assert.True(t, errors.Is(err, ErrNoSolution))
+}
+
+func TestSolveInvalidRange(t *testing.T) {
+	_, err := Solve([]int{99}, []Variable{Cell("noun", 1, 5, 4)}, MemoryResult(0), 0)
+	assert.EqualError(t, err, "empty range for variable noun: [5, 4]")
+}
@@ -0,0 +1,184 @@
+// Code generated by intcode transpile. DO NOT EDIT.
+
+// Package day05 runs an Intcode program transpiled to Go
+package day05
+
+import (
+	"fmt"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+var image = []int{
+	3, 225, 1, 225, 6, 6, 1100, 1, 238, 225, 104, 0, 1102, 89, 49, 225,
+	1102, 35, 88, 224, 101, -3080, 224, 224, 4, 224, 102, 8, 223, 223, 1001, 224,
+	3, 224, 1, 223, 224, 223, 1101, 25, 33, 224, 1001, 224, -58, 224, 4, 224,
+	102, 8, 223, 223, 101, 5, 224, 224, 1, 223, 224, 223, 1102, 78, 23, 225,
+	1, 165, 169, 224, 101, -80, 224, 224, 4, 224, 102, 8, 223, 223, 101, 7,
+	224, 224, 1, 224, 223, 223, 101, 55, 173, 224, 1001, 224, -65, 224, 4, 224,
+	1002, 223, 8, 223, 1001, 224, 1, 224, 1, 223, 224, 223, 2, 161, 14, 224,
+	101, -3528, 224, 224, 4, 224, 1002, 223, 8, 223, 1001, 224, 7, 224, 1, 224,
+	223, 223, 1002, 61, 54, 224, 1001, 224, -4212, 224, 4, 224, 102, 8, 223, 223,
+	1001, 224, 1, 224, 1, 223, 224, 223, 1101, 14, 71, 225, 1101, 85, 17, 225,
+	1102, 72, 50, 225, 1102, 9, 69, 225, 1102, 71, 53, 225, 1101, 10, 27, 225,
+	1001, 158, 34, 224, 101, -51, 224, 224, 4, 224, 102, 8, 223, 223, 101, 6,
+	224, 224, 1, 223, 224, 223, 102, 9, 154, 224, 101, -639, 224, 224, 4, 224,
+	102, 8, 223, 223, 101, 2, 224, 224, 1, 224, 223, 223, 4, 223, 99, 0,
+	0, 0, 677, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1105, 0,
+	99999, 1105, 227, 247, 1105, 1, 99999, 1005, 227, 99999, 1005, 0, 256, 1105, 1, 99999,
+	1106, 227, 99999, 1106, 0, 265, 1105, 1, 99999, 1006, 0, 99999, 1006, 227, 274, 1105,
+	1, 99999, 1105, 1, 280, 1105, 1, 99999, 1, 225, 225, 225, 1101, 294, 0, 0,
+	105, 1, 0, 1105, 1, 99999, 1106, 0, 300, 1105, 1, 99999, 1, 225, 225, 225,
+	1101, 314, 0, 0, 106, 0, 0, 1105, 1, 99999, 108, 226, 226, 224, 102, 2,
+	223, 223, 1006, 224, 329, 101, 1, 223, 223, 1007, 677, 677, 224, 1002, 223, 2,
+	223, 1005, 224, 344, 1001, 223, 1, 223, 8, 226, 677, 224, 1002, 223, 2, 223,
+	1006, 224, 359, 1001, 223, 1, 223, 108, 226, 677, 224, 1002, 223, 2, 223, 1005,
+	224, 374, 1001, 223, 1, 223, 107, 226, 677, 224, 102, 2, 223, 223, 1006, 224,
+	389, 101, 1, 223, 223, 1107, 226, 226, 224, 1002, 223, 2, 223, 1005, 224, 404,
+	1001, 223, 1, 223, 1107, 677, 226, 224, 102, 2, 223, 223, 1005, 224, 419, 101,
+	1, 223, 223, 1007, 226, 226, 224, 102, 2, 223, 223, 1006, 224, 434, 1001, 223,
+	1, 223, 1108, 677, 226, 224, 1002, 223, 2, 223, 1005, 224, 449, 101, 1, 223,
+	223, 1008, 226, 226, 224, 102, 2, 223, 223, 1005, 224, 464, 101, 1, 223, 223,
+	7, 226, 677, 224, 102, 2, 223, 223, 1006, 224, 479, 101, 1, 223, 223, 1008,
+	226, 677, 224, 1002, 223, 2, 223, 1006, 224, 494, 101, 1, 223, 223, 1107, 226,
+	677, 224, 1002, 223, 2, 223, 1005, 224, 509, 1001, 223, 1, 223, 1108, 226, 226,
+	224, 1002, 223, 2, 223, 1006, 224, 524, 101, 1, 223, 223, 7, 226, 226, 224,
+	102, 2, 223, 223, 1006, 224, 539, 1001, 223, 1, 223, 107, 226, 226, 224, 102,
+	2, 223, 223, 1006, 224, 554, 101, 1, 223, 223, 107, 677, 677, 224, 102, 2,
+	223, 223, 1006, 224, 569, 101, 1, 223, 223, 1008, 677, 677, 224, 1002, 223, 2,
+	223, 1006, 224, 584, 1001, 223, 1, 223, 8, 677, 226, 224, 1002, 223, 2, 223,
+	1005, 224, 599, 101, 1, 223, 223, 1108, 226, 677, 224, 1002, 223, 2, 223, 1005,
+	224, 614, 101, 1, 223, 223, 108, 677, 677, 224, 102, 2, 223, 223, 1005, 224,
+	629, 1001, 223, 1, 223, 8, 677, 677, 224, 1002, 223, 2, 223, 1005, 224, 644,
+	1001, 223, 1, 223, 7, 677, 226, 224, 102, 2, 223, 223, 1006, 224, 659, 1001,
+	223, 1, 223, 1007, 226, 677, 224, 102, 2, 223, 223, 1005, 224, 674, 101, 1,
+	223, 223, 4, 223, 99, 226,
+}
+
+// Run runs the program, calling onInput whenever it expects an input and onOutput whenever
+// it produces an output
+func Run(onInput func() int, onOutput func(output int)) (err error) {
+	m := &machine{
+		memory:   append([]int(nil), image...),
+		onInput:  onInput,
+		onOutput: onOutput,
+	}
+
+	defer func() {
+		r := recover()
+		if position, ok := r.(memoryError); ok {
+			err = fmt.Errorf("invalid memory position: %d", int(position))
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	ip, state := 0, running
+	for state == running {
+		switch ip {
+		case 0:
+			ip, state = m.block0()
+		default:
+			state = interpreted
+		}
+	}
+
+	if state == interpreted {
+		return intcode.NewIntcodeFromSnapshot(m.snapshot(ip), onInput, onOutput).Run()
+	}
+	return nil
+}
+
+// exit describes how the execution of a block ends
+type exit int
+
+const (
+	running exit = iota
+	halted
+	interpreted
+)
+
+// maxDense is the size up to which the dense memory grows, larger positions being stored in
+// the sparse memory so that programs can access very large positions
+const maxDense = 1 << 20
+
+// machine holds the state of the transpiled program
+type machine struct {
+	memory   []int
+	sparse   map[int]int
+	rb       int
+	onInput  func() int
+	onOutput func(output int)
+}
+
+// memoryError is raised when the program accesses a negative position
+type memoryError int
+
+func (m *machine) load(position int) int {
+	if position < 0 {
+		panic(memoryError(position))
+	}
+	if position < len(m.memory) {
+		return m.memory[position]
+	}
+	return m.sparse[position]
+}
+
+// store stores value at position and returns true if the position holds a transpiled instruction
+func (m *machine) store(position, value int) bool {
+	if position < 0 {
+		panic(memoryError(position))
+	}
+	switch {
+	case position < len(m.memory):
+		m.memory[position] = value
+	case position < maxDense:
+		m.memory = append(m.memory, make([]int, position+1-len(m.memory))...)
+		m.memory[position] = value
+	default:
+		if m.sparse == nil {
+			m.sparse = make(map[int]int)
+		}
+		m.sparse[position] = value
+	}
+	return isCode(position)
+}
+
+// snapshot returns the state of the machine, from which the interpreter resumes at ip
+func (m *machine) snapshot(ip int) intcode.Snapshot {
+	cells := make(map[int]int, len(m.memory)+len(m.sparse))
+	for position, value := range m.memory {
+		cells[position] = value
+	}
+	for position, value := range m.sparse {
+		cells[position] = value
+	}
+	return intcode.Snapshot{
+		Registers: intcode.Registers{InstructionPointer: ip, RelativeBase: m.rb},
+		Cells:     cells,
+	}
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// isCode returns true if position holds a transpiled instruction
+func isCode(position int) bool {
+	switch {
+	case position >= 0 && position < 6:
+		return true
+	}
+	return false
+}
+
+func (m *machine) block0() (int, exit) {
+	// 0: in [225]
+	m.store(225, m.onInput())
+	// 2: add [225], [6], [6]
+	m.store(6, m.load(225)+m.load(6))
+	return 6, running
+}
@@ -0,0 +1,569 @@
+// Code generated by intcode transpile. DO NOT EDIT.
+
+// Package day09 runs an Intcode program transpiled to Go
+package day09
+
+import (
+	"fmt"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+
+var image = []int{
+	1102, 34463338, 34463338, 63, 1007, 63, 34463338, 63, 1005, 63, 53, 1102, 3, 1, 1000, 109,
+	988, 209, 12, 9, 1000, 209, 6, 209, 3, 203, 0, 1008, 1000, 1, 63, 1005,
+	63, 65, 1008, 1000, 2, 63, 1005, 63, 904, 1008, 1000, 0, 63, 1005, 63, 58,
+	4, 25, 104, 0, 99, 4, 0, 104, 0, 99, 4, 17, 104, 0, 99, 0,
+	0, 1102, 1, 30, 1010, 1102, 1, 38, 1008, 1102, 1, 0, 1020, 1102, 22, 1,
+	1007, 1102, 26, 1, 1015, 1102, 31, 1, 1013, 1102, 1, 27, 1014, 1101, 0, 23,
+	1012, 1101, 0, 37, 1006, 1102, 735, 1, 1028, 1102, 1, 24, 1009, 1102, 1, 28,
+	1019, 1102, 20, 1, 1017, 1101, 34, 0, 1001, 1101, 259, 0, 1026, 1101, 0, 33,
+	1018, 1102, 1, 901, 1024, 1101, 21, 0, 1016, 1101, 36, 0, 1011, 1102, 730, 1,
+	1029, 1101, 1, 0, 1021, 1102, 1, 509, 1022, 1102, 39, 1, 1005, 1101, 35, 0,
+	1000, 1102, 1, 506, 1023, 1101, 0, 892, 1025, 1101, 256, 0, 1027, 1101, 25, 0,
+	1002, 1102, 1, 29, 1004, 1102, 32, 1, 1003, 109, 9, 1202, -3, 1, 63, 1008,
+	63, 39, 63, 1005, 63, 205, 1001, 64, 1, 64, 1106, 0, 207, 4, 187, 1002,
+	64, 2, 64, 109, -2, 1208, -4, 35, 63, 1005, 63, 227, 1001, 64, 1, 64,
+	1105, 1, 229, 4, 213, 1002, 64, 2, 64, 109, 5, 1206, 8, 243, 4, 235,
+	1106, 0, 247, 1001, 64, 1, 64, 1002, 64, 2, 64, 109, 14, 2106, 0, 1,
+	1105, 1, 265, 4, 253, 1001, 64, 1, 64, 1002, 64, 2, 64, 109, -25, 1201,
+	4, 0, 63, 1008, 63, 40, 63, 1005, 63, 285, 1106, 0, 291, 4, 271, 1001,
+	64, 1, 64, 1002, 64, 2, 64, 109, 14, 2107, 37, -7, 63, 1005, 63, 313,
+	4, 297, 1001, 64, 1, 64, 1106, 0, 313, 1002, 64, 2, 64, 109, -7, 21101,
+	40, 0, 5, 1008, 1013, 37, 63, 1005, 63, 333, 1105, 1, 339, 4, 319, 1001,
+	64, 1, 64, 1002, 64, 2, 64, 109, -7, 1207, 0, 33, 63, 1005, 63, 355,
+	1106, 0, 361, 4, 345, 1001, 64, 1, 64, 1002, 64, 2, 64, 109, 7, 21102,
+	41, 1, 9, 1008, 1017, 41, 63, 1005, 63, 387, 4, 367, 1001, 64, 1, 64,
+	1106, 0, 387, 1002, 64, 2, 64, 109, -1, 21102, 42, 1, 10, 1008, 1017, 43,
+	63, 1005, 63, 411, 1001, 64, 1, 64, 1106, 0, 413, 4, 393, 1002, 64, 2,
+	64, 109, -5, 21101, 43, 0, 8, 1008, 1010, 43, 63, 1005, 63, 435, 4, 419,
+	1106, 0, 439, 1001, 64, 1, 64, 1002, 64, 2, 64, 109, 16, 1206, 3, 455,
+	1001, 64, 1, 64, 1106, 0, 457, 4, 445, 1002, 64, 2, 64, 109, -8, 21107,
+	44, 45, 7, 1005, 1017, 479, 4, 463, 1001, 64, 1, 64, 1106, 0, 479, 1002,
+	64, 2, 64, 109, 6, 1205, 5, 497, 4, 485, 1001, 64, 1, 64, 1106, 0,
+	497, 1002, 64, 2, 64, 109, 1, 2105, 1, 6, 1105, 1, 515, 4, 503, 1001,
+	64, 1, 64, 1002, 64, 2, 64, 109, -10, 2108, 36, -1, 63, 1005, 63, 535,
+	1001, 64, 1, 64, 1105, 1, 537, 4, 521, 1002, 64, 2, 64, 109, -12, 2101,
+	0, 6, 63, 1008, 63, 32, 63, 1005, 63, 561, 1001, 64, 1, 64, 1105, 1,
+	563, 4, 543, 1002, 64, 2, 64, 109, 25, 21108, 45, 46, -2, 1005, 1018, 583,
+	1001, 64, 1, 64, 1105, 1, 585, 4, 569, 1002, 64, 2, 64, 109, -23, 2108,
+	34, 4, 63, 1005, 63, 607, 4, 591, 1001, 64, 1, 64, 1106, 0, 607, 1002,
+	64, 2, 64, 109, 3, 1202, 7, 1, 63, 1008, 63, 22, 63, 1005, 63, 633,
+	4, 613, 1001, 64, 1, 64, 1106, 0, 633, 1002, 64, 2, 64, 109, 12, 21108,
+	46, 46, 3, 1005, 1015, 651, 4, 639, 1106, 0, 655, 1001, 64, 1, 64, 1002,
+	64, 2, 64, 109, -5, 2102, 1, -1, 63, 1008, 63, 35, 63, 1005, 63, 679,
+	1001, 64, 1, 64, 1105, 1, 681, 4, 661, 1002, 64, 2, 64, 109, 13, 21107,
+	47, 46, -7, 1005, 1013, 701, 1001, 64, 1, 64, 1105, 1, 703, 4, 687, 1002,
+	64, 2, 64, 109, -2, 1205, 2, 715, 1106, 0, 721, 4, 709, 1001, 64, 1,
+	64, 1002, 64, 2, 64, 109, 17, 2106, 0, -7, 4, 727, 1105, 1, 739, 1001,
+	64, 1, 64, 1002, 64, 2, 64, 109, -23, 2107, 38, -6, 63, 1005, 63, 759,
+	1001, 64, 1, 64, 1106, 0, 761, 4, 745, 1002, 64, 2, 64, 109, -3, 1207,
+	-4, 40, 63, 1005, 63, 779, 4, 767, 1105, 1, 783, 1001, 64, 1, 64, 1002,
+	64, 2, 64, 109, -8, 2101, 0, -1, 63, 1008, 63, 35, 63, 1005, 63, 809,
+	4, 789, 1001, 64, 1, 64, 1105, 1, 809, 1002, 64, 2, 64, 109, -6, 2102,
+	1, 8, 63, 1008, 63, 32, 63, 1005, 63, 835, 4, 815, 1001, 64, 1, 64,
+	1106, 0, 835, 1002, 64, 2, 64, 109, 6, 1201, 5, 0, 63, 1008, 63, 37,
+	63, 1005, 63, 857, 4, 841, 1106, 0, 861, 1001, 64, 1, 64, 1002, 64, 2,
+	64, 109, 2, 1208, 0, 32, 63, 1005, 63, 883, 4, 867, 1001, 64, 1, 64,
+	1106, 0, 883, 1002, 64, 2, 64, 109, 23, 2105, 1, -2, 4, 889, 1001, 64,
+	1, 64, 1106, 0, 901, 4, 64, 99, 21102, 27, 1, 1, 21101, 0, 915, 0,
+	1106, 0, 922, 21201, 1, 55337, 1, 204, 1, 99, 109, 3, 1207, -2, 3, 63,
+	1005, 63, 964, 21201, -2, -1, 1, 21101, 0, 942, 0, 1105, 1, 922, 21202, 1,
+	1, -1, 21201, -2, -3, 1, 21102, 957, 1, 0, 1105, 1, 922, 22201, 1, -1,
+	-2, 1106, 0, 968, 21201, -2, 0, -2, 109, -3, 2105, 1, 0,
+}
+
+// Run runs the program, calling onInput whenever it expects an input and onOutput whenever
+// it produces an output
+func Run(onInput func() int, onOutput func(output int)) (err error) {
+	m := &machine{
+		memory:   append([]int(nil), image...),
+		onInput:  onInput,
+		onOutput: onOutput,
+	}
+
+	defer func() {
+		r := recover()
+		if position, ok := r.(memoryError); ok {
+			err = fmt.Errorf("invalid memory position: %d", int(position))
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	ip, state := 0, running
+	for state == running {
+		switch ip {
+		case 0:
+			ip, state = m.block0()
+		case 11:
+			ip, state = m.block11()
+		case 34:
+			ip, state = m.block34()
+		case 41:
+			ip, state = m.block41()
+		case 48:
+			ip, state = m.block48()
+		case 53:
+			ip, state = m.block53()
+		case 58:
+			ip, state = m.block58()
+		case 65:
+			ip, state = m.block65()
+		case 198:
+			ip, state = m.block198()
+		case 205:
+			ip, state = m.block205()
+		case 207:
+			ip, state = m.block207()
+		case 220:
+			ip, state = m.block220()
+		case 227:
+			ip, state = m.block227()
+		case 229:
+			ip, state = m.block229()
+		case 238:
+			ip, state = m.block238()
+		case 243:
+			ip, state = m.block243()
+		case 247:
+			ip, state = m.block247()
+		case 904:
+			ip, state = m.block904()
+		case 915:
+			ip, state = m.block915()
+		case 922:
+			ip, state = m.block922()
+		case 931:
+			ip, state = m.block931()
+		case 942:
+			ip, state = m.block942()
+		case 957:
+			ip, state = m.block957()
+		case 964:
+			ip, state = m.block964()
+		case 968:
+			ip, state = m.block968()
+		default:
+			state = interpreted
+		}
+	}
+
+	if state == interpreted {
+		return intcode.NewIntcodeFromSnapshot(m.snapshot(ip), onInput, onOutput).Run()
+	}
+	return nil
+}
+
+// exit describes how the execution of a block ends
+type exit int
+
+const (
+	running exit = iota
+	halted
+	interpreted
+)
+
+// maxDense is the size up to which the dense memory grows, larger positions being stored in
+// the sparse memory so that programs can access very large positions
+const maxDense = 1 << 20
+
+// machine holds the state of the transpiled program
+type machine struct {
+	memory   []int
+	sparse   map[int]int
+	rb       int
+	onInput  func() int
+	onOutput func(output int)
+}
+
+// memoryError is raised when the program accesses a negative position
+type memoryError int
+
+func (m *machine) load(position int) int {
+	if position < 0 {
+		panic(memoryError(position))
+	}
+	if position < len(m.memory) {
+		return m.memory[position]
+	}
+	return m.sparse[position]
+}
+
+// store stores value at position and returns true if the position holds a transpiled instruction
+func (m *machine) store(position, value int) bool {
+	if position < 0 {
+		panic(memoryError(position))
+	}
+	switch {
+	case position < len(m.memory):
+		m.memory[position] = value
+	case position < maxDense:
+		m.memory = append(m.memory, make([]int, position+1-len(m.memory))...)
+		m.memory[position] = value
+	default:
+		if m.sparse == nil {
+			m.sparse = make(map[int]int)
+		}
+		m.sparse[position] = value
+	}
+	return isCode(position)
+}
+
+// snapshot returns the state of the machine, from which the interpreter resumes at ip
+func (m *machine) snapshot(ip int) intcode.Snapshot {
+	cells := make(map[int]int, len(m.memory)+len(m.sparse))
+	for position, value := range m.memory {
+		cells[position] = value
+	}
+	for position, value := range m.sparse {
+		cells[position] = value
+	}
+	return intcode.Snapshot{
+		Registers: intcode.Registers{InstructionPointer: ip, RelativeBase: m.rb},
+		Cells:     cells,
+	}
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// isCode returns true if position holds a transpiled instruction
+func isCode(position int) bool {
+	switch {
+	case position >= 0 && position < 63:
+		return true
+	case position >= 65 && position < 256:
+		return true
+	case position >= 904 && position < 973:
+		return true
+	}
+	return false
+}
+
+func (m *machine) block0() (int, exit) {
+	// 0: mul 34463338, 34463338, [63]
+	m.store(63, 1187721666102244)
+	// 4: lt [63], 34463338, [63]
+	m.store(63, b2i(m.load(63) < 34463338))
+	// 8: jnz [63], 53
+	if m.load(63) != 0 {
+		return 53, running
+	}
+	return 11, running
+}
+
+func (m *machine) block11() (int, exit) {
+	// 11: mul 3, 1, [1000]
+	m.store(1000, 3)
+	// 15: arb 988
+	m.rb += 988
+	// 17: arb [rb+12]
+	m.rb += m.load(m.rb + 12)
+	// 19: arb [1000]
+	m.rb += m.load(1000)
+	// 21: arb [rb+6]
+	m.rb += m.load(m.rb + 6)
+	// 23: arb [rb+3]
+	m.rb += m.load(m.rb + 3)
+	// 25: in [rb+0]
+	if m.store(m.rb, m.onInput()) {
+		return 27, interpreted
+	}
+	// 27: eq [1000], 1, [63]
+	m.store(63, b2i(m.load(1000) == 1))
+	// 31: jnz [63], 65
+	if m.load(63) != 0 {
+		return 65, running
+	}
+	return 34, running
+}
+
+func (m *machine) block34() (int, exit) {
+	// 34: eq [1000], 2, [63]
+	m.store(63, b2i(m.load(1000) == 2))
+	// 38: jnz [63], 904
+	if m.load(63) != 0 {
+		return 904, running
+	}
+	return 41, running
+}
+
+func (m *machine) block41() (int, exit) {
+	// 41: eq [1000], 0, [63]
+	m.store(63, b2i(m.load(1000) == 0))
+	// 45: jnz [63], 58
+	if m.load(63) != 0 {
+		return 58, running
+	}
+	return 48, running
+}
+
+func (m *machine) block48() (int, exit) {
+	// 48: out [25]
+	m.onOutput(m.load(25))
+	// 50: out 0
+	m.onOutput(0)
+	// 52: halt
+	return 52, halted
+}
+
+func (m *machine) block53() (int, exit) {
+	// 53: out [0]
+	m.onOutput(m.load(0))
+	// 55: out 0
+	m.onOutput(0)
+	// 57: halt
+	return 57, halted
+}
+
+func (m *machine) block58() (int, exit) {
+	// 58: out [17]
+	m.onOutput(m.load(17))
+	// 60: out 0
+	m.onOutput(0)
+	// 62: halt
+	return 62, halted
+}
+
+func (m *machine) block65() (int, exit) {
+	// 65: mul 1, 30, [1010]
+	m.store(1010, 30)
+	// 69: mul 1, 38, [1008]
+	m.store(1008, 38)
+	// 73: mul 1, 0, [1020]
+	m.store(1020, 0)
+	// 77: mul 22, 1, [1007]
+	m.store(1007, 22)
+	// 81: mul 26, 1, [1015]
+	m.store(1015, 26)
+	// 85: mul 31, 1, [1013]
+	m.store(1013, 31)
+	// 89: mul 1, 27, [1014]
+	m.store(1014, 27)
+	// 93: add 0, 23, [1012]
+	m.store(1012, 23)
+	// 97: add 0, 37, [1006]
+	m.store(1006, 37)
+	// 101: mul 735, 1, [1028]
+	m.store(1028, 735)
+	// 105: mul 1, 24, [1009]
+	m.store(1009, 24)
+	// 109: mul 1, 28, [1019]
+	m.store(1019, 28)
+	// 113: mul 20, 1, [1017]
+	m.store(1017, 20)
+	// 117: add 34, 0, [1001]
+	m.store(1001, 34)
+	// 121: add 259, 0, [1026]
+	m.store(1026, 259)
+	// 125: add 0, 33, [1018]
+	m.store(1018, 33)
+	// 129: mul 1, 901, [1024]
+	m.store(1024, 901)
+	// 133: add 21, 0, [1016]
+	m.store(1016, 21)
+	// 137: add 36, 0, [1011]
+	m.store(1011, 36)
+	// 141: mul 730, 1, [1029]
+	m.store(1029, 730)
+	// 145: add 1, 0, [1021]
+	m.store(1021, 1)
+	// 149: mul 1, 509, [1022]
+	m.store(1022, 509)
+	// 153: mul 39, 1, [1005]
+	m.store(1005, 39)
+	// 157: add 35, 0, [1000]
+	m.store(1000, 35)
+	// 161: mul 1, 506, [1023]
+	m.store(1023, 506)
+	// 165: add 0, 892, [1025]
+	m.store(1025, 892)
+	// 169: add 256, 0, [1027]
+	m.store(1027, 256)
+	// 173: add 25, 0, [1002]
+	m.store(1002, 25)
+	// 177: mul 1, 29, [1004]
+	m.store(1004, 29)
+	// 181: mul 32, 1, [1003]
+	m.store(1003, 32)
+	// 185: arb 9
+	m.rb += 9
+	// 187: mul [rb-3], 1, [63]
+	m.store(63, m.load(m.rb-3)*1)
+	// 191: eq [63], 39, [63]
+	m.store(63, b2i(m.load(63) == 39))
+	// 195: jnz [63], 205
+	if m.load(63) != 0 {
+		return 205, running
+	}
+	return 198, running
+}
+
+func (m *machine) block198() (int, exit) {
+	// 198: add [64], 1, [64]
+	m.store(64, m.load(64)+1)
+	// 202: jz 0, 207
+	return 207, running
+}
+
+func (m *machine) block205() (int, exit) {
+	// 205: out [187]
+	m.onOutput(m.load(187))
+	return 207, running
+}
+
+func (m *machine) block207() (int, exit) {
+	// 207: mul [64], 2, [64]
+	m.store(64, m.load(64)*2)
+	// 211: arb -2
+	m.rb += -2
+	// 213: eq [rb-4], 35, [63]
+	m.store(63, b2i(m.load(m.rb-4) == 35))
+	// 217: jnz [63], 227
+	if m.load(63) != 0 {
+		return 227, running
+	}
+	return 220, running
+}
+
+func (m *machine) block220() (int, exit) {
+	// 220: add [64], 1, [64]
+	m.store(64, m.load(64)+1)
+	// 224: jnz 1, 229
+	return 229, running
+}
+
+func (m *machine) block227() (int, exit) {
+	// 227: out [213]
+	m.onOutput(m.load(213))
+	return 229, running
+}
+
+func (m *machine) block229() (int, exit) {
+	// 229: mul [64], 2, [64]
+	m.store(64, m.load(64)*2)
+	// 233: arb 5
+	m.rb += 5
+	// 235: jz [rb+8], 243
+	if m.load(m.rb+8) == 0 {
+		return 243, running
+	}
+	return 238, running
+}
+
+func (m *machine) block238() (int, exit) {
+	// 238: out [235]
+	m.onOutput(m.load(235))
+	// 240: jz 0, 247
+	return 247, running
+}
+
+func (m *machine) block243() (int, exit) {
+	// 243: add [64], 1, [64]
+	m.store(64, m.load(64)+1)
+	return 247, running
+}
+
+func (m *machine) block247() (int, exit) {
+	// 247: mul [64], 2, [64]
+	m.store(64, m.load(64)*2)
+	// 251: arb 14
+	m.rb += 14
+	// 253: jz 0, [rb+1]
+	return m.load(m.rb + 1), running
+}
+
+func (m *machine) block904() (int, exit) {
+	// 904: mul 27, 1, [rb+1]
+	if m.store(m.rb+1, 27) {
+		return 908, interpreted
+	}
+	// 908: add 0, 915, [rb+0]
+	if m.store(m.rb, 915) {
+		return 912, interpreted
+	}
+	// 912: jz 0, 922
+	return 922, running
+}
+
+func (m *machine) block915() (int, exit) {
+	// 915: add [rb+1], 55337, [rb+1]
+	if m.store(m.rb+1, m.load(m.rb+1)+55337) {
+		return 919, interpreted
+	}
+	// 919: out [rb+1]
+	m.onOutput(m.load(m.rb + 1))
+	// 921: halt
+	return 921, halted
+}
+
+func (m *machine) block922() (int, exit) {
+	// 922: arb 3
+	m.rb += 3
+	// 924: lt [rb-2], 3, [63]
+	m.store(63, b2i(m.load(m.rb-2) < 3))
+	// 928: jnz [63], 964
+	if m.load(63) != 0 {
+		return 964, running
+	}
+	return 931, running
+}
+
+func (m *machine) block931() (int, exit) {
+	// 931: add [rb-2], -1, [rb+1]
+	if m.store(m.rb+1, m.load(m.rb-2)+-1) {
+		return 935, interpreted
+	}
+	// 935: add 0, 942, [rb+0]
+	if m.store(m.rb, 942) {
+		return 939, interpreted
+	}
+	// 939: jnz 1, 922
+	return 922, running
+}
+
+func (m *machine) block942() (int, exit) {
+	// 942: mul [rb+1], 1, [rb-1]
+	if m.store(m.rb-1, m.load(m.rb+1)*1) {
+		return 946, interpreted
+	}
+	// 946: add [rb-2], -3, [rb+1]
+	if m.store(m.rb+1, m.load(m.rb-2)+-3) {
+		return 950, interpreted
+	}
+	// 950: mul 957, 1, [rb+0]
+	if m.store(m.rb, 957) {
+		return 954, interpreted
+	}
+	// 954: jnz 1, 922
+	return 922, running
+}
+
+func (m *machine) block957() (int, exit) {
+	// 957: add [rb+1], [rb-1], [rb-2]
+	if m.store(m.rb-2, m.load(m.rb+1)+m.load(m.rb-1)) {
+		return 961, interpreted
+	}
+	// 961: jz 0, 968
+	return 968, running
+}
+
+func (m *machine) block964() (int, exit) {
+	// 964: add [rb-2], 0, [rb-2]
+	if m.store(m.rb-2, m.load(m.rb-2)+0) {
+		return 968, interpreted
+	}
+	return 968, running
+}
+
+func (m *machine) block968() (int, exit) {
+	// 968: arb -3
+	m.rb += -3
+	// 970: jnz 1, [rb+0]
+	return m.load(m.rb), running
+}
@@ -0,0 +1,7 @@
+// Package transpiled holds Intcode programs transpiled to Go, which are used to check
+// that transpiled programs behave as the interpreted ones
+package transpiled
+
+//go:generate go run ../../../../cmd/intcode transpile -package day05 -o day05/day05.go ../../../../day05/day05.txt
+//go:generate go run ../../../../cmd/intcode transpile -package day09 -o day09/day09.go ../../../../day09/day09.txt
+//go:generate go run ../../../../cmd/intcode transpile -package highaddress -o highaddress/highaddress.go ../../testdata/highaddress.txt
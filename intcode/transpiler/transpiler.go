@@ -0,0 +1,410 @@
+package transpiler
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math"
+	"sort"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/analysis"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/instruction"
+)
+
+// valuesPerLine is the number of memory values per line of the generated image
+const valuesPerLine = 16
+
+// Transpile translates the program stored in memory into the source of a Go package named
+// packageName, which exposes a Run function equivalent to running the program with Intcode.
+//
+// Each basic block reachable by recursive descent becomes a function, and a dispatch loop
+// calls the function of the block at the instruction pointer. Execution falls back to the
+// interpreter when a transpiled instruction is written to, or when control reaches a
+// position which is not the start of a transpiled block. Arithmetic wraps around on
+// overflow, as Intcode does unless overflow detection is enabled.
+//
+// Only the standard instruction set is supported: the custom instructions of an
+// instruction.Set are invalid instructions for the transpiled code and its fallback, so
+// programs using them must be run with an Intcode configured with WithInstructionSet.
+func Transpile(memory []int, packageName string) ([]byte, error) {
+	graph, err := analysis.Build(memory)
+	if err != nil {
+		return nil, fmt.Errorf("could not build control flow graph: %w", err)
+	}
+
+	var blocks []*analysis.Block
+	for _, block := range graph.SortedBlocks() {
+		if len(block.Instructions) > 0 {
+			blocks = append(blocks, block)
+		}
+	}
+
+	t := &transpilation{code: codeSpans(blocks)}
+	fmt.Fprintf(&t.b, header, packageName, packageName)
+	t.writeImage(memory)
+	t.writeDispatch(blocks)
+	t.b.WriteString(runtime)
+	t.writeIsCode()
+	for _, block := range blocks {
+		t.writeBlock(block)
+	}
+
+	source, err := format.Source(t.b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("could not format generated source: %w", err)
+	}
+	return source, nil
+}
+
+// span is a range of memory positions from start, inclusive, to end, exclusive
+type span struct {
+	start, end int
+}
+
+// transpilation holds the state of the translation of a program
+type transpilation struct {
+	b bytes.Buffer
+	// code are the spans of memory holding transpiled instructions, in increasing order
+	code []span
+}
+
+// codeSpans returns the spans of memory holding the instructions of blocks
+func codeSpans(blocks []*analysis.Block) []span {
+	var spans []span
+	for _, block := range blocks {
+		for _, ins := range block.Instructions {
+			spans = append(spans, span{ins.Address, ins.Next()})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var merged []span
+	for _, s := range spans {
+		if len(merged) > 0 && s.start <= merged[len(merged)-1].end {
+			if s.end > merged[len(merged)-1].end {
+				merged[len(merged)-1].end = s.end
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// isCode returns true if position holds a transpiled instruction
+func (t *transpilation) isCode(position int) bool {
+	for _, s := range t.code {
+		if position >= s.start && position < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+const header = `// Code generated by intcode transpile. DO NOT EDIT.
+
+// Package %s runs an Intcode program transpiled to Go
+package %s
+
+import (
+	"fmt"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+)
+`
+
+const runtime = `
+// exit describes how the execution of a block ends
+type exit int
+
+const (
+	running exit = iota
+	halted
+	interpreted
+)
+
+// maxDense is the size up to which the dense memory grows, larger positions being stored in
+// the sparse memory so that programs can access very large positions
+const maxDense = 1 << 20
+
+// machine holds the state of the transpiled program
+type machine struct {
+	memory   []int
+	sparse   map[int]int
+	rb       int
+	onInput  func() int
+	onOutput func(output int)
+}
+
+// memoryError is raised when the program accesses a negative position
+type memoryError int
+
+func (m *machine) load(position int) int {
+	if position < 0 {
+		panic(memoryError(position))
+	}
+	if position < len(m.memory) {
+		return m.memory[position]
+	}
+	return m.sparse[position]
+}
+
+// store stores value at position and returns true if the position holds a transpiled instruction
+func (m *machine) store(position, value int) bool {
+	if position < 0 {
+		panic(memoryError(position))
+	}
+	switch {
+	case position < len(m.memory):
+		m.memory[position] = value
+	case position < maxDense:
+		m.memory = append(m.memory, make([]int, position+1-len(m.memory))...)
+		m.memory[position] = value
+	default:
+		if m.sparse == nil {
+			m.sparse = make(map[int]int)
+		}
+		m.sparse[position] = value
+	}
+	return isCode(position)
+}
+
+// snapshot returns the state of the machine, from which the interpreter resumes at ip
+func (m *machine) snapshot(ip int) intcode.Snapshot {
+	cells := make(map[int]int, len(m.memory)+len(m.sparse))
+	for position, value := range m.memory {
+		cells[position] = value
+	}
+	for position, value := range m.sparse {
+		cells[position] = value
+	}
+	return intcode.Snapshot{
+		Registers: intcode.Registers{InstructionPointer: ip, RelativeBase: m.rb},
+		Cells:     cells,
+	}
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+`
+
+func (t *transpilation) writeImage(memory []int) {
+	b := &t.b
+	b.WriteString("\nvar image = []int{\n")
+	for i, value := range memory {
+		fmt.Fprintf(b, "%d,", value)
+		if (i+1)%valuesPerLine == 0 || i == len(memory)-1 {
+			b.WriteString("\n")
+		} else {
+			b.WriteString(" ")
+		}
+	}
+	b.WriteString("}\n")
+}
+
+func (t *transpilation) writeDispatch(blocks []*analysis.Block) {
+	b := &t.b
+	b.WriteString(`
+// Run runs the program, calling onInput whenever it expects an input and onOutput whenever
+// it produces an output
+func Run(onInput func() int, onOutput func(output int)) (err error) {
+	m := &machine{
+		memory:   append([]int(nil), image...),
+		onInput:  onInput,
+		onOutput: onOutput,
+	}
+
+	defer func() {
+		r := recover()
+		if position, ok := r.(memoryError); ok {
+			err = fmt.Errorf("invalid memory position: %d", int(position))
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	ip, state := 0, running
+	for state == running {
+		switch ip {
+`)
+	for _, block := range blocks {
+		fmt.Fprintf(b, "case %d:\nip, state = m.block%d()\n", block.Start, block.Start)
+	}
+	b.WriteString(`default:
+			state = interpreted
+		}
+	}
+
+	if state == interpreted {
+		return intcode.NewIntcodeFromSnapshot(m.snapshot(ip), onInput, onOutput).Run()
+	}
+	return nil
+}
+`)
+}
+
+// writeIsCode writes the function that tells whether a position holds a transpiled instruction
+func (t *transpilation) writeIsCode() {
+	t.b.WriteString("\n// isCode returns true if position holds a transpiled instruction\n")
+	t.b.WriteString("func isCode(position int) bool {\nswitch {\n")
+	for _, s := range t.code {
+		fmt.Fprintf(&t.b, "case position >= %d && position < %d:\nreturn true\n", s.start, s.end)
+	}
+	t.b.WriteString("}\nreturn false\n}\n")
+}
+
+func (t *transpilation) writeBlock(block *analysis.Block) {
+	fmt.Fprintf(&t.b, "\nfunc (m *machine) block%d() (int, exit) {\n", block.Start)
+	defer t.b.WriteString("}\n")
+
+	for _, ins := range block.Instructions {
+		fmt.Fprintf(&t.b, "// %s\n", ins)
+		if returned := t.writeInstruction(ins); returned {
+			return
+		}
+	}
+
+	last := block.Last()
+	if block.Halts {
+		fmt.Fprintf(&t.b, "return %d, halted\n", last.Address)
+	} else if !alwaysJumps(last) {
+		fmt.Fprintf(&t.b, "return %d, running\n", last.Next())
+	}
+}
+
+// writeInstruction writes the statements of ins, returning true if they always return
+func (t *transpilation) writeInstruction(ins analysis.Instruction) bool {
+	switch ins.Opcode {
+	case instruction.AddOpcode, instruction.MultiplyOpcode,
+		instruction.LessThanOpcode, instruction.EqualsOpcode:
+		return t.writeStore(ins, 2, arithmetic(ins))
+	case instruction.InputOpcode:
+		return t.writeStore(ins, 0, "m.onInput()")
+	case instruction.OutputOpcode:
+		fmt.Fprintf(&t.b, "m.onOutput(%s)\n", read(ins, 0))
+	case instruction.AdjustRelativeBaseOpcode:
+		fmt.Fprintf(&t.b, "m.rb += %s\n", read(ins, 0))
+	case instruction.JumpIfTrueOpcode, instruction.JumpIfFalseOpcode:
+		t.writeJump(ins)
+	}
+	return false
+}
+
+// writeStore writes the store of value in the parameter i of ins, falling back to the
+// interpreter after ins if the store modifies a transpiled instruction. It returns true
+// if the store always modifies a transpiled instruction.
+func (t *transpilation) writeStore(ins analysis.Instruction, i int, value string) bool {
+	if ins.Modes[i] == instruction.Relative {
+		fmt.Fprintf(&t.b, "if m.store(%s, %s) {\nreturn %d, interpreted\n}\n",
+			relative(ins.Parameters[i]), value, ins.Next())
+		return false
+	}
+
+	position := ins.Parameters[i]
+	fmt.Fprintf(&t.b, "m.store(%d, %s)\n", position, value)
+	if t.isCode(position) {
+		fmt.Fprintf(&t.b, "return %d, interpreted\n", ins.Next())
+		return true
+	}
+	return false
+}
+
+func (t *transpilation) writeJump(ins analysis.Instruction) {
+	target := read(ins, 1)
+	if ins.Modes[0] != instruction.Immediate {
+		comparison := "!="
+		if ins.Opcode == instruction.JumpIfFalseOpcode {
+			comparison = "=="
+		}
+		fmt.Fprintf(&t.b, "if %s %s 0 {\nreturn %s, running\n}\n", read(ins, 0), comparison, target)
+		return
+	}
+
+	if alwaysJumps(ins) {
+		fmt.Fprintf(&t.b, "return %s, running\n", target)
+	}
+}
+
+// alwaysJumps returns true if ins is a jump whose condition always holds
+func alwaysJumps(ins analysis.Instruction) bool {
+	if ins.Modes[0] != instruction.Immediate {
+		return false
+	}
+	switch ins.Opcode {
+	case instruction.JumpIfTrueOpcode:
+		return ins.Parameters[0] != 0
+	case instruction.JumpIfFalseOpcode:
+		return ins.Parameters[0] == 0
+	default:
+		return false
+	}
+}
+
+// arithmetic returns the expression computed by an arithmetic or comparison instruction,
+// folding it when both operands are immediate so that it always wraps around on overflow
+func arithmetic(ins analysis.Instruction) string {
+	if ins.Modes[0] == instruction.Immediate && ins.Modes[1] == instruction.Immediate {
+		a, b := ins.Parameters[0], ins.Parameters[1]
+		switch ins.Opcode {
+		case instruction.AddOpcode:
+			return fmt.Sprint(a + b)
+		case instruction.MultiplyOpcode:
+			return fmt.Sprint(a * b)
+		case instruction.LessThanOpcode:
+			return fmt.Sprint(boolToInt(a < b))
+		default:
+			return fmt.Sprint(boolToInt(a == b))
+		}
+	}
+
+	a, b := read(ins, 0), read(ins, 1)
+	switch ins.Opcode {
+	case instruction.AddOpcode:
+		return fmt.Sprintf("%s + %s", a, b)
+	case instruction.MultiplyOpcode:
+		return fmt.Sprintf("%s * %s", a, b)
+	case instruction.LessThanOpcode:
+		return fmt.Sprintf("b2i(%s < %s)", a, b)
+	default:
+		return fmt.Sprintf("b2i(%s == %s)", a, b)
+	}
+}
+
+// read returns the expression of the value read by the parameter i of ins
+func read(ins analysis.Instruction, i int) string {
+	parameter := ins.Parameters[i]
+	switch ins.Modes[i] {
+	case instruction.Immediate:
+		return fmt.Sprint(parameter)
+	case instruction.Relative:
+		return fmt.Sprintf("m.load(%s)", relative(parameter))
+	default:
+		return fmt.Sprintf("m.load(%d)", parameter)
+	}
+}
+
+// relative returns the expression of the position at offset from the relative base
+func relative(offset int) string {
+	switch {
+	case offset == 0:
+		return "m.rb"
+	case offset == math.MinInt:
+		// the offset cannot be negated without overflowing
+		return fmt.Sprintf("m.rb+(%d)", offset)
+	case offset < 0:
+		return fmt.Sprintf("m.rb-%d", -offset)
+	default:
+		return fmt.Sprintf("m.rb+%d", offset)
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,189 @@
+package transpiler
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OctaviPascual/AdventOfCode2019/intcode"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/analysis"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/program"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/transpiler/internal/transpiled/day05"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/transpiler/internal/transpiled/day09"
+	"github.com/OctaviPascual/AdventOfCode2019/intcode/transpiler/internal/transpiled/highaddress"
+)
+
+func TestTranspile(t *testing.T) {
+	testCases := map[string]struct {
+		program  string
+		expected []string
+	}{
+		"arithmetic": {
+			program: "3,9,1002,9,-2,10,4,10,99,0,0",
+			expected: []string{
+				"func (m *machine) block0() (int, exit) {",
+				"\tm.store(9, m.onInput())\n",
+				"\tm.store(10, m.load(9)*-2)\n",
+				"\tm.onOutput(m.load(10))\n",
+				"\treturn 8, halted\n",
+			},
+		},
+		"constant folding": {
+			program: "1102,3037000500,3037000500,7,4,7,99,0",
+			expected: []string{
+				"\tm.store(7, -9223372036709301616)\n",
+			},
+		},
+		"conditional jump": {
+			program: "3,11,1005,11,8,104,0,99,104,1,99,0",
+			expected: []string{
+				"\tif m.load(11) != 0 {\n\t\treturn 8, running\n\t}\n\treturn 5, running\n",
+				"case 5:\n\t\t\tip, state = m.block5()\n",
+				"case 8:\n\t\t\tip, state = m.block8()\n",
+			},
+		},
+		"relative base": {
+			program: "109,10,21101,1,2,-1,204,-1,99",
+			expected: []string{
+				"\tm.rb += 10\n",
+				"\tif m.store(m.rb-1, 3) {\n\t\treturn 6, interpreted\n\t}\n",
+				"\tm.onOutput(m.load(m.rb - 1))\n",
+			},
+		},
+		"self-modifying": {
+			program: "1101,5,37,5,104,0,99",
+			expected: []string{
+				"\tm.store(5, 42)\n\treturn 4, interpreted\n}\n",
+				"\tcase position >= 0 && position < 7:\n",
+			},
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			memory, err := program.Parse(test.program)
+			require.NoError(t, err)
+
+			source, err := Transpile(memory, "example")
+			require.NoError(t, err)
+
+			assert.Contains(t, string(source), "package example\n")
+			for _, expected := range test.expected {
+				assert.Contains(t, string(source), expected)
+			}
+		})
+	}
+}
+
+func TestTranspileEmptyProgram(t *testing.T) {
+	_, err := Transpile(nil, "example")
+	assert.Error(t, err)
+}
+
+func TestTranspiledUpToDate(t *testing.T) {
+	testCases := map[string]string{
+		"day05":       "../../day05/day05.txt",
+		"day09":       "../../day09/day09.txt",
+		"highaddress": "testdata/highaddress.txt",
+	}
+
+	for name, filename := range testCases {
+		t.Run(name, func(t *testing.T) {
+			programString := readFile(t, filename)
+			memory, err := program.Parse(strings.TrimSuffix(programString, "\n"))
+			require.NoError(t, err)
+
+			source, err := Transpile(memory, name)
+			require.NoError(t, err)
+
+			generated := readFile(t, fmt.Sprintf("internal/transpiled/%s/%s.go", name, name))
+			assert.Equal(t, generated, string(source), "run go generate to update the transpiled programs")
+		})
+	}
+}
+
+func TestTranspiledTraces(t *testing.T) {
+	testCases := map[string]struct {
+		filename string
+		run      func(onInput func() int, onOutput func(output int)) error
+		inputs   []int
+	}{
+		"day05 part one": {filename: "../../day05/day05.txt", run: day05.Run, inputs: []int{1}},
+		"day05 part two": {filename: "../../day05/day05.txt", run: day05.Run, inputs: []int{5}},
+		"day09 part one": {filename: "../../day09/day09.txt", run: day09.Run, inputs: []int{1}},
+		"day09 part two": {filename: "../../day09/day09.txt", run: day09.Run, inputs: []int{2}},
+		// writes to very large positions, both transpiled and interpreted after modifying its code
+		"high address": {filename: "testdata/highaddress.txt", run: highaddress.Run},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			programString := strings.TrimSuffix(readFile(t, test.filename), "\n")
+
+			expected := trace(t, test.inputs, func(onInput func() int, onOutput func(output int)) error {
+				program, err := intcode.NewIntcodeProgram(programString, onInput, onOutput)
+				require.NoError(t, err)
+				return program.Run()
+			})
+			actual := trace(t, test.inputs, test.run)
+
+			assert.NotEmpty(t, expected)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+// FuzzTranspile checks that every program whose control flow graph can be built is
+// transpiled to valid Go source
+func FuzzTranspile(f *testing.F) {
+	for _, filename := range []string{"../../day02/day02.txt", "../../day05/day05.txt", "../../day09/day09.txt"} {
+		bytes, err := os.ReadFile(filename)
+		require.NoError(f, err)
+		f.Add(strings.TrimSuffix(string(bytes), "\n"))
+	}
+
+	f.Fuzz(func(t *testing.T, programString string) {
+		memory, err := program.Parse(programString)
+		if err != nil {
+			return
+		}
+		if _, err := analysis.Build(memory); err != nil {
+			return
+		}
+
+		_, err = Transpile(memory, "fuzzed")
+		require.NoError(t, err)
+	})
+}
+
+// trace runs a program with the given inputs and returns the sequence of its inputs and outputs
+func trace(
+	t *testing.T,
+	inputs []int,
+	run func(onInput func() int, onOutput func(output int)) error,
+) []string {
+	var events []string
+	onInput := func() int {
+		require.NotEmpty(t, inputs, "program expects more inputs than provided")
+		input := inputs[0]
+		inputs = inputs[1:]
+		events = append(events, fmt.Sprintf("input %d", input))
+		return input
+	}
+	onOutput := func(output int) {
+		events = append(events, fmt.Sprintf("output %d", output))
+	}
+
+	require.NoError(t, run(onInput, onOutput))
+	return events
+}
+
+func readFile(t *testing.T, filename string) string {
+	bytes, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	return string(bytes)
+}